@@ -4,26 +4,132 @@
 // for Go projects. It reads a version from a specified Go file (default "./version.go"),
 // bumps the version according to a given directive (e.g. "patch", "minor", "major", or an
 // explicit version string), stages the change, commits it with the bumped version as the commit
-// message (without the "v" prefix), and tags the commit with the bumped version (prefixed with "v").
+// message (without the "v" prefix), and tags the commit with the bumped version (prefixed with "v", or -tag-prefix).
 //
 // Command Usage:
 //
 //	goversion [flags] <version-bump>
+//	goversion [flags] -
+//	goversion [-C dir] discover
+//	goversion [flags] undo
+//	goversion [flags]
+//
+// Without a version bump, and with stdin a terminal (or with -interactive),
+// goversion shows the next patch, minor, major, and prerelease versions and
+// bumps to the one selected.
 //
 // Flags:
 //
+//	-C:            Runs as if started in the given directory: git and the post-bump script
+//	               run there and relative paths are resolved against it.
 //	-version-file: Specifies the path to the Go file containing the version declaration.
-//	               (Defaults to "./version.go")
+//	               (Defaults to "./version.go") Files without a .go extension are read
+//	               and written through their well-known version field if they have one
+//	               (e.g. setup.py, setup.cfg, package.json), and as plain text otherwise
+//	               (e.g. an embedded version.txt).
+//	-version-var:  Name of the var or const holding the version in a Go version file (default "Version").
+//	               A package qualifier (e.g. buildinfo.Version) is ignored.
 //	-file:         Specifies additional file(s) to be staged together with the version file.
-//	               This flag may be used multiple times.
-//	-bump-file:    Specifies additional file(s) to scan for the first semantic version and bump it.
-//	               This flag may be used multiple times. The found version is replaced with the same
-//	               version as the main version file. Only valid semver strings are matched (no "v" prefix).
+//	               This flag may be used multiple times. Each file must exist (after the
+//	               post-bump script runs, if one is set). A directory allows and commits
+//	               changes to any file beneath it.
+//	-files-from:   Reads more -file paths from a file, one per line, ignoring blank lines and # comments.
+//	-allow-missing-files: Warns about and skips -file paths that do not exist instead of failing.
+//	-mirror-file:  Additional Go file whose Version string literal is set to the new version. May be repeated.
+//	-bump-file:    Specifies additional file(s) to scan for the project version and bump it.
+//	               This flag may be used multiple times. Well-known version fields (package.json "version",
+//	               TOML "version =", Dockerfile "ARG VERSION=", etc.) are preferred, falling back to the first
+//	               semantic version in the file. The found version is replaced with the same version as the
+//	               main version file. A "v" prefix on a well-known field is preserved; the
+//	               first-semver fallback only matches versions without a "v" prefix.
+//	               Append "#key.path" to bump one YAML key (e.g. openapi.yaml#info.version).
+//	-prerelease-separator: Separator before the prerelease in -bump-file files (e.g. "." for 1.2.3.rc1).
+//	-reconcile:    Specifies an additional file that must hold the same version as the version file.
+//	               The bump is refused, listing each file's version, if they differ; otherwise the
+//	               file is bumped like a -bump-file. This flag may be used multiple times.
+//	-bump-yaml-list: Bumps list element versions that equal the file's own version
+//	               (e.g. Chart.yaml#dependencies[*].version). May be repeated.
+//	-bump-all-fields: Like -bump-file, but bumps every well-known version field in the file
+//	               (e.g. both version and appVersion in a Helm Chart.yaml). May be repeated.
+//	-changelog:    Adds a section for the release, listing the commits since the latest tag,
+//	               to the given Markdown changelog.
+//	-amend-changelog: Renames the changelog's "## [Unreleased]" heading to the release instead.
+//	-changelog-heading: The unreleased heading renamed by -amend-changelog.
+//	-changelog-unreleased: With -amend-changelog, adds a fresh, empty unreleased section above the release.
+//	-changelog-first-parent: Lists only mainline commits in the generated changelog section, leaving out
+//	               those of merged branches (git log --first-parent).
+//	-version-hook: Runs a script once the new version is computed, before any file is written.
+//	               A version it prints on stdout replaces the new version (e.g. for organization rules).
 //	-post-bump:    Specifies a script to execute after version bump but before git commit.
 //	               The script receives GOVERSION_OLD_VERSION and GOVERSION_NEW_VERSION environment variables.
 //	               Files created or modified by the script must be specified with -file to be included in the commit.
+//	-mod-tidy:     Runs "go mod tidy" after a major bump rewrites go.mod and commits go.sum too.
+//	               Skipped with a warning if go is not installed.
+//	-mod-tidy-required: Like -mod-tidy, but refuses to release if go is not installed.
+//	-build-check:  Runs "go build ./..." in the module before committing; if it fails, restores
+//	               the written files and aborts.
+//	-new-module-path: For major bumps, the module path to use verbatim instead of appending /vN.
+//	-mod-file:     For major bumps, the go.mod to update instead of the nearest one above the version file.
+//	-backup:       Copies each file to <file>.bak before modifying it. Backups are not committed or removed.
+//	-dry:          Reports what would change without modifying anything, warning if the tag already exists.
+//	               For each bump file, the field that would be replaced and its pattern are listed.
+//	-list-changed: Prints only the paths of files that would change, one per line (implies dry run).
+//	-base:         For from-commits, uses the commits in <ref>..HEAD instead of those since the latest tag.
+//	-from:         Computes the bump from the given version instead of the version file's value.
+//	-force:        With undo, removes the release even if its upstream branch already contains it.
+//	-cleanup-on-tag-failure: Undoes the release commit (git reset --soft HEAD~1) if tagging fails.
+//	-tag-prefix:   Prefix of release tags, added when tagging and stripped for from-git (default "v", or "<dir>/v" with -scope).
+//	-tag-pattern:  Only tags matching this glob are read from git (e.g. api/v*).
+//	-scope:        Releases only the module in the given directory, tagged <dir>/v<version> by default.
+//	-branch-tags-only: Only tags on the current branch's first-parent history are read from git
+//	               (with -tag-sort=topo).
+//	-tag-sort:     How from-git picks its tag: semver (default; highest version),
+//	               topo (nearest reachable from HEAD), or date (most recently created).
+//	-remote:       Remote whose copy of the branch HEAD must not be behind, instead of the
+//	               branch's upstream (default "origin" with -tracking-branch).
+//	-tracking-branch: Branch on -remote that HEAD must not be behind (default the current branch).
+//	-strict-ordering: Refuses a tag that sorts below the highest existing release tag instead of warning.
+//	-initial-version: Version to start from when the version file is missing and git has no tags.
+//	-summary-template: A text/template over VersionMeta printed instead of the default summary.
+//	-github-output: Appends new_version, old_version, tag, bump_type, and previous_tag to $GITHUB_OUTPUT.
+//	-commit-snapshot: Commits, but never tags, the files written by a snapshot bump.
+//	-no-tag-on-prerelease: Commits, but does not tag, a bump to a prerelease version.
+//	               Final releases are still tagged.
+//	-annotate:     Creates an annotated release tag with the release commit message.
+//	-commit-template: A text/template for the release commit message (e.g. 'Release {{.Tag}}'); -dry prints it.
+//	-conventional-message: Uses a Conventional Commits release commit message, chore(release): <version>.
+//	-conventional-scope: Scope of the -conventional-message (default "release").
+//	-trailer:      Adds a "Key: Value" trailer to the release commit message. May be repeated.
+//	-allow-empty:  Creates the release commit even if no file changed (unusual).
+//	-allow-dirty:  Skips the dirty working tree check, printing a warning instead.
+//	-require-clean-index-only: Only refuses to release over staged changes; unstaged ones are ignored.
+//	-no-create:    Fails if the version file does not exist instead of creating it.
+//	-no-gofmt:     Replaces only the Version literal in a Go version file, keeping its formatting.
+//	-lenient-version: Reads a version file holding only major.minor (e.g. 1.2) as major.minor.0.
+//	-keep-v-prefix: Writes the version into the version file with a leading "v".
+//	-timings:      Prints how long each phase of the release took to stderr.
+//	-signoff:      Adds a Signed-off-by trailer for the committer to the release commit.
+//	-credit-authors: Adds a Co-authored-by trailer to the release commit for each author since the latest tag.
+//	-git-backend:  Selects the git implementation; only exec (the git binary) is available in the goversion command.
+//	-git-bin:      Sets the git binary to run.
+//	-no-color:     Disables colored output (also disabled by NO_COLOR or when not writing to a terminal).
+//	-verbose:      Prints each file write, import rewrite, and git step to stderr as it happens.
+//	-interactive:  Picks the bump from a menu read from stdin, even when it is not a terminal.
+//	-check:        Runs every pre-release check without modifying anything.
+//	-ensure:       Idempotently releases an explicit version, performing only the missing
+//	               write, commit, and tag steps. Reports "Already at vX.Y.Z" when nothing is left.
+//	-idempotent-prerelease: Makes a prerelease bump a no-op if HEAD is the commit that set the
+//	               current prerelease, so a retried CI job does not bump twice.
+//	-require-branch: Refuses to release unless the current branch matches the given name.
+//	-allow-downgrade: Allows an explicit version lower than the current version.
+//	-compare-build-metadata: Orders versions of equal precedence by build metadata (non-standard).
+//	-print-config: Prints the effective configuration as JSON and exits without bumping.
 //	-version:      Displays the version of the goversion CLI tool and exits.
 //
+// Every option may also be set with a GOVERSION_<OPTION> environment variable
+// (e.g. GOVERSION_VERSION_FILE). Flags take precedence; repeatable options accept
+// a comma-separated list that is combined with any flag values.
+//
 // Examples:
 //
 //	# Bump the patch version (e.g. 1.2.3 → 1.2.4)
@@ -41,6 +147,9 @@
 //	# Bump a prerelease version (e.g. 1.2.4-0 → 1.2.4-1)
 //	goversion prerelease
 //
+//	# Start a prerelease of the current final version (e.g. 1.3.0 → 1.3.0-0)
+//	goversion prerelease-same
+//
 //	# Set an explicit version directly
 //	goversion 2.1.0
 //
@@ -50,6 +159,21 @@
 //	# Use a version from the latest Git tag
 //	goversion from-git
 //
+//	# Bump by the Conventional Commits since the latest tag
+//	goversion from-commits
+//
+//	# Write a snapshot version (e.g. 1.2.4-snapshot.abcdef0) without committing or tagging
+//	goversion snapshot
+//
+//	# Read the version bump from stdin
+//	echo minor | goversion -
+//
+//	# List the version files in the repository and suggest flags for them
+//	goversion discover
+//
+//	# Undo the unpushed release at HEAD, deleting its tag and restoring the previous version
+//	goversion undo
+//
 //	# Bump patch version and include README.md in the commit
 //	goversion -version-file=./version.go -file=README.md patch
 //
@@ -0,0 +1,631 @@
+// Package gogit provides a goversion Git backend in pure Go, built on go-git.
+// It lives in its own module so that programs that do not use it do not depend
+// on go-git. Importing it registers the backend as "go-git":
+//
+//	import _ "github.com/bcomnes/goversion/v2/gogit"
+//
+// after which Config.GitBackend may select it.
+//
+// The backend is a nested module rather than a build tag on the goversion
+// package so that go-git stays out of the goversion go.mod, and the goversion
+// command, which does not import it, only offers the "exec" backend. Its
+// tests, which also need a git binary to set up repositories, are gated behind
+// the gogit build tag:
+//
+//	go test -tags gogit ./...
+package gogit
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	goversion "github.com/bcomnes/goversion/v2/pkg"
+)
+
+func init() {
+	goversion.RegisterGitBackend("go-git", func(goversion.Config) goversion.Git { return Git{} })
+}
+
+// Git implements goversion.Git in pure Go with go-git, so that no git binary is
+// needed. It supports the subset of rev-parse and rev-list arguments that
+// goversion itself uses. Commits are never GPG signed, and hooks are not run.
+type Git struct{}
+
+// open returns the repository containing dir and its worktree. In a linked
+// worktree, refs and objects are read from and written to the repository
+// shared with the main checkout.
+func (Git) open(dir string) (*git.Repository, *git.Worktree, error) {
+	if dir == "" {
+		dir = "."
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open git repository at %s: %w", dir, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, wt, nil
+}
+
+// relPath returns p, which is absolute or relative to dir, as a slash-separated
+// path relative to the root of wt.
+func relPath(wt *git.Worktree, dir, p string) (string, error) {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	abs, err := canonicalPath(p)
+	if err != nil {
+		return "", err
+	}
+	root, err := canonicalPath(wt.Filesystem.Root())
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the repository at %s", p, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Add implements goversion.Git.
+func (g Git) Add(dir string, paths ...string) error {
+	_, wt, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		rel, err := relPath(wt, dir, p)
+		if err != nil {
+			return err
+		}
+		if _, err := wt.Add(rel); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Commit implements goversion.Git. The author and committer are read from the git
+// configuration (user.name and user.email).
+func (g Git) Commit(dir, message string, opts goversion.CommitOptions) error {
+	repo, wt, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	commitOpts := &git.CommitOptions{AllowEmptyCommits: opts.AllowEmpty}
+	if err := commitOpts.Validate(repo); err != nil {
+		return fmt.Errorf("failed to read commit author: %w", err)
+	}
+	trailers := opts.Trailers
+	if opts.Signoff {
+		trailers = append(slices.Clone(trailers), fmt.Sprintf("Signed-off-by: %s <%s>", commitOpts.Committer.Name, commitOpts.Committer.Email))
+	}
+	if len(trailers) > 0 {
+		message = strings.TrimRight(message, "\n") + "\n\n" + strings.Join(trailers, "\n")
+	}
+	if _, err := wt.Commit(message+"\n", commitOpts); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// ResetSoft implements goversion.Git.
+func (g Git) ResetSoft(dir, rev string) error {
+	repo, wt, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	return wt.Reset(&git.ResetOptions{Commit: *hash, Mode: git.SoftReset})
+}
+
+// ResetKeep implements goversion.Git with go-git's merge reset, which keeps uncommitted
+// changes rather than failing on them.
+func (g Git) ResetKeep(dir, rev string) error {
+	repo, wt, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	return wt.Reset(&git.ResetOptions{Commit: *hash, Mode: git.MergeReset})
+}
+
+// Tag implements goversion.Git.
+func (g Git) Tag(dir, name, rev, message string) error {
+	repo, _, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	var opts *git.CreateTagOptions
+	if message != "" {
+		opts = &git.CreateTagOptions{Message: message}
+	}
+	if _, err := repo.CreateTag(name, *hash, opts); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteTag implements goversion.Git.
+func (g Git) DeleteTag(dir, name string) error {
+	repo, _, err := g.open(dir)
+	if err != nil {
+		return err
+	}
+	if err := repo.DeleteTag(name); err != nil {
+		return fmt.Errorf("failed to delete tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// Tags implements goversion.Git. The pattern is matched with path.Match.
+func (g Git) Tags(dir, pattern string) ([]string, error) {
+	repo, _, err := g.open(dir)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ok, _ := path.Match(pattern, ref.Name().Short()); ok {
+			names = append(names, ref.Name().Short())
+		}
+		return nil
+	})
+	return names, err
+}
+
+// TagsByDate implements goversion.Git. Tags created at the same time are ordered by name.
+func (g Git) TagsByDate(dir, pattern string) ([]string, error) {
+	repo, _, err := g.open(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := g.Tags(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	created := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		ref, err := repo.Tag(name)
+		if err != nil {
+			return nil, err
+		}
+		if tag, err := repo.TagObject(ref.Hash()); err == nil {
+			created[name] = tag.Tagger.When
+		} else if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+			created[name] = commit.Committer.When
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(created[b].Compare(created[a]), strings.Compare(a, b))
+	})
+	return names, nil
+}
+
+// Status implements goversion.Git. Renames are reported as a deletion and an addition.
+func (g Git) Status(dir string) (string, error) {
+	_, wt, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for file, s := range status {
+		if s.Staging == git.Unmodified && s.Worktree == git.Unmodified {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%c%c %s\n", s.Staging, s.Worktree, file))
+	}
+	slices.SortFunc(lines, func(a, b string) int { return strings.Compare(a[3:], b[3:]) })
+	return strings.Join(lines, ""), nil
+}
+
+// Describe implements goversion.Git. opts.Match is matched with path.Match, which agrees
+// with git's glob matching for the patterns tag names typically use.
+func (g Git) Describe(dir string, opts goversion.DescribeOptions) (string, error) {
+	repo, _, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	tagged := map[plumbing.Hash][]string{}
+	refs, err := repo.Tags()
+	if err != nil {
+		return "", err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if opts.Match != "" {
+			if ok, _ := path.Match(opts.Match, name); !ok {
+				return nil
+			}
+		}
+		hash := ref.Hash()
+		if tag, err := repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				return nil
+			}
+			hash = commit.Hash
+		}
+		tagged[hash] = append(tagged[hash], name)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	var commits object.CommitIter
+	if opts.FirstParent {
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return "", err
+		}
+		commits = &firstParentIter{next: commit}
+	} else if commits, err = repo.Log(&git.LogOptions{From: head.Hash(), Order: git.LogOrderBSF}); err != nil {
+		return "", err
+	}
+	var found string
+	err = commits.ForEach(func(c *object.Commit) error {
+		if names := tagged[c.Hash]; len(names) > 0 {
+			slices.Sort(names)
+			found = names[0]
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", errors.New("no names found, cannot describe anything")
+	}
+	return found, nil
+}
+
+// firstParentIter walks from a commit through the first parent of each commit.
+type firstParentIter struct {
+	next *object.Commit
+	err  error
+}
+
+// Next implements object.CommitIter.
+func (it *firstParentIter) Next() (*object.Commit, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	if it.next == nil {
+		return nil, io.EOF
+	}
+	c := it.next
+	it.next = nil
+	if c.NumParents() > 0 {
+		it.next, it.err = c.Parent(0)
+	}
+	return c, nil
+}
+
+// ForEach implements object.CommitIter.
+func (it *firstParentIter) ForEach(cb func(*object.Commit) error) error {
+	for {
+		c, err := it.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := cb(c); err == storer.ErrStop {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Close implements object.CommitIter.
+func (it *firstParentIter) Close() {}
+
+// RevParse implements goversion.Git. It supports --show-toplevel, --short, "--abbrev-ref HEAD",
+// "--abbrev-ref --symbolic-full-name @{upstream}", "<rev>:<path>", and plain
+// revisions, which are peeled to a commit, so a "^{commit}" suffix is ignored;
+// --verify, --quiet, and -q are accepted and ignored.
+func (g Git) RevParse(dir string, args ...string) (string, error) {
+	repo, wt, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	var short, abbrevRef bool
+	var rev string
+	for _, arg := range args {
+		switch arg {
+		case "--verify", "--quiet", "-q", "--symbolic-full-name":
+		case "--short":
+			short = true
+		case "--abbrev-ref":
+			abbrevRef = true
+		case "--show-toplevel":
+			return canonicalPath(wt.Filesystem.Root())
+		default:
+			rev = strings.TrimSuffix(arg, "^{commit}")
+		}
+	}
+	switch {
+	case abbrevRef && rev == "HEAD":
+		head, err := repo.Head()
+		if err != nil {
+			return "", err
+		}
+		if !head.Name().IsBranch() {
+			return "HEAD", nil
+		}
+		return head.Name().Short(), nil
+	case rev == "@{upstream}" || rev == "@{u}":
+		upstream, err := gogitUpstream(repo)
+		if err != nil {
+			return "", err
+		}
+		return upstream.Short(), nil
+	}
+	if before, file, ok := strings.Cut(rev, ":"); ok {
+		commit, err := gogitCommit(repo, before)
+		if err != nil {
+			return "", err
+		}
+		f, err := commit.File(file)
+		if err != nil {
+			return "", fmt.Errorf("path %s does not exist in %s: %w", file, before, err)
+		}
+		return f.Hash.String(), nil
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s: %w", rev, err)
+	}
+	if short {
+		return hash.String()[:7], nil
+	}
+	return hash.String(), nil
+}
+
+// RevList implements goversion.Git. It supports "--count <a>..<b>" and "-1 <rev> -- <path>".
+func (g Git) RevList(dir string, args ...string) (string, error) {
+	repo, wt, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case len(args) == 2 && args[0] == "--count":
+		from, to, ok := strings.Cut(args[1], "..")
+		if !ok {
+			return "", fmt.Errorf("unsupported rev-list range %q", args[1])
+		}
+		exclude, err := gogitCommit(repo, from)
+		if err != nil {
+			return "", err
+		}
+		include, err := gogitCommit(repo, to)
+		if err != nil {
+			return "", err
+		}
+		seen := map[plumbing.Hash]bool{}
+		err = object.NewCommitPreorderIter(exclude, nil, nil).ForEach(func(c *object.Commit) error {
+			seen[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		count := 0
+		err = object.NewCommitPreorderIter(include, seen, nil).ForEach(func(*object.Commit) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(count), nil
+	case len(args) == 4 && args[0] == "-1" && args[2] == "--":
+		commit, err := gogitCommit(repo, args[1])
+		if err != nil {
+			return "", err
+		}
+		rel, err := relPath(wt, dir, args[3])
+		if err != nil {
+			return "", err
+		}
+		commits, err := repo.Log(&git.LogOptions{From: commit.Hash, FileName: &rel})
+		if err != nil {
+			return "", err
+		}
+		last, err := commits.Next()
+		if err != nil {
+			return "", fmt.Errorf("no commit touches %s", args[3])
+		}
+		return last.Hash.String(), nil
+	}
+	return "", fmt.Errorf("unsupported rev-list arguments %q", args)
+}
+
+// Log implements goversion.Git. It supports an optional "-1", then "--format=%s"
+// (subjects), "--format=%B" (full messages), "--format=%B%x00" (full messages,
+// each followed by a NUL), or "--format=%aN <%aE>" (authors, without mailmap),
+// optionally "--first-parent", and then a revision or an "<a>..<b>" range,
+// listing commits newest first.
+func (g Git) Log(dir string, args ...string) (string, error) {
+	repo, _, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	one := len(args) > 0 && args[0] == "-1"
+	if one {
+		args = args[1:]
+	}
+	firstParent := len(args) == 3 && args[1] == "--first-parent"
+	if firstParent {
+		args = []string{args[0], args[2]}
+	}
+	if len(args) != 2 || !slices.Contains([]string{"--format=%s", "--format=%B", "--format=%B%x00", "--format=%aN <%aE>"}, args[0]) {
+		return "", fmt.Errorf("unsupported log arguments %q", args)
+	}
+	seen := map[plumbing.Hash]bool{}
+	to := args[1]
+	if from, rest, ok := strings.Cut(args[1], ".."); ok {
+		to = rest
+		exclude, err := gogitCommit(repo, from)
+		if err != nil {
+			return "", err
+		}
+		err = object.NewCommitPreorderIter(exclude, nil, nil).ForEach(func(c *object.Commit) error {
+			seen[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	include, err := gogitCommit(repo, to)
+	if err != nil {
+		return "", err
+	}
+	var commits object.CommitIter = object.NewCommitIterCTime(include, seen, nil)
+	if firstParent {
+		commits = &firstParentIter{next: include}
+	}
+	var entries []string
+	err = commits.ForEach(func(c *object.Commit) error {
+		if seen[c.Hash] {
+			return storer.ErrStop
+		}
+		switch args[0] {
+		case "--format=%s":
+			subject, _, _ := strings.Cut(c.Message, "\n")
+			entries = append(entries, subject)
+		case "--format=%aN <%aE>":
+			entries = append(entries, fmt.Sprintf("%s <%s>", c.Author.Name, c.Author.Email))
+		case "--format=%B":
+			entries = append(entries, c.Message)
+		default:
+			entries = append(entries, c.Message+"\x00")
+		}
+		if one {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.Join(entries, "\n")), nil
+}
+
+// CheckIgnore implements goversion.Git. The returned rule is the matched path rather than
+// the pattern, which go-git does not expose.
+func (g Git) CheckIgnore(dir, p string) (string, error) {
+	_, wt, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := relPath(wt, dir, p)
+	if err != nil {
+		return "", err
+	}
+	patterns, err := gitignore.ReadPatterns(wt.Filesystem, nil)
+	if err != nil {
+		return "", err
+	}
+	patterns = append(patterns, wt.Excludes...)
+	if !gitignore.NewMatcher(patterns).Match(strings.Split(rel, "/"), false) {
+		return "", fmt.Errorf("%s is not ignored", p)
+	}
+	return "gitignore:" + rel, nil
+}
+
+// gogitCommit resolves rev, which may be "@{upstream}", to a commit.
+func gogitCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	if rev == "@{upstream}" || rev == "@{u}" {
+		upstream, err := gogitUpstream(repo)
+		if err != nil {
+			return nil, err
+		}
+		rev = upstream.String()
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %s: %w", rev, err)
+	}
+	return repo.CommitObject(*hash)
+}
+
+// gogitUpstream returns the remote-tracking branch configured for the current
+// branch, or an error if it has none.
+func gogitUpstream(repo *git.Repository) (plumbing.ReferenceName, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	if !head.Name().IsBranch() {
+		return "", errors.New("HEAD is not on a branch")
+	}
+	branch, err := repo.Branch(head.Name().Short())
+	if err != nil || branch.Remote == "" || branch.Merge == "" {
+		return "", fmt.Errorf("no upstream configured for branch %s", head.Name().Short())
+	}
+	if branch.Remote == "." {
+		return branch.Merge, nil
+	}
+	return plumbing.NewRemoteReferenceName(branch.Remote, branch.Merge.Short()), nil
+}
+
+// canonicalPath returns the absolute form of path with symbolic links in its
+// directory resolved, so that paths reported by go-git and given by the caller
+// compare equal. The file itself need not exist.
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		return filepath.Join(dir, filepath.Base(abs)), nil
+	}
+	return abs, nil
+}
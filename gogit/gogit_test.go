@@ -0,0 +1,345 @@
+//go:build gogit
+
+package gogit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	goversion "github.com/bcomnes/goversion/v2/pkg"
+)
+
+// initTestRepo creates a temporary git repository with a configured identity
+// and returns its path. Tests are skipped when git is unavailable.
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available on system")
+	}
+	dir := t.TempDir()
+	gitT(t, dir, "init")
+	gitT(t, dir, "config", "user.email", "test@example.com")
+	gitT(t, dir, "config", "user.name", "Test User")
+	gitT(t, dir, "config", "commit.gpgsign", "false")
+	gitT(t, dir, "config", "tag.gpgsign", "false")
+	return dir
+}
+
+// gitT runs git with args in dir, failing the test on error, and returns its trimmed output.
+func gitT(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitAllT stages everything in dir and commits it with msg.
+func commitAllT(t *testing.T, dir, msg string) {
+	t.Helper()
+	gitT(t, dir, "add", "-A")
+	gitT(t, dir, "commit", "-m", msg)
+}
+
+// writeFilesT writes each name/content pair under dir, creating parent directories.
+func writeFilesT(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestPatchBump verifies a patch release with the go-git backend in a
+// repository created with go-git, so that no git binary is involved.
+func TestPatchBump(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name = "Test User"
+	cfg.User.Email = "test@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "version.go"), []byte("package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.AddGlob("."); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("initial commit", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "build.log"), []byte("ignored\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := goversion.RunWithConfig(goversion.Config{
+		Dir:         dir,
+		VersionFile: "version.go",
+		VersionArg:  "patch",
+		ExtraFiles:  []string{"version.go"},
+		GitBackend:  "go-git",
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.NewVersion != "1.2.4" {
+		t.Errorf("NewVersion = %q, want 1.2.4", meta.NewVersion)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Message != "1.2.4\n" {
+		t.Errorf("commit message = %q, want %q", commit.Message, "1.2.4\n")
+	}
+	tag, err := repo.Tag("v1.2.4")
+	if err != nil {
+		t.Fatalf("tag v1.2.4 missing: %v", err)
+	}
+	if tag.Hash() != head.Hash() {
+		t.Errorf("tag v1.2.4 points at %s, want HEAD %s", tag.Hash(), head.Hash())
+	}
+	status, err := Git{}.Status(dir)
+	if err != nil || status != "" {
+		t.Errorf("expected a clean tree after the release, got %q (%v)", status, err)
+	}
+	if got, err := (Git{}).Describe(dir, goversion.DescribeOptions{}); err != nil || got != "v1.2.4" {
+		t.Errorf("Describe = %q (%v), want v1.2.4", got, err)
+	}
+}
+
+// TestReleaseInLinkedWorktree verifies that the go-git backend reads and
+// writes the refs a linked worktree shares with the main checkout.
+func TestReleaseInLinkedWorktree(t *testing.T) {
+	mainDir := initTestRepo(t)
+	writeFilesT(t, mainDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, mainDir, "initial commit")
+	worktree := filepath.Join(t.TempDir(), "release")
+	gitT(t, mainDir, "worktree", "add", "-b", "release", worktree)
+
+	meta, err := goversion.RunWithConfig(goversion.Config{
+		Dir:         worktree,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+		GitBackend:  "go-git",
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.Tag != "v1.3.0" {
+		t.Errorf("tag = %q, want v1.3.0", meta.Tag)
+	}
+	release := gitT(t, mainDir, "rev-parse", "release")
+	if tagged := gitT(t, mainDir, "rev-parse", "v1.3.0^{commit}"); tagged != release {
+		t.Errorf("tag v1.3.0 points at %s in the main checkout, want the release branch %s", tagged, release)
+	}
+	if v, _ := goversion.ValidateVersionFile(filepath.Join(mainDir, "version.go"), "Version"); v != "1.2.3" {
+		t.Errorf("main checkout version = %q, want it untouched", v)
+	}
+	if status := gitT(t, worktree, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean worktree, got:\n%s", status)
+	}
+}
+
+// TestTagSort verifies that the go-git backend orders tags like git for each
+// TagSort.
+func TestTagSort(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	at := func(date string) { t.Setenv("GIT_COMMITTER_DATE", date+"T12:00:00Z") }
+
+	// v3.0.0 is the highest, v2.0.0 the nearest to HEAD, and v1.5.0, annotated
+	// on a branch HEAD does not contain, the newest.
+	at("2020-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"0.1.0\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v3.0.0")
+	branch := gitT(t, tmpDir, "rev-parse", "--abbrev-ref", "HEAD")
+	gitT(t, tmpDir, "checkout", "-q", "-b", "maintenance")
+	at("2024-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"NOTES.md": "maintenance\n"})
+	commitAllT(t, tmpDir, "maintenance fix")
+	gitT(t, tmpDir, "tag", "-a", "-m", "v1.5.0", "v1.5.0")
+	gitT(t, tmpDir, "checkout", "-q", branch)
+	at("2021-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"README.md": "# foo\n"})
+	commitAllT(t, tmpDir, "docs")
+	gitT(t, tmpDir, "tag", "v2.0.0")
+	at("2022-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"lib.go": "package foo\n"})
+	commitAllT(t, tmpDir, "more work")
+
+	for sort, want := range map[string]string{"": "3.0.0", "topo": "2.0.0", "semver": "3.0.0", "date": "1.5.0"} {
+		meta, err := goversion.DryRunWithConfig(goversion.Config{
+			Dir:         tmpDir,
+			VersionFile: "version.go",
+			VersionArg:  "from-git",
+			TagSort:     sort,
+			GitBackend:  "go-git",
+		})
+		if err != nil {
+			t.Errorf("TagSort %q: DryRun failed: %v", sort, err)
+			continue
+		}
+		if meta.NewVersion != want {
+			t.Errorf("TagSort %q: NewVersion = %q, want %q", sort, meta.NewVersion, want)
+		}
+	}
+	if _, err := goversion.DryRunWithConfig(goversion.Config{Dir: tmpDir, VersionFile: "version.go", VersionArg: "from-git", TagSort: "alpha", GitBackend: "go-git"}); err == nil || !strings.Contains(err.Error(), "unknown tag sort") {
+		t.Errorf("DryRun error = %v, want an unknown tag sort", err)
+	}
+}
+
+// TestAnnotateTag verifies that the go-git backend creates annotated release
+// tags, both when releasing and when Ensure tags an existing release commit.
+func TestAnnotateTag(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := goversion.Config{
+		Dir:                 tmpDir,
+		VersionFile:         "version.go",
+		VersionArg:          "patch",
+		ConventionalMessage: true,
+		AnnotateTag:         true,
+		GitBackend:          "go-git",
+	}
+	if _, err := goversion.RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if kind := gitT(t, tmpDir, "cat-file", "-t", "v1.2.4"); kind != "tag" {
+		t.Errorf("v1.2.4 is a %s, want an annotated tag", kind)
+	}
+	if msg := gitT(t, tmpDir, "tag", "--list", "--format=%(contents)", "v1.2.4"); msg != "chore(release): 1.2.4" {
+		t.Errorf("v1.2.4 message = %q, want the commit message", msg)
+	}
+
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.3.0\"\n)\n"})
+	commitAllT(t, tmpDir, "release 1.3.0 by hand")
+	cfg.VersionArg = "1.3.0"
+	cfg.Ensure = true
+	if _, err := goversion.RunWithConfig(cfg); err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+	if kind := gitT(t, tmpDir, "cat-file", "-t", "v1.3.0"); kind != "tag" {
+		t.Errorf("v1.3.0 is a %s, want an annotated tag", kind)
+	}
+	if msg := gitT(t, tmpDir, "tag", "--list", "--format=%(contents)", "v1.3.0"); msg != "release 1.3.0 by hand" {
+		t.Errorf("v1.3.0 message = %q, want the commit message", msg)
+	}
+	if target := gitT(t, tmpDir, "rev-parse", "v1.3.0^{commit}"); target != gitT(t, tmpDir, "rev-parse", "HEAD") {
+		t.Errorf("v1.3.0 points at %s, want HEAD", target)
+	}
+}
+
+// TestFullRelease verifies a from-commits release with a changelog, credited
+// authors and an annotated tag through the go-git backend, and that Undo
+// reverts it, so the git arguments those steps pass stay supported.
+func TestFullRelease(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"CHANGELOG.md": "# Changelog\n\n## [1.2.3] - 2024-01-01\n\n- Initial release.\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v1.2.3")
+	released := gitT(t, tmpDir, "rev-parse", "HEAD")
+	writeFilesT(t, tmpDir, map[string]string{"a.go": "package foo\n"})
+	gitT(t, tmpDir, "add", "-A")
+	gitT(t, tmpDir, "commit", "-m", "feat: add a", "--author", "Ada Lovelace <ada@example.com>")
+	writeFilesT(t, tmpDir, map[string]string{"b.go": "package foo\n"})
+	commitAllT(t, tmpDir, "fix: correct b")
+	before := gitT(t, tmpDir, "rev-parse", "HEAD")
+
+	cfg := goversion.Config{
+		Dir:           tmpDir,
+		VersionFile:   "version.go",
+		VersionArg:    "from-commits",
+		Changelog:     "CHANGELOG.md",
+		CreditAuthors: true,
+		AnnotateTag:   true,
+		GitBackend:    "go-git",
+	}
+	meta, err := goversion.RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.NewVersion != "1.3.0" {
+		t.Errorf("NewVersion = %q, want 1.3.0", meta.NewVersion)
+	}
+	changelog, err := os.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantChangelog := "# Changelog\n\n## [1.3.0] - " + time.Now().Format("2006-01-02") + "\n\n- fix: correct b\n- feat: add a\n\n## [1.2.3] - 2024-01-01\n\n- Initial release.\n"
+	if string(changelog) != wantChangelog {
+		t.Errorf("CHANGELOG.md = %q, want %q", changelog, wantChangelog)
+	}
+	got := gitT(t, tmpDir, "log", "-1", "--format=%(trailers:key=Co-authored-by,valueonly)")
+	if want := "Test User <test@example.com>\nAda Lovelace <ada@example.com>"; got != want {
+		t.Errorf("Co-authored-by trailers = %q, want %q", got, want)
+	}
+	if kind := gitT(t, tmpDir, "cat-file", "-t", "v1.3.0"); kind != "tag" {
+		t.Errorf("v1.3.0 is a %s, want an annotated tag", kind)
+	}
+	if tagged, head := gitT(t, tmpDir, "rev-parse", "v1.3.0^{commit}"), gitT(t, tmpDir, "rev-parse", "HEAD"); tagged != head {
+		t.Errorf("tag v1.3.0 points at %s, want HEAD %s", tagged, head)
+	}
+
+	undone, err := goversion.Undo(cfg)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if undone.Tag != "v1.3.0" || undone.OldVersion != "1.3.0" || undone.NewVersion != "1.2.3" {
+		t.Errorf("Undo = %+v, want v1.3.0 undone back to 1.2.3", undone)
+	}
+	if head := gitT(t, tmpDir, "rev-parse", "HEAD"); head != before {
+		t.Errorf("HEAD = %s after Undo, want %s", head, before)
+	}
+	if tags := gitT(t, tmpDir, "tag", "--list"); tags != "v1.2.3" {
+		t.Errorf("tags after Undo = %q, want only v1.2.3", tags)
+	}
+	if v, _ := goversion.ValidateVersionFile(filepath.Join(tmpDir, "version.go"), "Version"); v != "1.2.3" {
+		t.Errorf("version after Undo = %q, want 1.2.3", v)
+	}
+	if got := gitT(t, tmpDir, "rev-parse", "v1.2.3"); got != released {
+		t.Errorf("v1.2.3 = %s after Undo, want %s", got, released)
+	}
+}
@@ -3,11 +3,20 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path"
 	"slices"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	goversion "github.com/bcomnes/goversion/v2/pkg"
 )
@@ -23,21 +32,110 @@ func (a *arrayFlags) Set(value string) error {
 	return nil
 }
 
+// envPrefix is prepended to a flag's upper-cased name, with dashes replaced by
+// underscores, to form the environment variable that provides its default
+// (e.g. GOVERSION_VERSION_FILE for -version-file).
+const envPrefix = "GOVERSION_"
+
+// applyEnvDefaults sets flags from their GOVERSION_* environment variables.
+// It must run before flag parsing so command-line values take precedence.
+// Repeatable flags accept a comma-separated list and are combined with any
+// values given on the command line.
+func applyEnvDefaults(fs *flag.FlagSet, skip ...string) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || slices.Contains(skip, f.Name) {
+			return
+		}
+		key := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		val, ok := os.LookupEnv(key)
+		if !ok {
+			return
+		}
+		values := []string{val}
+		if _, isList := f.Value.(*arrayFlags); isList {
+			values = strings.Split(val, ",")
+		}
+		for _, v := range values {
+			if setErr := f.Value.Set(strings.TrimSpace(v)); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %v", val, key, setErr)
+				return
+			}
+		}
+	})
+	return err
+}
+
+// noColor disables ANSI colors regardless of the terminal; set by -no-color.
+var noColor bool
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// colorEnabled reports whether ANSI colors should be written to f: only when f is
+// a terminal and neither -no-color nor the NO_COLOR environment variable is set.
+func colorEnabled(f *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal reports whether f is a terminal: a character device other than
+// the null device, which is what a detached or redirected stdin often is.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
+// paint wraps s in the given ANSI color code if colors are enabled for f.
+func paint(f *os.File, color, s string) string {
+	if !colorEnabled(f) {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// printError writes args to stderr after an "Error:" label, in red on a terminal.
+func printError(args ...any) {
+	fmt.Fprintln(os.Stderr, append([]any{paint(os.Stderr, ansiRed, "Error:")}, args...)...)
+}
+
 func usage() {
 	msg := `Usage:
   goversion [options] <version-bump>
+  goversion [options]    (in a terminal, or with -interactive: pick the bump from a menu)
 
 Bumps the version in a Go source file (default: ./version.go), commits the change with the version string (no "v" prefix),
-and tags the commit with the version prefixed with "v". For major version bumps >= v2, go.mod and all self references are also updated.
+and tags the commit with the version prefixed with "v" (see -tag-prefix). For major version bumps >= v2, go.mod and all self references are also updated.
 
 Examples:
   goversion minor
   goversion 1.2.3
+  goversion -ensure 1.2.3
+  goversion -C ../my-module patch
+  goversion discover
+  goversion undo
+  echo minor | goversion -
   goversion -bump-file package.json -bump-file Cargo.toml patch
   goversion -post-bump ./scripts/update-docs.sh -file docs/version.md patch
+  GOVERSION_BUMP_FILE=package.json goversion -print-config
+
+Every option can also be set with a GOVERSION_<OPTION> environment variable (e.g. GOVERSION_VERSION_FILE).
+Command-line flags take precedence; repeatable options take a comma-separated list and are combined with flags.
 
 Positional arguments:
-  <version-bump>     One of: major, minor, patch, premajor, preminor, prepatch, prerelease, from-git, or an explicit version like 1.2.3
+  <version-bump>     One of: major, minor, patch, premajor, preminor, prepatch, prerelease, prerelease-same, from-git, from-commits, snapshot, or an explicit version like 1.2.3
+  -                  Read the <version-bump> from stdin (e.g. from a tool that computes the bump)
+  discover           Instead of bumping, list the version files found in the repository and suggest flags for them
+  undo               Instead of bumping, delete the tag of the unpushed release at HEAD and undo its commit
 
 Options:
 `
@@ -45,19 +143,285 @@ Options:
 	flag.PrintDefaults()
 }
 
+// printEvent writes a one-line description of a progress event to stderr.
+func printEvent(e goversion.Event) {
+	switch e.Kind {
+	case goversion.EventFileWritten:
+		fmt.Fprintf(os.Stderr, "wrote %s\n", e.Path)
+	case goversion.EventImportRewritten:
+		fmt.Fprintf(os.Stderr, "rewrote imports in %s\n", e.Path)
+	case goversion.EventGitStep:
+		fmt.Fprintf(os.Stderr, "git %s\n", e.Step)
+	}
+}
+
+// printTimings writes the duration of each phase to w, slowest first, with the
+// total last. It writes nothing if timings is empty.
+func printTimings(w io.Writer, timings map[string]time.Duration) {
+	if len(timings) == 0 {
+		return
+	}
+	phases := slices.DeleteFunc(slices.Collect(maps.Keys(timings)), func(p string) bool { return p == "total" })
+	slices.SortFunc(phases, func(a, b string) int { return cmp.Or(cmp.Compare(timings[b], timings[a]), cmp.Compare(a, b)) })
+	fmt.Fprintln(w, "Timings:")
+	for _, p := range phases {
+		fmt.Fprintf(w, "  %-14s %v\n", p, timings[p].Round(time.Microsecond))
+	}
+	if total, ok := timings["total"]; ok {
+		fmt.Fprintf(w, "  %-14s %v\n", "total", total.Round(time.Microsecond))
+	}
+}
+
+// parseSummaryTemplate parses text as a text/template over goversion.VersionMeta.
+// The template is executed once against an empty VersionMeta so that references
+// to unknown fields are reported before any changes are made.
+func parseSummaryTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("summary").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid summary template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, goversion.VersionMeta{}); err != nil {
+		return nil, fmt.Errorf("invalid summary template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderSummary writes the summary template executed against meta to w,
+// adding a trailing newline if the template does not end with one.
+func renderSummary(w io.Writer, tmpl *template.Template, meta goversion.VersionMeta) error {
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, meta); err != nil {
+		return fmt.Errorf("rendering summary template: %w", err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// suggestVersionFile returns the candidate best suited as the version file: the
+// shallowest version.go, then the shallowest VERSION file, then the first candidate.
+func suggestVersionFile(candidates []goversion.VersionFileCandidate) goversion.VersionFileCandidate {
+	depth := func(c goversion.VersionFileCandidate) int { return strings.Count(c.Path, "/") }
+	for _, name := range []string{"version.go", "VERSION"} {
+		var best *goversion.VersionFileCandidate
+		for i, c := range candidates {
+			if path.Base(c.Path) == name && (best == nil || depth(c) < depth(*best)) {
+				best = &candidates[i]
+			}
+		}
+		if best != nil {
+			return *best
+		}
+	}
+	return candidates[0]
+}
+
+// printDiscovery writes the version files found by discover and a suggested
+// command line, with the same settings as GOVERSION_* environment variables.
+// dir is the -C directory the candidate paths are relative to, if any.
+func printDiscovery(w io.Writer, dir string, candidates []goversion.VersionFileCandidate) {
+	if len(candidates) == 0 {
+		fmt.Fprintln(w, "No version files found.")
+		return
+	}
+	fmt.Fprintln(w, "Detected version files:")
+	width := 0
+	for _, c := range candidates {
+		width = max(width, len(c.Path))
+	}
+	for _, c := range candidates {
+		fmt.Fprintf(w, "  %-*s  %s (%s)\n", width, c.Path, c.Version, c.Pattern)
+	}
+
+	versionFile := suggestVersionFile(candidates)
+	args := []string{"goversion"}
+	if dir != "" {
+		args = append(args, "-C", dir)
+	}
+	args = append(args, "-version-file="+versionFile.Path)
+	var bumpFiles, differing []string
+	for _, c := range candidates {
+		if c.Path == versionFile.Path {
+			continue
+		}
+		bumpFiles = append(bumpFiles, c.Path)
+		args = append(args, "-bump-file="+c.Path)
+		if c.Version != versionFile.Version {
+			differing = append(differing, c.Path)
+		}
+	}
+	args = append(args, "patch")
+
+	fmt.Fprintln(w, "\nSuggested command:")
+	fmt.Fprintf(w, "  %s\n", strings.Join(args, " "))
+	fmt.Fprintln(w, "\nOr as environment variables:")
+	fmt.Fprintf(w, "  %sVERSION_FILE=%s\n", envPrefix, versionFile.Path)
+	if len(bumpFiles) > 0 {
+		fmt.Fprintf(w, "  %sBUMP_FILE=%s\n", envPrefix, strings.Join(bumpFiles, ","))
+	}
+	if len(differing) > 0 {
+		fmt.Fprintf(w, "\nNote: the version in %s differs from %s; make sure it tracks the project version before bumping it.\n",
+			strings.Join(differing, ", "), versionFile.Version)
+	}
+}
+
+// readVersionArg reads a version-bump directive, such as "minor" or "1.2.3",
+// from r. Surrounding whitespace is ignored; anything but a single word is an
+// error. The directive itself is validated later like a positional argument.
+func readVersionArg(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the version directive from stdin: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	switch len(fields) {
+	case 0:
+		return "", fmt.Errorf("no version directive on stdin")
+	case 1:
+		return fields[0], nil
+	default:
+		return "", fmt.Errorf("expected a single version directive on stdin, got %q", strings.TrimSpace(string(data)))
+	}
+}
+
+// pickKeywords are the bump keywords offered by pickVersionArg, in menu order.
+var pickKeywords = []string{"patch", "minor", "major", "prerelease"}
+
+// pickVersionArg shows the version each of pickKeywords would produce from
+// current on w, and reads the choice, a menu number or a keyword, from r.
+func pickVersionArg(r io.Reader, w io.Writer, current string) (string, error) {
+	next, err := goversion.NextVersions(current)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(w, "Current version: %s\n", current)
+	for i, keyword := range pickKeywords {
+		fmt.Fprintf(w, "  %d) %-10s %s\n", i+1, keyword, next[keyword])
+	}
+	fmt.Fprintf(w, "Select a version bump [1-%d]: ", len(pickKeywords))
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no version bump selected")
+	}
+	choice := strings.TrimSpace(line)
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(pickKeywords) {
+		return pickKeywords[n-1], nil
+	}
+	if slices.Contains(pickKeywords, choice) {
+		return choice, nil
+	}
+	return "", fmt.Errorf("invalid selection %q", choice)
+}
+
+// writeGitHubOutput appends the result of a run to path, the file named by
+// $GITHUB_OUTPUT in GitHub Actions, as key=value lines.
+func writeGitHubOutput(path string, meta goversion.VersionMeta) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening GITHUB_OUTPUT: %w", err)
+	}
+	_, err = fmt.Fprintf(f, "new_version=%s\nold_version=%s\ntag=%s\nbump_type=%s\nprevious_tag=%s\n",
+		meta.NewVersion, meta.OldVersion, meta.Tag, meta.BumpType, meta.PreviousTag)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing GITHUB_OUTPUT: %w", err)
+	}
+	return nil
+}
+
 func main() {
 	// Define flags.
-	versionFile := flag.String("version-file", "./version.go", "Path to the Go file containing the version declaration")
+	dir := flag.String("C", "", "Run as if goversion was started in this directory: git runs there and relative paths are resolved against it")
+	versionVar := flag.String("version-var", "", "Name of the var or const holding the version in a Go version file (default \"Version\"); a package qualifier, as in buildinfo.Version, is ignored")
+	versionFile := flag.String("version-file", "./version.go", "Path to the Go file containing the version declaration, a manifest with a version field (e.g. setup.py), or a plain-text file (e.g. version.txt) holding only the version")
 	var extraFiles arrayFlags
-	flag.Var(&extraFiles, "file", "Additional file to stage and commit. May be repeated.")
+	flag.Var(&extraFiles, "file", "Additional file, or directory, to stage and commit. May be repeated.")
+	filesFrom := flag.String("files-from", "", "File listing more files to stage and commit, one path per line; blank lines and # comments are ignored")
+	allowMissingFiles := flag.Bool("allow-missing-files", false, "Warn about and skip -file paths that do not exist instead of refusing to release")
+	var mirrorFiles arrayFlags
+	flag.Var(&mirrorFiles, "mirror-file", "Additional Go file whose Version string literal is set to the new version and committed. May be repeated.")
 	var bumpFiles arrayFlags
-	flag.Var(&bumpFiles, "bump-file", "Additional file to scan for first semver and bump it. May be repeated.")
+	flag.Var(&bumpFiles, "bump-file", "Additional file to scan for first semver and bump it, or file#key.path to bump one YAML key. May be repeated.")
+	prereleaseSeparator := flag.String("prerelease-separator", "-", "Separator between the version and its prerelease in -bump-file files, e.g. '.' for 1.2.3.rc1 or '_' for 1.2.3_beta")
+	var reconcile arrayFlags
+	flag.Var(&reconcile, "reconcile", "Additional file that must hold the same version as the version file before bumping, and is then bumped like a -bump-file. May be repeated.")
+	var bumpYAMLLists arrayFlags
+	flag.Var(&bumpYAMLLists, "bump-yaml-list", "file#list[*].key whose list element versions are bumped where they equal the file's own version (e.g. Chart.yaml#dependencies[*].version). May be repeated.")
+	var bumpAllFields arrayFlags
+	flag.Var(&bumpAllFields, "bump-all-fields", "Additional file in which every main version field (e.g. Helm's version and appVersion) is bumped. May be repeated.")
+	changelog := flag.String("changelog", "", "Markdown changelog to add a section for the release to, listing the commit subjects since the latest tag")
+	amendChangelog := flag.Bool("amend-changelog", false, "Rename the changelog's unreleased section to the release instead of adding a generated section")
+	changelogHeading := flag.String("changelog-heading", "", "Heading of the unreleased section renamed by -amend-changelog (default \"## [Unreleased]\")")
+	changelogUnreleased := flag.Bool("changelog-unreleased", false, "With -amend-changelog, add a fresh, empty unreleased section above the release")
+	changelogFirstParent := flag.Bool("changelog-first-parent", false, "List only mainline commits in the generated changelog section (git log --first-parent), leaving out those of merged branches")
+	versionHook := flag.String("version-hook", "", "Script run once the new version is computed, before any file is written. Receives GOVERSION_OLD_VERSION, GOVERSION_NEW_VERSION, and GOVERSION_BUMP_TYPE; a version it prints on stdout replaces the new version.")
 	postBump := flag.String("post-bump", "", "Script to execute after version bump but before git commit. Receives GOVERSION_OLD_VERSION and GOVERSION_NEW_VERSION env vars.")
+	modTidy := flag.Bool("mod-tidy", false, "After a major bump rewrites go.mod and imports, run 'go mod tidy' and commit go.sum too; skipped with a warning if go is not installed")
+	modTidyRequired := flag.Bool("mod-tidy-required", false, "Like -mod-tidy, but refuse to release if go is not installed")
+	buildCheck := flag.Bool("build-check", false, "Run 'go build ./...' in the module after bumping and, if it fails, restore the written files and abort before committing")
+	backup := flag.Bool("backup", false, "Copy each file to <file>.bak before modifying it; backups are not committed or removed")
 	dryRun := flag.Bool("dry", false, "Perform a dry run without modifying any files or git repository")
+	requireBranch := flag.String("require-branch", "", "Refuse to release unless the current branch matches this name (e.g. main)")
+	allowDowngrade := flag.Bool("allow-downgrade", false, "Allow an explicit version lower than the current version")
+	compareBuild := flag.Bool("compare-build-metadata", false, "Order versions of equal precedence by build metadata, compared lexically (non-standard)")
+	newModulePath := flag.String("new-module-path", "", "Major bumps only: module path to write to go.mod and rewrite self-imports to, instead of appending /vN")
+	modFile := flag.String("mod-file", "", "Major bumps only: the go.mod to update and whose module's self-imports to rewrite, instead of the nearest one above the version file")
+	listChanged := flag.Bool("list-changed", false, "Print only the paths of files that would change, one per line, and exit (implies -dry)")
+	force := flag.Bool("force", false, "With undo, remove the release even if its upstream branch already contains it")
+	cleanupOnTagFailure := flag.Bool("cleanup-on-tag-failure", false, "If tagging fails after committing, undo the release commit with 'git reset --soft HEAD~1', keeping its changes staged")
+	tagPrefix := flag.String("tag-prefix", "", "Prefix of release tags, followed by the version (e.g. release-) (default \"v\", or \"<scope>/v\" with -scope)")
+	tagPattern := flag.String("tag-pattern", "", "Only consider tags matching this glob when reading the version from git (e.g. api/v*)")
+	scope := flag.String("scope", "", "Release only the module in this directory of a monorepo: uncommitted changes elsewhere are ignored, and tags default to <dir>/v*")
+	remote := flag.String("remote", "", "Remote whose copy of the branch HEAD must not be behind, instead of the branch's upstream (default \"origin\" with -tracking-branch)")
+	trackingBranch := flag.String("tracking-branch", "", "Branch on -remote that HEAD must not be behind, instead of the branch's upstream (default: the current branch)")
+	strictOrdering := flag.Bool("strict-ordering", false, "Refuse to create a tag that sorts below the highest existing release tag (e.g. v1.2.4 when v2.0.0 exists) instead of warning")
+	branchTagsOnly := flag.Bool("branch-tags-only", false, "Only consider tags on the current branch's first-parent history when reading the version from git (with -tag-sort=topo)")
+	tagSort := flag.String("tag-sort", "", "How from-git picks its tag: semver (default; the highest version), topo (the nearest reachable from HEAD), or date (the most recently created)")
+	base := flag.String("base", "", "For from-commits: compute the bump from the commits in <ref>..HEAD instead of those since the latest tag (e.g. -base origin/main)")
+	from := flag.String("from", "", "Compute the bump from this version instead of the version file's current value (e.g. -from 1.5.0 minor)")
+	initialVersion := flag.String("initial-version", "", "Version to start from when the version file is missing and git has no tags (default 0.0.0)")
+	summaryTemplate := flag.String("summary-template", "", "Go text/template over the result (e.g. '{{.NewVersion}}') printed instead of the default summary")
+	githubOutput := flag.Bool("github-output", false, "Append new_version, old_version, tag, bump_type, and previous_tag to the file named by $GITHUB_OUTPUT")
+	commitSnapshot := flag.Bool("commit-snapshot", false, "Commit, but do not tag, the files written by a snapshot bump")
+	noTagOnPrerelease := flag.Bool("no-tag-on-prerelease", false, "Commit, but do not tag, a bump to a prerelease version; final releases are still tagged")
+	annotateTag := flag.Bool("annotate", false, "Create an annotated release tag, with the release commit message, instead of a lightweight one")
+	commitTemplate := flag.String("commit-template", "", "Go text/template for the release commit message over .OldVersion, .NewVersion, .Tag, and .BumpType (e.g. 'Release {{.Tag}}'); the default message is the new version")
+	conventionalMessage := flag.Bool("conventional-message", false, "Use a Conventional Commits release commit message, chore(release): <version>; cannot be combined with -commit-template")
+	conventionalScope := flag.String("conventional-scope", "", "Scope of the -conventional-message (default \"release\")")
+	var trailers arrayFlags
+	flag.Var(&trailers, "trailer", "Trailer to add to the release commit message, as \"Key: Value\" (e.g. \"Release-As: 2.0.0\"). May be repeated.")
+	allowEmpty := flag.Bool("allow-empty", false, "Create the release commit even if no file changed (git commit --allow-empty); unusual, for symbolic releases")
+	timings := flag.Bool("timings", false, "Print how long each phase of the release took to stderr")
+	noCreate := flag.Bool("no-create", false, "Fail if the version file does not exist instead of creating it (guards against a mistyped -version-file)")
+	noGofmt := flag.Bool("no-gofmt", false, "Replace only the Version string literal in a Go version file, keeping its formatting exactly, instead of rewriting the file in gofmt'd form")
+	lenientVersion := flag.Bool("lenient-version", false, "Read a version file holding only major.minor (e.g. 1.2) as major.minor.0; the full version is written back")
+	keepVPrefix := flag.Bool("keep-v-prefix", false, "Write the version into the version file with a leading \"v\" (e.g. v1.2.3); the tag still has exactly one \"v\"")
+	allowDirty := flag.Bool("allow-dirty", false, "Release even if files other than the ones being released have uncommitted changes (prints a warning; those changes are not committed)")
+	requireCleanIndexOnly := flag.Bool("require-clean-index-only", false, "Only refuse to release over staged changes to other files; unstaged changes and untracked files are ignored (and not committed)")
+	signoff := flag.Bool("signoff", false, "Add a Signed-off-by trailer for the committer to the release commit")
+	creditAuthors := flag.Bool("credit-authors", false, "Add a Co-authored-by trailer to the release commit for each author of a commit since the latest tag")
+	gitBackend := flag.String("git-backend", "exec", "Git implementation; only exec (run the git binary) is available in this binary")
+	gitBin := flag.String("git-bin", "", "Path of the git binary to run (default git on PATH)")
+	flag.BoolVar(&noColor, "no-color", false, "Disable colored output (also disabled by NO_COLOR or when output is not a terminal)")
+	verbose := flag.Bool("verbose", false, "Print each file write, import rewrite, and git step to stderr as it happens")
+	pickInteractive := flag.Bool("interactive", false, "Pick the bump from a menu read from stdin, even when stdin is not a terminal")
+	check := flag.Bool("check", false, "Run every pre-release check without modifying anything and exit non-zero if the release would be refused")
+	ensure := flag.Bool("ensure", false, "Idempotently release an explicit version, performing only the missing write, commit, and tag steps")
+	idempotentPrerelease := flag.Bool("idempotent-prerelease", false, "Make a prerelease bump a no-op if HEAD is the commit that set the current prerelease, so a retried job does not bump twice")
 	showVersion := flag.Bool("version", false, "Show CLI version and exit")
 	help := flag.Bool("help", false, "Show help message and exit")
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration as JSON and exit")
 
 	flag.Usage = usage
+	if err := applyEnvDefaults(flag.CommandLine, "help", "version", "print-config"); err != nil {
+		printError(err)
+		os.Exit(1)
+	}
 	flag.Parse()
 
 	if *help {
@@ -71,48 +435,246 @@ func main() {
 
 	// Guard against misplaced flags after positional args.
 	for _, arg := range flag.Args() {
-		if strings.HasPrefix(arg, "-") {
-			fmt.Fprintln(os.Stderr, "Error: Flags must be specified before the command. Please reorder your arguments.")
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			printError("Flags must be specified before the command. Please reorder your arguments.")
 			usage()
 			os.Exit(1)
 		}
 	}
 
 	args := flag.Args()
-	if len(args) != 1 {
-		fmt.Fprintln(os.Stderr, "Error: <version-bump> positional argument is required")
+	if *pickInteractive && len(args) > 0 {
+		printError("-interactive cannot be combined with a <version-bump>")
+		usage()
+		os.Exit(1)
+	}
+	interactive := len(args) == 0 && !*printConfig && (*pickInteractive || isTerminal(os.Stdin))
+	if len(args) > 1 || (len(args) == 0 && !*printConfig && !interactive) {
+		printError("<version-bump> positional argument is required")
 		usage()
 		os.Exit(1)
 	}
-	versionArg := args[0]
+	var versionArg string
+	if len(args) == 1 {
+		versionArg = args[0]
+	}
+	if versionArg == "-" {
+		var err error
+		if versionArg, err = readVersionArg(os.Stdin); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+	}
+
+	if versionArg == "discover" {
+		root := *dir
+		if root == "" {
+			root = "."
+		}
+		candidates, err := goversion.DiscoverVersionFiles(root)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		printDiscovery(os.Stdout, *dir, candidates)
+		return
+	}
 
 	// Make sure versionFile is in extraFiles so it's always staged.
 	if !slices.Contains(extraFiles, *versionFile) {
 		extraFiles = append(extraFiles, *versionFile)
 	}
 
-	var meta goversion.VersionMeta
-	var err error
+	if *listChanged {
+		*dryRun = true
+	}
 
-	if *dryRun {
-		meta, err = goversion.DryRun(*versionFile, versionArg, bumpFiles)
-	} else {
-		meta, err = goversion.Run(*versionFile, versionArg, extraFiles, bumpFiles, *postBump)
+	cfg := goversion.Config{
+		Dir:                  *dir,
+		VersionFile:          *versionFile,
+		VersionVar:           *versionVar,
+		VersionArg:           versionArg,
+		ExtraFiles:           extraFiles,
+		FilesFrom:            *filesFrom,
+		AllowMissingFiles:    *allowMissingFiles,
+		MirrorFiles:          mirrorFiles,
+		BumpFiles:            bumpFiles,
+		PrereleaseSeparator:  *prereleaseSeparator,
+		BumpAllFields:        bumpAllFields,
+		Reconcile:            reconcile,
+		BumpYAMLLists:        bumpYAMLLists,
+		Changelog:            *changelog,
+		AmendChangelog:       *amendChangelog,
+		ChangelogHeading:     *changelogHeading,
+		ChangelogUnreleased:  *changelogUnreleased,
+		ChangelogFirstParent: *changelogFirstParent,
+		VersionHook:          *versionHook,
+		PostBumpScript:       *postBump,
+		BuildCheck:           *buildCheck,
+		ModTidy:              *modTidy,
+		ModTidyRequired:      *modTidyRequired,
+		Backup:               *backup,
+		DryRun:               *dryRun,
+		Ensure:               *ensure,
+		IdempotentPrerelease: *idempotentPrerelease,
+		RequireBranch:        *requireBranch,
+		AllowDowngrade:       *allowDowngrade,
+		CompareBuildMetadata: *compareBuild,
+		NewModulePath:        *newModulePath,
+		ModFile:              *modFile,
+		Force:                *force,
+		CleanupOnTagFailure:  *cleanupOnTagFailure,
+		TagPrefix:            *tagPrefix,
+		TagPattern:           *tagPattern,
+		Scope:                *scope,
+		BranchTagsOnly:       *branchTagsOnly,
+		TagSort:              *tagSort,
+		Remote:               *remote,
+		TrackingBranch:       *trackingBranch,
+		StrictOrdering:       *strictOrdering,
+		Base:                 *base,
+		From:                 *from,
+		InitialVersion:       *initialVersion,
+		CommitSnapshot:       *commitSnapshot,
+		NoTagOnPrerelease:    *noTagOnPrerelease,
+		AnnotateTag:          *annotateTag,
+		CommitTemplate:       *commitTemplate,
+		ConventionalMessage:  *conventionalMessage,
+		ConventionalScope:    *conventionalScope,
+		Trailers:             trailers,
+		AllowEmpty:           *allowEmpty,
+		Signoff:              *signoff,
+		CreditAuthors:        *creditAuthors,
+		AllowDirty:           *allowDirty,
+		CleanIndexOnly:       *requireCleanIndexOnly,
+		KeepVPrefix:          *keepVPrefix,
+		LenientVersion:       *lenientVersion,
+		NoGofmt:              *noGofmt,
+		NoCreate:             *noCreate,
+		Timings:              *timings,
+		GitBackend:           *gitBackend,
+		GitBin:               *gitBin,
+	}
+
+	if *verbose {
+		cfg.OnEvent = printEvent
+	}
+
+	if *printConfig {
+		out, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		os.Exit(0)
+	}
+
+	if interactive {
+		current, err := goversion.CurrentVersion(cfg)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		if cfg.VersionArg, err = pickVersionArg(os.Stdin, os.Stderr, current); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+	}
+
+	if versionArg == "undo" {
+		meta, err := goversion.Undo(cfg)
+		if err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		if meta.Tag != "" {
+			fmt.Printf("Deleted tag %s.\n", meta.Tag)
+		}
+		fmt.Printf("Undid release %s; the version is back to %s.\n", meta.OldVersion, meta.NewVersion)
+		return
+	}
+
+	if *check {
+		if err := goversion.CheckReleasable(cfg); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		fmt.Println(paint(os.Stdout, ansiGreen, "Release checks passed."))
+		os.Exit(0)
+	}
+
+	githubOutputFile := os.Getenv("GITHUB_OUTPUT")
+	if *githubOutput && githubOutputFile == "" {
+		printError("-github-output requires the GITHUB_OUTPUT environment variable to be set")
+		os.Exit(1)
+	}
+
+	var summary *template.Template
+	if *summaryTemplate != "" {
+		var err error
+		if summary, err = parseSummaryTemplate(*summaryTemplate); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
 	}
+
+	meta, err := goversion.RunWithConfig(cfg)
+	printTimings(os.Stderr, meta.Timings)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
+		printError(err)
 		os.Exit(1)
 	}
 
+	if *githubOutput {
+		if err := writeGitHubOutput(githubOutputFile, meta); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+	}
+
+	if *listChanged {
+		for _, f := range meta.UpdatedFiles {
+			fmt.Println(f)
+		}
+		return
+	}
+
+	if summary != nil {
+		if err := renderSummary(os.Stdout, summary, meta); err != nil {
+			printError(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if meta.AlreadyReleased {
+		fmt.Printf("Already at %s; nothing to do.\n", cmp.Or(meta.Tag, meta.NewVersion))
+		return
+	}
+
+	if meta.TagExists {
+		fmt.Fprintf(os.Stderr, "Warning: tag %s already exists; a real run would fail.\n", meta.Tag)
+	}
+
 	// Summary
 	if *dryRun {
 		fmt.Println("Dry run complete — no files were modified.")
 	} else {
-		fmt.Println("Version bump successful!")
+		fmt.Println(paint(os.Stdout, ansiGreen, "Version bump successful!"))
 	}
 	fmt.Printf("Old Version: %s\n", meta.OldVersion)
 	fmt.Printf("New Version: %s\n", meta.NewVersion)
 	fmt.Printf("Bump Type:   %s\n", meta.BumpType)
+	if *dryRun && meta.CommitMessage != "" {
+		if meta.Tag != "" {
+			fmt.Printf("Tag:         %s\n", meta.Tag)
+		}
+		fmt.Println("Commit message:")
+		for _, line := range strings.Split(meta.CommitMessage, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
 
 	// Print out exactly which files were (or would be) touched.
 	if len(meta.UpdatedFiles) > 0 {
@@ -126,4 +688,29 @@ func main() {
 		}
 	}
 
+	// Show the self-import rewrites a major bump would make.
+	if len(meta.ImportChanges) > 0 {
+		fmt.Println("Import changes:")
+		for _, f := range slices.Sorted(maps.Keys(meta.ImportChanges)) {
+			fmt.Printf("  %s\n", f)
+			for _, c := range meta.ImportChanges[f] {
+				fmt.Printf("    %d: %s -> %s\n", c.Line, c.Old, c.New)
+			}
+		}
+	}
+
+	// Show which field of each bump file matched, to debug files that do not bump.
+	if len(meta.BumpFileMatches) > 0 {
+		fmt.Println("Bump file matches:")
+		for _, f := range slices.Sorted(maps.Keys(meta.BumpFileMatches)) {
+			fmt.Printf("  %s\n", f)
+			if len(meta.BumpFileMatches[f]) == 0 {
+				fmt.Println("    no version found")
+			}
+			for _, m := range meta.BumpFileMatches[f] {
+				fmt.Printf("    %d: %s -> %s (%s)\n", m.Line, m.Old, m.New, m.Pattern)
+			}
+		}
+	}
+
 }
@@ -2,11 +2,14 @@
 package main
 
 import (
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	goversion "github.com/bcomnes/goversion/v2/pkg"
 )
 
 // TestMain triggers the CLI as a subprocess when GO_HELPER_PROCESS is set.
@@ -27,6 +30,44 @@ func runCLI(args []string, extraEnv ...string) (string, error) {
 	return string(out), err
 }
 
+// runCLIInput is runCLI with stdin read from input.
+func runCLIInput(input string, args []string, extraEnv ...string) (string, error) {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GO_HELPER_PROCESS=1")
+	cmd.Env = append(cmd.Env, extraEnv...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// initCLIRepo creates a temporary git repository whose one commit holds a
+// version.go at version 1.2.3, and returns its path.
+func initCLIRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitT(t, dir, "init")
+	gitT(t, dir, "config", "user.email", "test@example.com")
+	gitT(t, dir, "config", "user.name", "Test User")
+	if err := os.WriteFile(filepath.Join(dir, "version.go"), []byte("package main\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitT(t, dir, "add", ".")
+	gitT(t, dir, "commit", "-m", "initial")
+	return dir
+}
+
+// gitT runs git with args in dir, failing the test on error, and returns its trimmed output.
+func gitT(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
 func TestCLIHelp(t *testing.T) {
 	out, _ := runCLI([]string{"-help"})
 	if !strings.Contains(out, "Usage:") {
@@ -48,6 +89,15 @@ func TestCLIMissingVersionArg(t *testing.T) {
 	}
 }
 
+// TestCLIGitBinEnv verifies that GOVERSION_GIT_BIN sets -git-bin.
+func TestCLIGitBinEnv(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "no-such-git")
+	out, err := runCLI([]string{"-check", "patch"}, "GOVERSION_GIT_BIN="+missing)
+	if err == nil || !strings.Contains(out, missing+" not found") {
+		t.Errorf("expected %s to be reported as not found, got %v:\n%s", missing, err, out)
+	}
+}
+
 func TestCLIPatchBumpIntegration(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "goversion_cli_test")
 	if err != nil {
@@ -213,98 +263,418 @@ var (
 }
 
 func TestCLIMajorBumpIntegration(t *testing.T) {
-    tmpDir, err := os.MkdirTemp("", "goversion_cli_major_test")
-    if err != nil {
-        t.Fatal(err)
-    }
-    defer os.RemoveAll(tmpDir)
-
-    runGit := func(args ...string) {
-        cmd := exec.Command("git", args...)
-        cmd.Dir = tmpDir
-        if out, err := cmd.CombinedOutput(); err != nil {
-            t.Fatalf("git %v failed: %v\n%s", args, err, out)
-        }
-    }
-
-    // init repo + config
-    runGit("init")
-    runGit("config", "user.email", "test@example.com")
-    runGit("config", "user.name", "Test User")
-
-    // write a simple go.mod
-    modContent := `module example.com/m
+	tmpDir, err := os.MkdirTemp("", "goversion_cli_major_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	// init repo + config
+	runGit("init")
+	runGit("config", "user.email", "test@example.com")
+	runGit("config", "user.name", "Test User")
+
+	// write a simple go.mod
+	modContent := `module example.com/m
 
 go 1.18
 `
-    if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(modContent), 0644); err != nil {
-        t.Fatalf("failed to write go.mod: %v", err)
-    }
-
-    // create the version file
-    versionDir := filepath.Join(tmpDir, "pkg")
-    if err := os.MkdirAll(versionDir, 0755); err != nil {
-        t.Fatalf("failed to mkdir pkg: %v", err)
-    }
-    rel := filepath.Join("pkg", "version.go")
-    abs := filepath.Join(tmpDir, rel)
-    initial := `package version
+	if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(modContent), 0644); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+
+	// create the version file
+	versionDir := filepath.Join(tmpDir, "pkg")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("failed to mkdir pkg: %v", err)
+	}
+	rel := filepath.Join("pkg", "version.go")
+	abs := filepath.Join(tmpDir, rel)
+	initial := `package version
 
 var (
     Version = "1.2.3"
 )
 `
-    if err := os.WriteFile(abs, []byte(initial), 0644); err != nil {
-        t.Fatalf("write version.go: %v", err)
-    }
-
-    // commit both files
-    runGit("add", ".")
-    runGit("commit", "-m", "initial")
-
-    // run CLI with "major"
-    cmd := exec.Command(os.Args[0], "-version-file", rel, "major")
-    cmd.Dir = tmpDir
-    cmd.Env = append(os.Environ(),
-        "GO_HELPER_PROCESS=1",
-        "GIT_AUTHOR_NAME=Test User",
-        "GIT_AUTHOR_EMAIL=test@example.com",
-        "GIT_COMMITTER_NAME=Test User",
-        "GIT_COMMITTER_EMAIL=test@example.com",
-    )
-    out, err := cmd.CombinedOutput()
-    if err != nil {
-        t.Fatalf("CLI major bump failed: %v\n%s", err, out)
-    }
-
-    // check version.go
-    got, err := os.ReadFile(abs)
-    if err != nil {
-        t.Fatalf("read version.go failed: %v", err)
-    }
-    if !strings.Contains(string(got), `Version = "2.0.0"`) {
-        t.Errorf("version.go =\n%s\nwant Version = \"2.0.0\"", got)
-    }
-
-    // check go.mod
-    modGot, err := os.ReadFile(filepath.Join(tmpDir, "go.mod"))
-    if err != nil {
-        t.Fatalf("read go.mod failed: %v", err)
-    }
-    first := strings.SplitN(string(modGot), "\n", 2)[0]
-    if !strings.Contains(first, "/v2") {
-        t.Errorf("go.mod first line = %q; want it to include \"/v2\"", first)
-    }
-
-    // check git tag
-    // check git tag
-    cmd = exec.Command("git", "tag")
-    cmd.Dir = tmpDir
-    tagsOut, err := cmd.CombinedOutput()
-    if err != nil {
-        t.Fatalf("git tag failed: %v\n%s", err, tagsOut)
-    }
-    if !strings.Contains(string(tagsOut), "v2.0.0") {
-        t.Errorf("git tags = %s; want v2.0.0", tagsOut)
-    }
+	if err := os.WriteFile(abs, []byte(initial), 0644); err != nil {
+		t.Fatalf("write version.go: %v", err)
+	}
+
+	// commit both files
+	runGit("add", ".")
+	runGit("commit", "-m", "initial")
+
+	// run CLI with "major"
+	cmd := exec.Command(os.Args[0], "-version-file", rel, "major")
+	cmd.Dir = tmpDir
+	cmd.Env = append(os.Environ(),
+		"GO_HELPER_PROCESS=1",
+		"GIT_AUTHOR_NAME=Test User",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test User",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("CLI major bump failed: %v\n%s", err, out)
+	}
+
+	// check version.go
+	got, err := os.ReadFile(abs)
+	if err != nil {
+		t.Fatalf("read version.go failed: %v", err)
+	}
+	if !strings.Contains(string(got), `Version = "2.0.0"`) {
+		t.Errorf("version.go =\n%s\nwant Version = \"2.0.0\"", got)
+	}
+
+	// check go.mod
+	modGot, err := os.ReadFile(filepath.Join(tmpDir, "go.mod"))
+	if err != nil {
+		t.Fatalf("read go.mod failed: %v", err)
+	}
+	first := strings.SplitN(string(modGot), "\n", 2)[0]
+	if !strings.Contains(first, "/v2") {
+		t.Errorf("go.mod first line = %q; want it to include \"/v2\"", first)
+	}
+
+	// check git tag
+	// check git tag
+	cmd = exec.Command("git", "tag")
+	cmd.Dir = tmpDir
+	tagsOut, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git tag failed: %v\n%s", err, tagsOut)
+	}
+	if !strings.Contains(string(tagsOut), "v2.0.0") {
+		t.Errorf("git tags = %s; want v2.0.0", tagsOut)
+	}
+}
+
+// TestCLIPrintConfigEnv verifies that -print-config reports values sourced from
+// GOVERSION_* environment variables without performing a bump.
+func TestCLIPrintConfigEnv(t *testing.T) {
+	out, err := runCLI([]string{"-bump-file", "package.json", "-print-config"},
+		"GOVERSION_VERSION_FILE=internal/version.go",
+		"GOVERSION_BUMP_FILE=Cargo.toml",
+	)
+	if err != nil {
+		t.Fatalf("CLI -print-config failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		`"versionFile": "internal/version.go"`,
+		`"Cargo.toml"`,
+		`"package.json"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in config dump, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Version bump successful") {
+		t.Errorf("-print-config should not bump, got:\n%s", out)
+	}
+}
+
+// TestCLIListChanged verifies that -list-changed prints exactly the paths DryRun
+// would update, one per line, and leaves the files untouched.
+func TestCLIListChanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := os.WriteFile(versionFile, []byte("package main\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	packageJSON := filepath.Join(tmpDir, "package.json")
+	if err := os.WriteFile(packageJSON, []byte(`{"version": "1.2.3"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCLI([]string{"-version-file", versionFile, "-bump-file", packageJSON, "-list-changed", "minor"})
+	if err != nil {
+		t.Fatalf("CLI -list-changed failed: %v\n%s", err, out)
+	}
+	want := versionFile + "\n" + packageJSON + "\n"
+	if out != want {
+		t.Errorf("unexpected output\ngot:\n%q\nwant:\n%q", out, want)
+	}
+
+	content, _ := os.ReadFile(versionFile)
+	if !strings.Contains(string(content), `Version = "1.2.3"`) {
+		t.Errorf("-list-changed modified the version file:\n%s", content)
+	}
+}
+
+// TestRenderSummary verifies that a custom summary template is rendered against
+// VersionMeta and that templates referencing unknown fields are rejected up front.
+func TestRenderSummary(t *testing.T) {
+	meta := goversion.VersionMeta{
+		OldVersion:   "1.2.3",
+		NewVersion:   "1.3.0",
+		BumpType:     "minor",
+		UpdatedFiles: []string{"version.go", "package.json"},
+	}
+	tmpl, err := parseSummaryTemplate(`::set-output name=version::{{.NewVersion}} ({{.BumpType}} from {{.OldVersion}}, {{len .UpdatedFiles}} files)`)
+	if err != nil {
+		t.Fatalf("parseSummaryTemplate failed: %v", err)
+	}
+	var out strings.Builder
+	if err := renderSummary(&out, tmpl, meta); err != nil {
+		t.Fatalf("renderSummary failed: %v", err)
+	}
+	want := "::set-output name=version::1.3.0 (minor from 1.2.3, 2 files)\n"
+	if out.String() != want {
+		t.Errorf("summary = %q, want %q", out.String(), want)
+	}
+
+	for _, bad := range []string{"{{.NewVersion", "{{.Nope}}"} {
+		if _, err := parseSummaryTemplate(bad); err == nil {
+			t.Errorf("expected template %q to be rejected", bad)
+		}
+	}
+}
+
+// TestCLIGitHubOutput verifies that -github-output appends the result to the file
+// named by GITHUB_OUTPUT, and fails when the variable is unset.
+func TestCLIGitHubOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := os.WriteFile(versionFile, []byte("package main\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(tmpDir, "github_output")
+	if err := os.WriteFile(outputFile, []byte("existing=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"-version-file", versionFile, "-github-output", "-dry", "minor"}
+	if out, err := runCLI(args, "GITHUB_OUTPUT="); err == nil || !strings.Contains(out, "GITHUB_OUTPUT") {
+		t.Errorf("expected an error about GITHUB_OUTPUT being unset, got err=%v:\n%s", err, out)
+	}
+
+	if out, err := runCLI(args, "GITHUB_OUTPUT="+outputFile); err != nil {
+		t.Fatalf("CLI -github-output failed: %v\n%s", err, out)
+	}
+	got, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "existing=1\nnew_version=1.3.0\nold_version=1.2.3\ntag=v1.3.0\nbump_type=minor\nprevious_tag="
+	if !strings.HasPrefix(string(got), want) || !strings.HasSuffix(string(got), "\n") {
+		t.Errorf("GITHUB_OUTPUT contents\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestCLINoColorWhenPiped verifies that no ANSI escape codes are written when
+// output is not a terminal, for both successful runs and errors.
+func TestCLINoColorWhenPiped(t *testing.T) {
+	tmpDir := t.TempDir()
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := os.WriteFile(versionFile, []byte("package main\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"-version-file", versionFile, "-dry", "patch"},
+		{"-version-file", versionFile, "-dry", "not-a-version"},
+	} {
+		out, _ := runCLI(args)
+		if strings.Contains(out, "\x1b[") {
+			t.Errorf("unexpected ANSI escape in piped output for %v:\n%q", args, out)
+		}
+	}
+}
+
+// TestCLIDiscover verifies that discover lists the detected version files and
+// suggests flags for them without bumping anything.
+func TestCLIDiscover(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"version.go":                    "package main\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"package.json":                  "{\n  \"version\": \"1.2.3\"\n}\n",
+		"charts/app/Chart.yaml":         "name: app\nversion: 0.4.0\n",
+		"node_modules/dep/package.json": "{\n  \"version\": \"9.9.9\"\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := runCLI([]string{"-C", tmpDir, "discover"})
+	if err != nil {
+		t.Fatalf("CLI discover failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"version.go             1.2.3 (go-version)",
+		"charts/app/Chart.yaml  0.4.0 (yaml-version)",
+		"goversion -C " + tmpDir + " -version-file=version.go -bump-file=charts/app/Chart.yaml -bump-file=package.json patch",
+		"GOVERSION_VERSION_FILE=version.go",
+		"GOVERSION_BUMP_FILE=charts/app/Chart.yaml,package.json",
+		"the version in charts/app/Chart.yaml differs from 1.2.3",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "node_modules") {
+		t.Errorf("node_modules should be skipped, got:\n%s", out)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tmpDir, "version.go")); string(content) != files["version.go"] {
+		t.Errorf("discover modified the version file:\n%s", content)
+	}
+}
+
+// TestCLIStdinDirective verifies that "-" reads the version bump from stdin and
+// that the directive read is validated like a positional argument.
+func TestCLIStdinDirective(t *testing.T) {
+	tmpDir := t.TempDir()
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := os.WriteFile(versionFile, []byte("package main\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(stdin string) (string, error) {
+		cmd := exec.Command(os.Args[0], "-version-file", versionFile, "-dry", "-summary-template", "{{.NewVersion}} {{.BumpType}}", "-")
+		cmd.Env = append(os.Environ(), "GO_HELPER_PROCESS=1")
+		cmd.Stdin = strings.NewReader(stdin)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := run("minor\n")
+	if err != nil {
+		t.Fatalf("CLI with a stdin directive failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "1.3.0 minor") {
+		t.Errorf("expected a minor bump to 1.3.0, got:\n%s", out)
+	}
+
+	for _, stdin := range []string{"", "sideways\n", "minor patch\n"} {
+		if out, err := run(stdin); err == nil || !strings.Contains(out, "Error:") {
+			t.Errorf("stdin %q: expected an error, got err=%v:\n%s", stdin, err, out)
+		}
+	}
+}
+
+// TestCLITagOrderWarning verifies that releasing a version below the highest
+// existing tag warns, and that -strict-ordering refuses it instead.
+func TestCLITagOrderWarning(t *testing.T) {
+	tmpDir := initCLIRepo(t)
+	gitT(t, tmpDir, "tag", "v2.0.0+build.7")
+	gitT(t, tmpDir, "tag", "v1.2.3")
+
+	const warning = "Warning: the new tag v1.2.4 sorts below the existing tag v2.0.0+build.7"
+	out, err := runCLI([]string{"-C", tmpDir, "-strict-ordering", "patch"})
+	if err == nil || !strings.Contains(out, "sorts below the existing tag v2.0.0+build.7") || strings.Contains(out, warning) {
+		t.Fatalf("expected -strict-ordering to refuse the release, got err=%v:\n%s", err, out)
+	}
+
+	out, err = runCLI([]string{"-C", tmpDir, "patch"})
+	if err != nil {
+		t.Fatalf("CLI failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, warning) {
+		t.Errorf("expected a tag order warning, got:\n%s", out)
+	}
+
+	out, err = runCLI([]string{"-C", tmpDir, "major"})
+	if err != nil {
+		t.Fatalf("CLI failed: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "sorts below") {
+		t.Errorf("v2.0.0 does not sort below v2.0.0+build.7, got a warning:\n%s", out)
+	}
+}
+
+// TestCLIUndo verifies that "goversion undo" takes back the release just made,
+// deleting its tag and restoring the previous version.
+func TestCLIUndo(t *testing.T) {
+	tmpDir := initCLIRepo(t)
+	before := gitT(t, tmpDir, "rev-parse", "HEAD")
+
+	if out, err := runCLI([]string{"-C", tmpDir, "minor"}); err != nil {
+		t.Fatalf("CLI failed: %v\n%s", err, out)
+	}
+	out, err := runCLI([]string{"-C", tmpDir, "undo"})
+	if err != nil {
+		t.Fatalf("undo failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Deleted tag v1.3.0.") || !strings.Contains(out, "Undid release 1.3.0; the version is back to 1.2.3.") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if head := gitT(t, tmpDir, "rev-parse", "HEAD"); head != before {
+		t.Errorf("HEAD = %s, want %s", head, before)
+	}
+	if tags := gitT(t, tmpDir, "tag", "--list"); tags != "" {
+		t.Errorf("tags = %q, want none", tags)
+	}
+
+	if out, err := runCLI([]string{"-C", tmpDir, "undo"}); err == nil {
+		t.Errorf("expected undoing a non-release commit to fail, got:\n%s", out)
+	}
+}
+
+// TestPickVersionArg verifies that the interactive menu accepts a number or a
+// keyword.
+func TestPickVersionArg(t *testing.T) {
+	var menu strings.Builder
+	got, err := pickVersionArg(strings.NewReader("2\n"), &menu, "1.2.3")
+	if err != nil {
+		t.Fatalf("pickVersionArg failed: %v", err)
+	}
+	if got != "minor" {
+		t.Errorf("selection 2 = %q, want minor", got)
+	}
+	for _, want := range []string{"Current version: 1.2.3", "1) patch      1.2.4", "2) minor      1.3.0", "3) major      2.0.0", "4) prerelease 1.2.4-0"} {
+		if !strings.Contains(menu.String(), want) {
+			t.Errorf("menu lacks %q:\n%s", want, menu.String())
+		}
+	}
+	if got, err := pickVersionArg(strings.NewReader("major"), io.Discard, "1.2.3"); err != nil || got != "major" {
+		t.Errorf("selection major = %q, %v; want major", got, err)
+	}
+	for _, input := range []string{"", "5\n", "premajor\n"} {
+		if got, err := pickVersionArg(strings.NewReader(input), io.Discard, "1.2.3"); err == nil {
+			t.Errorf("selection %q = %q, want an error", input, got)
+		}
+	}
+}
+
+// TestCLIInteractive verifies that the bump picked from the menu is released,
+// and that nothing is written before one is picked.
+func TestCLIInteractive(t *testing.T) {
+	tmpDir := initCLIRepo(t)
+	out, err := runCLIInput("2\n", []string{"-C", tmpDir, "-interactive"})
+	if err != nil {
+		t.Fatalf("CLI failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "2) minor      1.3.0") {
+		t.Errorf("expected the menu, got:\n%s", out)
+	}
+	if tags := gitT(t, tmpDir, "tag", "--list"); tags != "v1.3.0" {
+		t.Errorf("tags = %q, want v1.3.0", tags)
+	}
+
+	out, err = runCLIInput("", []string{"-C", tmpDir, "-version-file", "other.go", "-interactive"})
+	if err == nil {
+		t.Fatalf("expected an error without a choice, got:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "other.go")); !os.IsNotExist(err) {
+		t.Errorf("the version file must not be created before a bump is picked (%v)", err)
+	}
+
+	if out, err := runCLIInput("minor\n", []string{"-C", tmpDir, "-interactive", "minor"}); err == nil {
+		t.Errorf("expected -interactive with a <version-bump> to fail, got:\n%s", out)
+	}
 }
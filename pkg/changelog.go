@@ -0,0 +1,159 @@
+package goversion
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultUnreleasedHeading is the Keep a Changelog heading AmendChangelog promotes
+// unless Config.ChangelogHeading is set.
+const defaultUnreleasedHeading = "## [Unreleased]"
+
+// writesChangelog reports whether a release of bumpType updates cfg.Changelog.
+// Snapshots are not releases and never touch it.
+func (cfg Config) writesChangelog(bumpType string) bool {
+	return cfg.Changelog != "" && bumpType != "snapshot"
+}
+
+// unreleasedHeading returns the heading of the section AmendChangelog promotes.
+func (cfg Config) unreleasedHeading() string {
+	if cfg.ChangelogHeading != "" {
+		return cfg.ChangelogHeading
+	}
+	return defaultUnreleasedHeading
+}
+
+// releaseHeading returns the changelog heading for version released on date,
+// in Keep a Changelog style: "## [1.2.3] - 2006-01-02".
+func releaseHeading(version string, date time.Time) string {
+	return fmt.Sprintf("## [%s] - %s", version, date.Format("2006-01-02"))
+}
+
+// findHeadingLine returns the offsets of the first line of content equal to
+// heading, ignoring surrounding whitespace, or -1 if there is none.
+func findHeadingLine(content []byte, heading string) (start, end int) {
+	offset := 0
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if string(bytes.TrimSpace(line)) == heading {
+			return offset, offset + len(bytes.TrimRight(line, "\r\n"))
+		}
+		offset += len(line)
+	}
+	return -1, -1
+}
+
+// checkChangelog verifies that cfg.Changelog can be updated for a release: with
+// AmendChangelog, the file must exist and contain the unreleased heading.
+func checkChangelog(cfg Config) error {
+	if cfg.Changelog == "" {
+		if cfg.AmendChangelog {
+			return fmt.Errorf("amending the changelog requires a changelog file")
+		}
+		return nil
+	}
+	if !cfg.AmendChangelog {
+		return nil
+	}
+	content, err := os.ReadFile(cfg.Changelog)
+	if err != nil {
+		return fmt.Errorf("failed to read changelog: %w", err)
+	}
+	if start, _ := findHeadingLine(content, cfg.unreleasedHeading()); start < 0 {
+		return fmt.Errorf("no %q heading found in %s", cfg.unreleasedHeading(), cfg.Changelog)
+	}
+	return nil
+}
+
+// changelogEntries returns the subject of every commit since the latest tag,
+// newest first, or of every commit if there is no tag yet. With
+// ChangelogFirstParent, commits reached only through the second parent of a
+// merge are left out.
+func changelogEntries(cfg Config) ([]string, error) {
+	g := cfg.git()
+	rev := cfg.sinceLatestTag(g)
+	args := []string{"--format=%s", rev}
+	if cfg.ChangelogFirstParent {
+		args = []string{"--format=%s", "--first-parent", rev}
+	}
+	out, err := g.Log(cfg.Dir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits for the changelog: %w", err)
+	}
+	var entries []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
+
+// addChangelogSection inserts section before the first level-two heading in
+// content, so that it follows any title and introduction, or appends it if
+// there is no such heading.
+func addChangelogSection(content []byte, section string) []byte {
+	offset := 0
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("## ")) {
+			break
+		}
+		offset += len(line)
+	}
+	var out bytes.Buffer
+	out.Write(content[:offset])
+	if offset > 0 && !bytes.HasSuffix(content[:offset], []byte("\n\n")) {
+		if !bytes.HasSuffix(content[:offset], []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		out.WriteByte('\n')
+	}
+	out.WriteString(section)
+	if offset < len(content) {
+		out.WriteByte('\n')
+	}
+	out.Write(content[offset:])
+	return out.Bytes()
+}
+
+// updateChangelog records the release of version in cfg.Changelog. With
+// AmendChangelog, the unreleased heading is renamed to the release heading,
+// preceded by a fresh, empty unreleased heading if ChangelogUnreleased is set.
+// Otherwise a section listing the commits since the latest tag is added above
+// the previous releases, creating the file if it does not exist.
+func updateChangelog(cfg Config, version string) error {
+	heading := releaseHeading(version, time.Now())
+	content, err := os.ReadFile(cfg.Changelog)
+	if err != nil && !(os.IsNotExist(err) && !cfg.AmendChangelog) {
+		return fmt.Errorf("failed to read changelog: %w", err)
+	}
+	if cfg.AmendChangelog {
+		start, end := findHeadingLine(content, cfg.unreleasedHeading())
+		if start < 0 {
+			return fmt.Errorf("no %q heading found in %s", cfg.unreleasedHeading(), cfg.Changelog)
+		}
+		if cfg.ChangelogUnreleased {
+			heading = cfg.unreleasedHeading() + "\n\n" + heading
+		}
+		content = append(append(append([]byte{}, content[:start]...), heading...), content[end:]...)
+	} else {
+		entries, err := changelogEntries(cfg)
+		if err != nil {
+			return err
+		}
+		section := heading + "\n"
+		if len(entries) > 0 {
+			section += "\n- " + strings.Join(entries, "\n- ") + "\n"
+		}
+		if len(content) == 0 {
+			content = []byte("# Changelog\n")
+		}
+		content = addChangelogSection(content, section)
+	}
+	if err := os.WriteFile(cfg.Changelog, content, 0644); err != nil {
+		return fmt.Errorf("failed to write changelog: %w", err)
+	}
+	return nil
+}
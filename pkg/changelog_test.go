@@ -0,0 +1,191 @@
+package goversion
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKeepAChangelog = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+## [Unreleased]
+
+### Added
+
+- Frobnicate widgets.
+
+## [1.2.3] - 2024-01-01
+
+- Initial release.
+`
+
+// TestAmendChangelog verifies that AmendChangelog promotes the Unreleased section
+// of a Keep a Changelog file to the release, optionally adding a fresh one above.
+func TestAmendChangelog(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	tests := []struct {
+		name       string
+		unreleased bool
+		want       string
+	}{
+		{
+			name: "promote",
+			want: strings.Replace(testKeepAChangelog, "## [Unreleased]", "## [1.3.0] - "+today, 1),
+		},
+		{
+			name:       "promote and add unreleased",
+			unreleased: true,
+			want:       strings.Replace(testKeepAChangelog, "## [Unreleased]", "## [Unreleased]\n\n## [1.3.0] - "+today, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{
+				"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+				"CHANGELOG.md": testKeepAChangelog,
+			})
+			commitAllT(t, tmpDir, "initial commit")
+
+			meta, err := RunWithConfig(Config{
+				Dir:                 tmpDir,
+				VersionFile:         "version.go",
+				VersionArg:          "minor",
+				ExtraFiles:          []string{"version.go"},
+				Changelog:           "CHANGELOG.md",
+				AmendChangelog:      true,
+				ChangelogUnreleased: tt.unreleased,
+			})
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			got, _ := os.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md"))
+			if string(got) != tt.want {
+				t.Errorf("changelog mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if want := filepath.Join(tmpDir, "CHANGELOG.md"); meta.UpdatedFiles[len(meta.UpdatedFiles)-1] != want {
+				t.Errorf("UpdatedFiles = %v, want it to end with %s", meta.UpdatedFiles, want)
+			}
+			if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+				t.Errorf("expected the changelog to be committed, got:\n%s", status)
+			}
+		})
+	}
+}
+
+// TestAmendChangelogMissingHeading verifies that a changelog without the
+// unreleased heading refuses the release before anything is written.
+func TestAmendChangelogMissingHeading(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"CHANGELOG.md": "# Changelog\n\n## [Next]\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := Config{
+		Dir:            tmpDir,
+		VersionFile:    "version.go",
+		VersionArg:     "patch",
+		ExtraFiles:     []string{"version.go"},
+		Changelog:      "CHANGELOG.md",
+		AmendChangelog: true,
+	}
+	if _, err := RunWithConfig(cfg); err == nil || !strings.Contains(err.Error(), `"## [Unreleased]"`) {
+		t.Fatalf("Run error = %v, want a missing heading error", err)
+	}
+	if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+		t.Errorf("version file = %q, want it untouched", v)
+	}
+
+	cfg.ChangelogHeading = "## [Next]"
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run with a custom heading failed: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md"))
+	if !strings.Contains(string(got), "## [1.2.4] - ") || strings.Contains(string(got), "[Next]") {
+		t.Errorf("custom heading not promoted:\n%s", got)
+	}
+}
+
+// TestChangelogSection verifies that a changelog section listing the commits
+// since the latest tag is added above the previous releases.
+func TestChangelogSection(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"CHANGELOG.md": "# Changelog\n\n## [1.2.3] - 2024-01-01\n\n- Initial release.\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v1.2.3")
+	writeFilesT(t, tmpDir, map[string]string{"a.go": "package foo\n"})
+	commitAllT(t, tmpDir, "Add a")
+	writeFilesT(t, tmpDir, map[string]string{"b.go": "package foo\n"})
+	commitAllT(t, tmpDir, "Add b")
+
+	if _, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "patch",
+		ExtraFiles:  []string{"version.go"},
+		Changelog:   "CHANGELOG.md",
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := "# Changelog\n\n## [1.2.4] - " + time.Now().Format("2006-01-02") + "\n\n- Add b\n- Add a\n\n## [1.2.3] - 2024-01-01\n\n- Initial release.\n"
+	got, _ := os.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md"))
+	if string(got) != want {
+		t.Errorf("changelog mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestChangelogFirstParent verifies that ChangelogFirstParent leaves the commits
+// of a merged branch out of the changelog section, keeping the merge itself.
+func TestChangelogFirstParent(t *testing.T) {
+	for _, firstParent := range []bool{false, true} {
+		t.Run(fmt.Sprintf("firstParent=%v", firstParent), func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+			commitAllT(t, tmpDir, "initial commit")
+			gitT(t, tmpDir, "tag", "v1.2.3")
+			gitT(t, tmpDir, "checkout", "-q", "-b", "feature")
+			writeFilesT(t, tmpDir, map[string]string{"a.go": "package foo\n"})
+			commitAllT(t, tmpDir, "Start feature")
+			writeFilesT(t, tmpDir, map[string]string{"b.go": "package foo\n"})
+			commitAllT(t, tmpDir, "Finish feature")
+			gitT(t, tmpDir, "checkout", "-q", "-")
+			writeFilesT(t, tmpDir, map[string]string{"c.go": "package foo\n"})
+			commitAllT(t, tmpDir, "Fix mainline")
+			gitT(t, tmpDir, "merge", "--no-ff", "-m", "Merge feature", "feature")
+
+			if _, err := RunWithConfig(Config{
+				Dir:                  tmpDir,
+				VersionFile:          "version.go",
+				VersionArg:           "patch",
+				ExtraFiles:           []string{"version.go"},
+				Changelog:            "CHANGELOG.md",
+				ChangelogFirstParent: firstParent,
+			}); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			got, _ := os.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md"))
+			if firstParent {
+				want := "# Changelog\n\n## [1.2.4] - " + time.Now().Format("2006-01-02") + "\n\n- Merge feature\n- Fix mainline\n"
+				if string(got) != want {
+					t.Errorf("changelog mismatch\ngot:\n%s\nwant:\n%s", got, want)
+				}
+				return
+			}
+			for _, entry := range []string{"Merge feature", "Fix mainline", "Finish feature", "Start feature"} {
+				if !strings.Contains(string(got), "- "+entry+"\n") {
+					t.Errorf("changelog lacks %q:\n%s", entry, got)
+				}
+			}
+		})
+	}
+}
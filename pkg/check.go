@@ -0,0 +1,317 @@
+package goversion
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Errors returned, possibly wrapped, by CheckReleasable and Run when a release is
+// blocked. Use errors.Is to test for them.
+var (
+	ErrGitUnavailable   = errors.New("git is not available on the system")
+	ErrNoCommits        = errors.New("repository has no commits")
+	ErrDirtyWorkingTree = errors.New("working directory is dirty")
+	ErrTagExists        = errors.New("tag already exists")
+	ErrBehindUpstream   = errors.New("branch is behind its upstream")
+	ErrVersionMismatch  = errors.New("version files disagree")
+)
+
+// releasePlan is the outcome of the pre-flight checks: the versions involved and
+// what a release needs to touch.
+type releasePlan struct {
+	oldVersion string
+	newVersion string
+	bumpType   string
+	allowed    []string // Files that may be modified: extra files, the version file, and go.mod.
+	modDir     string   // Directory of go.mod for a major bump, if any.
+	oldModPath string   // Module path in go.mod before a major bump.
+	lastTag    string   // Highest existing release tag, if a tag is created.
+}
+
+// CheckReleasable runs every guard a release performed with cfg would run, without
+// modifying anything, and returns the first reason the release would be refused.
+// It checks that git is available, HEAD is on cfg.RequireBranch if set, the
+// repository has a commit, the new version can be computed, no file outside those
+// being released is dirty, the files to write are neither ignored nor read-only,
+// the tag does not exist yet, and HEAD is not behind the branch's upstream, or
+// the branch given by cfg.Remote and cfg.TrackingBranch, as of the last fetch.
+// The version file and any cfg.Reconcile files must hold the same version.
+//
+// Guard failures wrap ErrGitUnavailable, ErrNoCommits, ErrDirtyWorkingTree,
+// ErrTagExists, ErrBehindUpstream, or ErrVersionMismatch where applicable.
+func CheckReleasable(cfg Config) error {
+	cfg, err := cfg.withResolvedPaths()
+	if err != nil {
+		return err
+	}
+	if err := cfg.checkGit(); err != nil {
+		return err
+	}
+	if cfg.RequireBranch != "" {
+		if err := checkBranch(cfg.git(), cfg.Dir, cfg.RequireBranch); err != nil {
+			return err
+		}
+	}
+	_, err = preflight(cfg, false)
+	return err
+}
+
+// preflight reads the current version, computes the new one, and runs the
+// release guards in the order Run relies on. A missing version file is written
+// only if create is set. The versions in the returned plan are filled in as far
+// as they were determined, even on error.
+func preflight(cfg Config, create bool) (releasePlan, error) {
+	var plan releasePlan
+
+	// 1. Ensure git is available and there is history to release on top of
+	if err := cfg.checkGit(); err != nil {
+		return plan, err
+	}
+	if _, err := cfg.git().RevParse(cfg.Dir, "--verify", "--quiet", "HEAD"); err != nil {
+		return plan, fmt.Errorf("%w; commit something before releasing", ErrNoCommits)
+	}
+
+	// 1.5. Make sure the files that should agree on the version still do
+	if err := cfg.checkReconcile(); err != nil {
+		return plan, err
+	}
+
+	// 2. Read the current version
+	current, err := readBaseVersion(cfg, create)
+	if err != nil {
+		return plan, err
+	}
+	plan.oldVersion = current
+
+	// 3. Determine new version
+	plan.newVersion, plan.bumpType, err = computeNewVersion(cfg, current)
+	if err != nil {
+		return plan, err
+	}
+
+	// Prepare allowed list for dirty check
+	plan.allowed = append(append([]string{}, cfg.ExtraFiles...), cfg.VersionFile)
+	plan.allowed = append(plan.allowed, cfg.MirrorFiles...)
+	if cfg.writesChangelog(plan.bumpType) {
+		plan.allowed = append(plan.allowed, cfg.Changelog)
+	}
+	if err := checkChangelog(cfg); err != nil {
+		return plan, err
+	}
+	release := VersionMeta{OldVersion: plan.oldVersion, NewVersion: plan.newVersion, BumpType: plan.bumpType}
+	release.Tag = cfg.releaseTag(plan.bumpType, plan.newVersion)
+	if _, err := cfg.commitMessage(release); err != nil {
+		return plan, err
+	}
+
+	if err := checkMirrorFiles(cfg.MirrorFiles); err != nil {
+		return plan, err
+	}
+	if err := cfg.checkBumpYAMLLists(); err != nil {
+		return plan, err
+	}
+
+	// Missing extra files are only known to be an error if there is no post-bump
+	// script that could create them.
+	if cfg.PostBumpScript == "" && !cfg.AllowMissingFiles {
+		if _, err := existingExtraFiles(cfg); err != nil {
+			return plan, err
+		}
+	}
+
+	if err := checkNewModulePath(cfg, plan.bumpType); err != nil {
+		return plan, err
+	}
+
+	// 4. Detect module for major bumps
+	if plan.bumpType == "major" {
+		root, err := cfg.goModDir()
+		if err != nil && cfg.ModFile != "" {
+			return plan, err
+		}
+		if err != nil && cfg.NewModulePath != "" {
+			return plan, fmt.Errorf("a new module path was given but no go.mod was found")
+		}
+		if err == nil && cfg.ModTidyRequired {
+			if _, err := exec.LookPath("go"); err != nil {
+				return plan, fmt.Errorf("ModTidyRequired (-mod-tidy-required) needs the go command: %w", err)
+			}
+		}
+		if err == nil {
+			plan.modDir = root
+			// Read existing module path
+			data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+			if err != nil {
+				return plan, fmt.Errorf("reading go.mod: %w", err)
+			}
+			f, err := modfile.Parse("go.mod", data, nil)
+			if err != nil {
+				return plan, fmt.Errorf("parsing go.mod: %w", err)
+			}
+			plan.oldModPath = f.Module.Mod.Path
+			plan.allowed = append(plan.allowed, filepath.Join(root, "go.mod"))
+		}
+	}
+
+	// 5. Check for uncommitted files
+	if err := cfg.checkDirty(plan.allowed); err != nil {
+		return plan, err
+	}
+
+	// 5.5. Make sure nothing we intend to commit is ignored by git
+	if err := checkIgnoredFiles(cfg.git(), cfg.Dir, append(plan.allowed, cfg.bumpFiles()...)); err != nil {
+		return plan, err
+	}
+
+	// 5.6. Make sure every file we may write is writable before changing anything
+	if err := checkWritableFiles(append(plan.allowed, cfg.bumpFiles()...)); err != nil {
+		return plan, err
+	}
+
+	// 5.7. Make sure the tag is free and the branch is not behind its upstream
+	if tag := cfg.tagName(plan.newVersion); cfg.createsTag(plan.bumpType, plan.newVersion) && tagExists(cfg.git(), cfg.Dir, tag) {
+		return plan, fmt.Errorf("%w: %s", ErrTagExists, tag)
+	}
+	if cfg.createsTag(plan.bumpType, plan.newVersion) {
+		if plan.lastTag, err = cfg.checkTagOrder(plan.newVersion); err != nil {
+			return plan, err
+		}
+	}
+	if err := cfg.checkUpToDate(); err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+// highestTag returns the existing release tag (one with the tag prefix, matching
+// TagPattern if set) with the highest version, and that version, or "" if there
+// is none. Build metadata is ignored, and tags that are not the prefix followed
+// by a semantic version are skipped.
+func (cfg Config) highestTag(dir string) (tag, version string, err error) {
+	tags, err := cfg.git().Tags(dir, cfg.tagGlob())
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list tags: %w", err)
+	}
+	for _, name := range tags {
+		v, err := ParseTag(name, cfg.tagPrefix())
+		if err != nil {
+			continue
+		}
+		if version == "" || CompareVersions(v, version, false) > 0 {
+			tag, version = name, v
+		}
+	}
+	return tag, version, nil
+}
+
+// checkTagOrder returns the highest existing release tag, as found by
+// highestTag, or "" if there is none. It warns if the tag for newVersion would
+// sort below it, which usually means the wrong version or branch, or returns an
+// error if StrictOrdering is set.
+func (cfg Config) checkTagOrder(newVersion string) (string, error) {
+	if newVersion == "dev" {
+		return "", nil
+	}
+	highestTag, highest, err := cfg.highestTag(cfg.Dir)
+	if err != nil {
+		return "", err
+	}
+	if highest == "" || CompareVersions(newVersion, highest, false) >= 0 {
+		return highestTag, nil
+	}
+	msg := fmt.Sprintf("the new tag %s sorts below the existing tag %s", cfg.tagName(newVersion), highestTag)
+	if cfg.StrictOrdering {
+		return highestTag, fmt.Errorf("%s; refusing to release with StrictOrdering (-strict-ordering)", msg)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s; is this the right version?\n", msg)
+	return highestTag, nil
+}
+
+// upstreamRef returns the remote-tracking branch HEAD is compared against, as a
+// revision and as a name for messages. If cfg.Remote or cfg.TrackingBranch is set,
+// it is refs/remotes/<remote>/<branch>, with the remote defaulting to "origin" and
+// the branch to the current one; otherwise it is the current branch's configured
+// upstream. The error says why there is none.
+func (cfg Config) upstreamRef(g Git) (ref, name string, err error) {
+	if cfg.Remote == "" && cfg.TrackingBranch == "" {
+		name, err := g.RevParse(cfg.Dir, "--abbrev-ref", "--symbolic-full-name", "@{upstream}")
+		if err != nil {
+			return "", "", errors.New("the current branch has no upstream")
+		}
+		return "@{upstream}", name, nil
+	}
+	branch := cfg.TrackingBranch
+	if branch == "" {
+		if branch, err = g.RevParse(cfg.Dir, "--abbrev-ref", "HEAD"); err != nil || branch == "HEAD" {
+			return "", "", errors.New("HEAD is not on a branch; set TrackingBranch (-tracking-branch)")
+		}
+	}
+	name = cmp.Or(cfg.Remote, "origin") + "/" + branch
+	ref = "refs/remotes/" + name
+	if _, err := g.RevParse(cfg.Dir, "--verify", "--quiet", ref); err != nil {
+		return "", "", fmt.Errorf("%s has not been fetched", name)
+	}
+	return ref, name, nil
+}
+
+// checkUpToDate returns an error wrapping ErrBehindUpstream if the remote-tracking
+// branch chosen by upstreamRef has commits HEAD lacks. Only that branch as of the
+// last fetch is consulted; nothing is fetched. A branch without an upstream is
+// not checked, unless cfg.Remote or cfg.TrackingBranch is set, in which case the
+// branch they name must exist.
+func (cfg Config) checkUpToDate() error {
+	g := cfg.git()
+	ref, upstream, err := cfg.upstreamRef(g)
+	if err != nil {
+		if cfg.Remote == "" && cfg.TrackingBranch == "" {
+			return nil
+		}
+		return fmt.Errorf("cannot check that HEAD is up to date: %w", err)
+	}
+	behind, err := g.RevList(cfg.Dir, "--count", "HEAD.."+ref)
+	if err != nil {
+		return err
+	}
+	if behind != "0" {
+		return fmt.Errorf("%w: %s is %s commit(s) ahead; pull before releasing", ErrBehindUpstream, upstream, behind)
+	}
+	return nil
+}
+
+// checkReconcile returns an error wrapping ErrVersionMismatch, listing the
+// version held by each file, unless the version file and every cfg.Reconcile file
+// hold the same version. A leading "v" is ignored.
+func (cfg Config) checkReconcile() error {
+	if len(cfg.Reconcile) == 0 {
+		return nil
+	}
+	var report []string
+	seen := make(map[string]bool)
+	versions := make(map[string]bool)
+	for _, path := range slices.Concat([]string{cfg.VersionFile}, cfg.Reconcile) {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		version, err := ValidateVersionFile(path, cfg.VersionVar)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile versions (-reconcile): %w", err)
+		}
+		version = strings.TrimPrefix(version, "v")
+		versions[version] = true
+		report = append(report, path+": "+version)
+	}
+	if len(versions) > 1 {
+		return fmt.Errorf("%w (-reconcile); fix them by hand before bumping:\n  %s", ErrVersionMismatch, strings.Join(report, "\n  "))
+	}
+	return nil
+}
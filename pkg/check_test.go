@@ -0,0 +1,261 @@
+package goversion
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckReleasable verifies that each failing guard is reported with its
+// typed error, and that a releasable tree passes without being modified.
+func TestCheckReleasable(t *testing.T) {
+	// newRepo returns a repository with a committed version file at 1.2.3.
+	newRepo := func(t *testing.T) (string, Config) {
+		dir := initTestRepo(t)
+		versionFile := filepath.Join(dir, "version.go")
+		if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+			t.Fatal(err)
+		}
+		commitAllT(t, dir, "initial commit")
+		return dir, Config{Dir: dir, VersionFile: versionFile, VersionArg: "patch", ExtraFiles: []string{versionFile}}
+	}
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) Config
+		want  error
+	}{
+		{
+			name: "releasable",
+			setup: func(t *testing.T) Config {
+				_, cfg := newRepo(t)
+				return cfg
+			},
+		},
+		{
+			name: "git unavailable",
+			setup: func(t *testing.T) Config {
+				_, cfg := newRepo(t)
+				t.Setenv("PATH", "")
+				return cfg
+			},
+			want: ErrGitUnavailable,
+		},
+		{
+			name: "no commits",
+			setup: func(t *testing.T) Config {
+				dir := initTestRepo(t)
+				return Config{Dir: dir, VersionFile: "version.go", VersionArg: "patch"}
+			},
+			want: ErrNoCommits,
+		},
+		{
+			name: "dirty",
+			setup: func(t *testing.T) Config {
+				dir, cfg := newRepo(t)
+				writeFilesT(t, dir, map[string]string{"notes.txt": "wip\n"})
+				return cfg
+			},
+			want: ErrDirtyWorkingTree,
+		},
+		{
+			name: "tag exists",
+			setup: func(t *testing.T) Config {
+				dir, cfg := newRepo(t)
+				gitT(t, dir, "tag", "v1.2.4")
+				return cfg
+			},
+			want: ErrTagExists,
+		},
+		{
+			name: "behind upstream",
+			setup: func(t *testing.T) Config {
+				upstream, _ := newRepo(t)
+				clone := filepath.Join(t.TempDir(), "clone")
+				gitT(t, upstream, "clone", upstream, clone)
+				writeFilesT(t, upstream, map[string]string{"CHANGELOG.md": "new\n"})
+				commitAllT(t, upstream, "upstream change")
+				gitT(t, clone, "fetch")
+				versionFile := filepath.Join(clone, "version.go")
+				return Config{Dir: clone, VersionFile: versionFile, VersionArg: "patch", ExtraFiles: []string{versionFile}}
+			},
+			want: ErrBehindUpstream,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.setup(t)
+			err := CheckReleasable(cfg)
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("CheckReleasable failed: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.want) {
+				t.Errorf("CheckReleasable error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// TestCheckReleasableDoesNotCreateVersionFile verifies that the check leaves a
+// missing version file missing, where Run would create it.
+func TestCheckReleasableDoesNotCreateVersionFile(t *testing.T) {
+	dir := initTestRepo(t)
+	writeFilesT(t, dir, map[string]string{"README.md": "# foo\n"})
+	commitAllT(t, dir, "initial commit")
+
+	versionFile := filepath.Join(dir, "version.go")
+	if err := CheckReleasable(Config{Dir: dir, VersionFile: versionFile, VersionArg: "minor"}); err != nil {
+		t.Fatalf("CheckReleasable failed: %v", err)
+	}
+	if _, err := os.Stat(versionFile); !os.IsNotExist(err) {
+		t.Errorf("expected %s to remain missing, stat error: %v", versionFile, err)
+	}
+}
+
+// TestPreviousTag verifies that PreviousTag is the highest release tag before
+// the bump, by version rather than by creation order, ignoring tags with
+// another prefix.
+func TestPreviousTag(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	for _, tag := range []string{"v1.2.3", "v1.10.0-rc.1", "v0.9.0", "api/v9.0.0", "nightly"} {
+		gitT(t, tmpDir, "tag", tag)
+	}
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "1.10.0",
+		ExtraFiles:  []string{"version.go"},
+	}
+
+	meta, err := DryRunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if meta.PreviousTag != "v1.10.0-rc.1" || meta.Tag != "v1.10.0" {
+		t.Errorf("dry run tags = %q...%q, want v1.10.0-rc.1...v1.10.0", meta.PreviousTag, meta.Tag)
+	}
+	if meta, err = RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.PreviousTag != "v1.10.0-rc.1" {
+		t.Errorf("PreviousTag = %q, want v1.10.0-rc.1", meta.PreviousTag)
+	}
+
+	cfg.VersionArg = "patch"
+	if meta, err = RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.PreviousTag != "v1.10.0" || meta.Tag != "v1.10.1" {
+		t.Errorf("tags = %q...%q, want v1.10.0...v1.10.1", meta.PreviousTag, meta.Tag)
+	}
+}
+
+// TestReconcile verifies that a bump with Reconcile is refused, listing each
+// file's version, when the files disagree, and bumps all of them when they agree.
+func TestReconcile(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"package.json": "{\n  \"name\": \"foo\",\n  \"version\": \"1.2.4\"\n}\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+		Reconcile:   []string{"package.json"},
+	}
+
+	for name, run := range map[string]func(Config) (VersionMeta, error){"Run": RunWithConfig, "DryRun": DryRunWithConfig} {
+		_, err := run(cfg)
+		if !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("%s error = %v, want ErrVersionMismatch", name, err)
+		}
+		for _, want := range []string{"version.go: 1.2.3", "package.json: 1.2.4"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s error %q does not mention %q", name, err, want)
+			}
+		}
+	}
+	if tags := gitT(t, tmpDir, "tag"); tags != "" {
+		t.Errorf("tags = %q, want none after a refused bump", tags)
+	}
+
+	writeFilesT(t, tmpDir, map[string]string{"package.json": "{\n  \"name\": \"foo\",\n  \"version\": \"1.2.3\"\n}\n"})
+	commitAllT(t, tmpDir, "fix drift")
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(tmpDir, "package.json")); !strings.Contains(string(got), `"version": "1.3.0"`) {
+		t.Errorf("package.json was not bumped:\n%s", got)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected the reconciled file to be committed, got status:\n%s", status)
+	}
+}
+
+// TestCheckUpToDateTrackingBranch verifies that Remote and TrackingBranch choose
+// the remote-tracking branch HEAD is compared against in place of the upstream,
+// and that a branch that was never fetched skips the check.
+func TestCheckUpToDateTrackingBranch(t *testing.T) {
+	upstream := initTestRepo(t)
+	writeFilesT(t, upstream, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, upstream, "initial commit")
+	branch := gitT(t, upstream, "rev-parse", "--abbrev-ref", "HEAD")
+	gitT(t, upstream, "checkout", "-q", "-b", "release")
+	writeFilesT(t, upstream, map[string]string{"CHANGELOG.md": "new\n"})
+	commitAllT(t, upstream, "release change")
+	gitT(t, upstream, "checkout", "-q", branch)
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	gitT(t, upstream, "clone", "-q", upstream, clone)
+
+	tests := []struct {
+		name           string
+		remote, branch string
+		want           error
+		wantErr        string
+	}{
+		{name: "upstream"},
+		{name: "remote", remote: "origin"},
+		{name: "tracking branch", branch: "release", want: ErrBehindUpstream},
+		{name: "remote and tracking branch", remote: "origin", branch: "release", want: ErrBehindUpstream},
+		{name: "not fetched", remote: "fork", branch: "release", wantErr: "fork/release has not been fetched"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckReleasable(Config{
+				Dir:            clone,
+				VersionFile:    "version.go",
+				VersionArg:     "patch",
+				ExtraFiles:     []string{"version.go"},
+				Remote:         tc.remote,
+				TrackingBranch: tc.branch,
+			})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("CheckReleasable error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("CheckReleasable failed: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.want) || !strings.Contains(err.Error(), "origin/release is 1 commit(s) ahead") {
+				t.Errorf("CheckReleasable error = %v, want origin/release to be 1 commit ahead", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package goversion
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// conventionalHeader matches the header of a Conventional Commits message,
+// capturing its type and the "!" that marks a breaking change.
+var conventionalHeader = regexp.MustCompile(`^(\w+)(?:\([^()\r\n]*\))?(!)?: \S`)
+
+// conventionalBreaking matches a footer announcing a breaking change.
+var conventionalBreaking = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+
+// conventionalBump returns the bump keyword the commit messages call for under
+// Conventional Commits: "major" for a breaking change, "minor" for a feat, and
+// "patch" for a fix or perf. It returns "" if no message calls for a release.
+func conventionalBump(messages []string) string {
+	level := ""
+	for _, msg := range messages {
+		m := conventionalHeader.FindStringSubmatch(msg)
+		switch {
+		case (m != nil && m[2] == "!") || conventionalBreaking.MatchString(msg):
+			return "major"
+		case m == nil:
+		case m[1] == "feat":
+			level = "minor"
+		case (m[1] == "fix" || m[1] == "perf") && level == "":
+			level = "patch"
+		}
+	}
+	return level
+}
+
+// sinceLatestTag returns the revision range of the commits since the latest tag
+// reachable from HEAD, "<tag>..HEAD", or "HEAD" for every commit if there is no
+// tag yet.
+func (cfg Config) sinceLatestTag(g Git) string {
+	if tag, err := g.Describe(cfg.Dir, cfg.describeOptions()); err == nil {
+		return tag + "..HEAD"
+	}
+	return "HEAD"
+}
+
+// commitMessages returns the full message of every commit in cfg.Base..HEAD, or
+// since the latest tag if Base is empty, or of every commit if there is no tag yet.
+func commitMessages(cfg Config) (rev string, messages []string, err error) {
+	g := cfg.git()
+	rev = cfg.Base + "..HEAD"
+	if cfg.Base == "" {
+		rev = cfg.sinceLatestTag(g)
+	}
+	out, err := g.Log(cfg.Dir, "--format=%B%x00", rev)
+	if err != nil {
+		return rev, nil, fmt.Errorf("failed to list commits in %s: %w", rev, err)
+	}
+	for _, msg := range strings.Split(out, "\x00") {
+		if msg = strings.TrimSpace(msg); msg != "" {
+			messages = append(messages, msg)
+		}
+	}
+	return rev, messages, nil
+}
+
+// creditTrailers returns a "Co-authored-by" trailer for each author of a commit
+// since the latest tag, or of every commit if there is no tag yet, most recent
+// first. Authors are told apart by email, ignoring case.
+func creditTrailers(cfg Config) ([]string, error) {
+	g := cfg.git()
+	rev := cfg.sinceLatestTag(g)
+	out, err := g.Log(cfg.Dir, "--format=%aN <%aE>", rev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commit authors in %s: %w", rev, err)
+	}
+	var trailers []string
+	seen := make(map[string]bool)
+	for _, author := range strings.Split(out, "\n") {
+		author = strings.TrimSpace(author)
+		email := author
+		if i := strings.LastIndex(author, "<"); i >= 0 {
+			email = author[i:]
+		}
+		if author == "" || seen[strings.ToLower(email)] {
+			continue
+		}
+		seen[strings.ToLower(email)] = true
+		trailers = append(trailers, "Co-authored-by: "+author)
+	}
+	return trailers, nil
+}
+
+// commitBumpLevel returns the bump keyword for the "from-commits" version
+// argument: the level the Conventional Commits in range call for, as reported by
+// conventionalBump. It is an error if none of them calls for a release.
+func commitBumpLevel(cfg Config) (string, error) {
+	rev, messages, err := commitMessages(cfg)
+	if err != nil {
+		return "", err
+	}
+	level := conventionalBump(messages)
+	if level == "" {
+		return "", fmt.Errorf("none of the %d commit(s) in %s is a feat, fix, perf, or breaking change", len(messages), rev)
+	}
+	return level, nil
+}
@@ -0,0 +1,109 @@
+package goversion
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestConventionalBump verifies the bump level each kind of Conventional Commit
+// calls for, and that the highest level wins.
+func TestConventionalBump(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     string
+	}{
+		{name: "fix", messages: []string{"fix: handle nil", "docs: typo"}, want: "patch"},
+		{name: "perf", messages: []string{"perf(parser): cache regexps"}, want: "patch"},
+		{name: "feat", messages: []string{"fix: a", "feat(cli): add -base", "chore: deps"}, want: "minor"},
+		{name: "bang", messages: []string{"feat: a", "refactor!: drop Run"}, want: "major"},
+		{name: "footer", messages: []string{"fix: a\n\nBREAKING CHANGE: Run is gone"}, want: "major"},
+		{name: "nothing to release", messages: []string{"docs: readme", "Merge branch 'x'", "feature: not a type"}, want: ""},
+		{name: "no space", messages: []string{"fix:typo"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conventionalBump(tt.messages); got != tt.want {
+				t.Errorf("conventionalBump = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFromCommitsBase verifies that from-commits uses the commits since the
+// latest tag by default, and only the base-to-HEAD range with Base.
+func TestFromCommitsBase(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.0.0\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v1.0.0")
+	gitT(t, tmpDir, "branch", "-M", "main")
+	writeFilesT(t, tmpDir, map[string]string{"a.go": "package foo\n"})
+	commitAllT(t, tmpDir, "feat: add a")
+	gitT(t, tmpDir, "checkout", "-b", "topic")
+	writeFilesT(t, tmpDir, map[string]string{"b.go": "package foo\n"})
+	commitAllT(t, tmpDir, "fix: correct b")
+
+	tests := []struct {
+		base     string
+		wantType string
+		want     string
+	}{
+		{base: "", wantType: "minor", want: "1.1.0"},
+		{base: "main", wantType: "patch", want: "1.0.1"},
+	}
+	for _, tt := range tests {
+		meta, err := RunWithConfig(Config{
+			Dir:         tmpDir,
+			VersionFile: "version.go",
+			VersionArg:  "from-commits",
+			Base:        tt.base,
+			DryRun:      true,
+		})
+		if err != nil {
+			t.Fatalf("base %q: Run failed: %v", tt.base, err)
+		}
+		if meta.BumpType != tt.wantType || meta.NewVersion != tt.want {
+			t.Errorf("base %q: bump = %s to %s, want %s to %s", tt.base, meta.BumpType, meta.NewVersion, tt.wantType, tt.want)
+		}
+	}
+
+	gitT(t, tmpDir, "checkout", "main")
+	if _, err := RunWithConfig(Config{Dir: tmpDir, VersionFile: "version.go", VersionArg: "from-commits", Base: "main", DryRun: true}); err == nil {
+		t.Error("expected an error when the base-to-HEAD range has nothing to release")
+	}
+}
+
+// TestCreditAuthors verifies that CreditAuthors adds one Co-authored-by trailer
+// per author of a commit since the latest tag, told apart by email.
+func TestCreditAuthors(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v1.2.3")
+	authors := []string{
+		"Ada Lovelace <ada@example.com>",
+		"Grace Hopper <grace@example.com>",
+		"Ada L. <ADA@example.com>",
+		"Ada Lovelace <ada@example.com>",
+	}
+	for i, author := range authors {
+		writeFilesT(t, tmpDir, map[string]string{"file.txt": strings.Repeat("x", i+1)})
+		gitT(t, tmpDir, "add", "-A")
+		gitT(t, tmpDir, "commit", "-m", "change", "--author", author)
+	}
+
+	if _, err := RunWithConfig(Config{
+		Dir:           tmpDir,
+		VersionFile:   "version.go",
+		VersionArg:    "patch",
+		ExtraFiles:    []string{"version.go"},
+		CreditAuthors: true,
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	got := gitT(t, tmpDir, "log", "-1", "--format=%(trailers:key=Co-authored-by,valueonly)")
+	if want := "Ada Lovelace <ada@example.com>\nGrace Hopper <grace@example.com>"; got != want {
+		t.Errorf("Co-authored-by trailers = %q, want %q", got, want)
+	}
+}
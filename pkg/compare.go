@@ -0,0 +1,23 @@
+package goversion
+
+import (
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// CompareVersions compares two semantic versions, with or without a "v" prefix,
+// returning -1, 0, or +1 as a sorts before, equal to, or after b.
+//
+// By default build metadata is ignored, as the semver specification requires,
+// so 1.2.3+1 and 1.2.3+2 compare equal. If compareBuild is set, versions of
+// equal precedence are ordered by comparing their build metadata lexically.
+// This is non-standard and intended only for artifacts whose build metadata
+// carries a meaningful ordering.
+func CompareVersions(a, b string, compareBuild bool) int {
+	va, vb := normalizeVersion(a), normalizeVersion(b)
+	if c := semver.Compare(va, vb); c != 0 || !compareBuild {
+		return c
+	}
+	return strings.Compare(semver.Build(va), semver.Build(vb))
+}
@@ -0,0 +1,56 @@
+package goversion
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCompareVersions covers semver precedence with and without the non-standard
+// build metadata tiebreaker.
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b         string
+		compareBuild bool
+		want         int
+	}{
+		{"1.2.3", "1.2.4", false, -1},
+		{"v2.0.0", "1.9.9", false, 1},
+		{"1.2.3-rc.1", "1.2.3", false, -1},
+		{"1.2.3+1", "1.2.3+2", false, 0},
+		{"1.2.3+1", "1.2.3+2", true, -1},
+		{"1.2.3+2", "1.2.3+1", true, 1},
+		{"1.2.3+1", "1.2.3+1", true, 0},
+		{"1.2.4+1", "1.2.3+2", true, 1},
+	}
+	for _, tc := range tests {
+		if got := CompareVersions(tc.a, tc.b, tc.compareBuild); got != tc.want {
+			t.Errorf("CompareVersions(%q, %q, %v) = %d, want %d", tc.a, tc.b, tc.compareBuild, got, tc.want)
+		}
+	}
+}
+
+// TestDowngradeDetection verifies that explicit downgrades are refused, and that
+// build metadata only counts toward ordering when CompareBuildMetadata is set.
+func TestDowngradeDetection(t *testing.T) {
+	versionFile := filepath.Join(t.TempDir(), "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3+2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DryRunWithConfig(Config{VersionFile: versionFile, VersionArg: "1.2.3+1"}); err != nil {
+		t.Errorf("spec-compliant comparison should treat 1.2.3+1 as equal, got: %v", err)
+	}
+
+	_, err := DryRunWithConfig(Config{VersionFile: versionFile, VersionArg: "1.2.3+1", CompareBuildMetadata: true})
+	if err == nil || !strings.Contains(err.Error(), "lower than the current version") {
+		t.Errorf("expected downgrade error with CompareBuildMetadata, got: %v", err)
+	}
+
+	if _, err := DryRunWithConfig(Config{VersionFile: versionFile, VersionArg: "1.0.0"}); err == nil {
+		t.Error("expected downgrade to 1.0.0 to be refused")
+	}
+	if _, err := DryRunWithConfig(Config{VersionFile: versionFile, VersionArg: "1.0.0", AllowDowngrade: true}); err != nil {
+		t.Errorf("AllowDowngrade should permit 1.0.0, got: %v", err)
+	}
+}
@@ -0,0 +1,430 @@
+package goversion
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Config holds every option for a version bump. It is the library counterpart
+// of the CLI flags; Run and DryRun are shorthands for the common fields.
+type Config struct {
+	Dir                  string      `json:"dir"`                  // Directory git runs in and relative paths are resolved against; defaults to the current directory.
+	VersionFile          string      `json:"versionFile"`          // Path to the Go file containing the version declaration.
+	VersionVar           string      `json:"versionVar"`           // Name of the var or const holding the version in a Go VersionFile (default "Version"); a package qualifier, as in "buildinfo.Version", is ignored.
+	VersionArg           string      `json:"versionArg"`           // Bump keyword or explicit version.
+	KeepVPrefix          bool        `json:"keepVPrefix"`          // Write the version into VersionFile and MirrorFiles with a leading "v" (e.g. "v1.2.3"); tags are unaffected.
+	LenientVersion       bool        `json:"lenientVersion"`       // Read a VersionFile holding only major.minor, or major, as if its missing components were zero (e.g. "1.2" as "1.2.0"); the full version is written back.
+	NoCreate             bool        `json:"noCreate"`             // Refuse to run if VersionFile does not exist instead of creating it from the latest tag or InitialVersion.
+	NoGofmt              bool        `json:"noGofmt"`              // Replace only the Version string literal in a Go VersionFile, keeping its formatting, instead of rewriting the file.
+	ExtraFiles           []string    `json:"extraFiles"`           // Additional files to stage and commit.
+	FilesFrom            string      `json:"filesFrom"`            // If set, a file listing more ExtraFiles, one path per line; blank lines and "#" comments are ignored.
+	AllowMissingFiles    bool        `json:"allowMissingFiles"`    // Warn about and skip extra files that do not exist instead of refusing to commit.
+	MirrorFiles          []string    `json:"mirrorFiles"`          // Additional Go files whose Version string literal is set to the new version.
+	BumpFiles            []string    `json:"bumpFiles"`            // Additional files whose project version is bumped; "file.yaml#key.path" targets one YAML key.
+	Reconcile            []string    `json:"reconcile"`            // Files that must hold the same version as VersionFile before a bump; each is then bumped like a BumpFiles entry.
+	BumpYAMLLists        []string    `json:"bumpYAMLLists"`        // "file.yaml#list[*].key" specs: list element versions bumped only where they equal the file's own version.
+	PrereleaseSeparator  string      `json:"prereleaseSeparator"`  // Separator before the prerelease in BumpFiles without a key path, e.g. "." for "1.2.3.rc1" (default "-").
+	BumpAllFields        []string    `json:"bumpAllFields"`        // Additional files in which every main version field (e.g. version and appVersion) is bumped.
+	Changelog            string      `json:"changelog"`            // If set, the Markdown changelog that gets a section for each release, listing the commits since the latest tag.
+	AmendChangelog       bool        `json:"amendChangelog"`       // Rename the changelog's unreleased section to the release instead of adding a generated section.
+	ChangelogHeading     string      `json:"changelogHeading"`     // Heading of the unreleased section renamed by AmendChangelog (default "## [Unreleased]").
+	ChangelogUnreleased  bool        `json:"changelogUnreleased"`  // With AmendChangelog, add a fresh, empty unreleased section above the release.
+	ChangelogFirstParent bool        `json:"changelogFirstParent"` // List only mainline commits in the generated changelog section (git log --first-parent).
+	VersionHook          string      `json:"versionHook"`          // Script run once the new version is computed, before anything is written; a version it prints replaces the new version.
+	PostBumpScript       string      `json:"postBumpScript"`       // Script run after bumping but before committing.
+	BuildCheck           bool        `json:"buildCheck"`           // Run "go build ./..." in the module after bumping and, if it fails, restore the files the release wrote and refuse to commit.
+	ModTidy              bool        `json:"modTidy"`              // Major bumps only: run "go mod tidy" in the module after rewriting it and commit go.sum too; skipped with a warning if go is not installed.
+	ModTidyRequired      bool        `json:"modTidyRequired"`      // Like ModTidy, but refuse to release if go is not installed.
+	Backup               bool        `json:"backup"`               // Copy each file to "<file>.bak" before modifying it; backups are neither committed nor removed.
+	Timings              bool        `json:"timings"`              // Record the duration of each phase of a run in VersionMeta.Timings.
+	DryRun               bool        `json:"dryRun"`               // Report what would change without modifying anything.
+	Ensure               bool        `json:"ensure"`               // Idempotently complete a release of the explicit VersionArg.
+	IdempotentPrerelease bool        `json:"idempotentPrerelease"` // Make a "prerelease" bump a no-op if HEAD is the commit that set the current prerelease, as on a retry.
+	AllowDirty           bool        `json:"allowDirty"`           // Skip the dirty working tree check entirely, printing a warning instead.
+	CleanIndexOnly       bool        `json:"cleanIndexOnly"`       // Only refuse to release over staged changes to other files; unstaged changes and untracked files are ignored and left out of the commit.
+	RequireBranch        string      `json:"requireBranch"`        // If set, refuse to release unless HEAD is on this branch.
+	AllowDowngrade       bool        `json:"allowDowngrade"`       // Permit an explicit version lower than the current one.
+	CompareBuildMetadata bool        `json:"compareBuildMetadata"` // Break comparison ties on build metadata, lexically (non-standard).
+	NewModulePath        string      `json:"newModulePath"`        // Major bumps only: module path to use verbatim instead of base + "/vN".
+	ModFile              string      `json:"modFile"`              // If set, the go.mod to update on major bumps instead of the nearest one above VersionFile.
+	CommitSnapshot       bool        `json:"commitSnapshot"`       // Commit (but never tag) the version file for a "snapshot" bump instead of only writing it.
+	NoTagOnPrerelease    bool        `json:"noTagOnPrerelease"`    // Commit a new prerelease version without tagging it; final releases are still tagged.
+	AnnotateTag          bool        `json:"annotateTag"`          // Create an annotated tag (git tag --annotate) with the release commit message instead of a lightweight one.
+	CommitTemplate       string      `json:"commitTemplate"`       // If set, a text/template for the release commit message over VersionMeta's OldVersion, NewVersion, Tag, and BumpType; the default message is the new version.
+	ConventionalMessage  bool        `json:"conventionalMessage"`  // Use a Conventional Commits message, "chore(<ConventionalScope>): <new version>"; cannot be combined with CommitTemplate.
+	ConventionalScope    string      `json:"conventionalScope"`    // Scope of the ConventionalMessage (default "release").
+	Trailers             []string    `json:"trailers"`             // Commit message trailers such as "Release-As: 2.0.0".
+	AllowEmpty           bool        `json:"allowEmpty"`           // Create the release commit even if no file changed (git commit --allow-empty).
+	Signoff              bool        `json:"signoff"`              // Add a Signed-off-by trailer for the committer (DCO).
+	CreditAuthors        bool        `json:"creditAuthors"`        // Add a Co-authored-by trailer for each author of a commit since the latest tag.
+	Force                bool        `json:"force"`                // Let Undo remove a release commit its upstream branch already contains.
+	CleanupOnTagFailure  bool        `json:"cleanupOnTagFailure"`  // If tagging fails after committing, undo the release commit (git reset --soft HEAD~1).
+	Scope                string      `json:"scope"`                // If set, the directory of one module in a monorepo: the dirty check ignores changes outside it, and TagPrefix and TagPattern default to "<dir>/v" and "<dir>/v*".
+	TagPrefix            string      `json:"tagPrefix"`            // Prefix of release tags, followed by the version (default "v").
+	TagPattern           string      `json:"tagPattern"`           // If set, only tags matching this glob (git describe --match) are read, e.g. "api/v*".
+	BranchTagsOnly       bool        `json:"branchTagsOnly"`       // Only read tags on the current branch's first-parent history (git describe --first-parent), with TagSort "topo".
+	TagSort              string      `json:"tagSort"`              // How from-git picks its tag: "semver" (default: the highest version), "topo" (the nearest reachable from HEAD), or "date" (the most recently created).
+	Remote               string      `json:"remote"`               // If set, the remote whose copy of the branch HEAD must not be behind, instead of the branch's upstream (default "origin" with TrackingBranch).
+	TrackingBranch       string      `json:"trackingBranch"`       // If set, the branch on Remote HEAD must not be behind, instead of the branch's upstream (default the current branch with Remote).
+	StrictOrdering       bool        `json:"strictOrdering"`       // Refuse to create a tag that sorts below the highest existing release tag, instead of warning.
+	Base                 string      `json:"base"`                 // For "from-commits": the ref whose commits are excluded (git log Base..HEAD) instead of the latest tag's, e.g. "origin/main".
+	From                 string      `json:"from"`                 // If set, the version to bump from instead of the version file's current value.
+	InitialVersion       string      `json:"initialVersion"`       // Version assumed when there is no version file and no git tag (default "dev", i.e. 0.0.0).
+	OnEvent              func(Event) `json:"-"`                    // If set, receives progress events as the run proceeds.
+	Git                  Git         `json:"-"`                    // If set, performs git operations in place of the git binary (ExecGit).
+	GitBackend           string      `json:"gitBackend"`           // Git backend used when Git is nil: "exec" (default), or one added by RegisterGitBackend such as "go-git".
+	GitBin               string      `json:"gitBin"`               // If set, the git binary the exec backend runs instead of "git" on PATH.
+}
+
+// withResolvedPaths returns a copy of cfg in which Dir is absolute and relative
+// file paths are joined to it, so that they do not depend on the process working
+// directory. Paths are left unchanged if Dir is empty. The paths listed in
+// FilesFrom are added to ExtraFiles first. It also selects the Git implementation
+// named by GitBackend if Git is nil, and applies the tag defaults of Scope.
+func (cfg Config) withResolvedPaths() (Config, error) {
+	if cfg.FilesFrom != "" {
+		listed, err := readFileList(cfg.FilesFrom, cfg.Dir)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.ExtraFiles = slices.Concat(cfg.ExtraFiles, listed)
+		cfg.FilesFrom = "" // Listed once, even if paths are resolved again.
+	}
+	if cfg.Git == nil && cfg.GitBackend != "" {
+		newGit, ok := gitBackends[cfg.GitBackend]
+		if !ok {
+			return cfg, fmt.Errorf("unknown git backend %q (go-git requires importing github.com/bcomnes/goversion/v2/gogit)", cfg.GitBackend)
+		}
+		cfg.Git = newGit(cfg)
+	}
+	if cfg.Dir == "" {
+		return cfg.withScope()
+	}
+	dir, err := filepath.Abs(cfg.Dir)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to resolve directory %q: %w", cfg.Dir, err)
+	}
+	cfg.Dir = dir
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	resolveAll := func(paths []string) []string {
+		if paths == nil {
+			return nil
+		}
+		out := make([]string, len(paths))
+		for i, path := range paths {
+			out[i] = resolve(path)
+		}
+		return out
+	}
+	cfg.VersionFile = resolve(cfg.VersionFile)
+	cfg.ExtraFiles = resolveAll(cfg.ExtraFiles)
+	cfg.MirrorFiles = resolveAll(cfg.MirrorFiles)
+	cfg.BumpFiles = resolveAll(cfg.BumpFiles)
+	cfg.Reconcile = resolveAll(cfg.Reconcile)
+	cfg.BumpAllFields = resolveAll(cfg.BumpAllFields)
+	cfg.BumpYAMLLists = resolveAll(cfg.BumpYAMLLists)
+	cfg.Changelog = resolve(cfg.Changelog)
+	cfg.VersionHook = resolve(cfg.VersionHook)
+	cfg.PostBumpScript = resolve(cfg.PostBumpScript)
+	cfg.ModFile = resolve(cfg.ModFile)
+	cfg.Scope = resolve(cfg.Scope)
+	return cfg.withScope()
+}
+
+// readFileList returns the paths listed one per line in the file at path, which
+// is resolved against dir if relative. Surrounding whitespace, blank lines, and
+// lines starting with "#" are ignored. The paths are returned as written, to be
+// resolved like the ExtraFiles they join.
+func readFileList(path, dir string) ([]string, error) {
+	if dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file list: %w", err)
+	}
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+// withScope returns a copy of cfg in which an empty TagPrefix is set to Scope's
+// path from the repository root followed by "/v", as Go expects of the tags of a
+// nested module, and an empty TagPattern to that prefix followed by "*". Scope is
+// made absolute. Nothing changes if Scope is empty or the repository root itself.
+func (cfg Config) withScope() (Config, error) {
+	if cfg.Scope == "" {
+		return cfg, nil
+	}
+	scope, err := canonicalPath(cfg.Scope)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to resolve scope %q: %w", cfg.Scope, err)
+	}
+	cfg.Scope = scope
+	if err := cfg.checkGit(); err != nil {
+		return cfg, err
+	}
+	root, err := cfg.git().RevParse(cfg.Dir, "--show-toplevel")
+	if err != nil {
+		return cfg, fmt.Errorf("failed to find repository root: %w", err)
+	}
+	if root, err = canonicalPath(root); err != nil {
+		return cfg, err
+	}
+	rel, err := filepath.Rel(root, scope)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return cfg, fmt.Errorf("scope %s is outside the repository at %s", cfg.Scope, root)
+	}
+	if rel == "." {
+		return cfg, nil
+	}
+	if cfg.TagPrefix == "" {
+		cfg.TagPrefix = filepath.ToSlash(rel) + "/v"
+	}
+	if cfg.TagPattern == "" {
+		cfg.TagPattern = cfg.TagPrefix + "*"
+	}
+	return cfg, nil
+}
+
+// inScope reports whether path lies within cfg.Scope, or whether cfg has no scope.
+func (cfg Config) inScope(path string) bool {
+	if cfg.Scope == "" {
+		return true
+	}
+	rel, err := filepath.Rel(cfg.Scope, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// bumpFiles returns the path of every file whose version fields are bumped:
+// BumpFiles followed by BumpAllFields, BumpYAMLLists, and Reconcile, without any
+// "#key.path" suffix. The version file is left out of Reconcile.
+func (cfg Config) bumpFiles() []string {
+	reconcile := slices.DeleteFunc(slices.Clone(cfg.Reconcile), func(path string) bool { return path == cfg.VersionFile })
+	var paths []string
+	for _, spec := range slices.Concat(cfg.BumpFiles, cfg.BumpAllFields, cfg.BumpYAMLLists, reconcile) {
+		path, _ := splitKeyPath(spec)
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// bumpFile sets the version in one of cfg.bumpFiles to newVersion. The list elements
+// given in BumpYAMLLists are updated first, while the file still holds its current
+// version. Then every main version field is updated if path is listed in
+// BumpAllFields, only the YAML keys given as "path#key.path" in BumpFiles if there
+// are any, and the first field otherwise, as for a Reconcile file, unless path is
+// only in BumpYAMLLists.
+func (cfg Config) bumpFile(path, newVersion string) error {
+	for _, spec := range cfg.BumpYAMLLists {
+		if p, keyPath := splitKeyPath(spec); p == path {
+			if err := BumpYAMLListInFile(path, keyPath, newVersion); err != nil {
+				return err
+			}
+		}
+	}
+	if !slices.ContainsFunc(slices.Concat(cfg.BumpFiles, cfg.BumpAllFields, cfg.Reconcile), func(spec string) bool {
+		p, _ := splitKeyPath(spec)
+		return p == path
+	}) {
+		return nil
+	}
+	if slices.Contains(cfg.BumpAllFields, path) {
+		return BumpAllVersionsInFile(path, newVersion)
+	}
+	var keyPaths []string
+	for _, spec := range cfg.BumpFiles {
+		if p, keyPath := splitKeyPath(spec); p == path && keyPath != "" {
+			keyPaths = append(keyPaths, keyPath)
+		}
+	}
+	if len(keyPaths) == 0 {
+		if cfg.PrereleaseSeparator != "" {
+			return BumpVersionInFileWithSeparator(path, newVersion, cfg.PrereleaseSeparator)
+		}
+		return BumpVersionInFile(path, newVersion)
+	}
+	for _, keyPath := range keyPaths {
+		if err := BumpYAMLKeyInFile(path, keyPath, newVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bumpFileMatches returns the version fields that bumpFile would replace in path
+// with newVersion, without modifying it, in the order bumpFile replaces them.
+// Fields it would fail to find are left out, so no matches means a real run fails
+// unless path is only in BumpYAMLLists.
+func (cfg Config) bumpFileMatches(path, newVersion string) ([]BumpMatch, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	newVersion = strings.TrimPrefix(newVersion, "v")
+	var matches []BumpMatch
+	add := func(m VersionMatch, newVersion string) {
+		matches = append(matches, BumpMatch{Pattern: m.Pattern, Line: m.Line, Old: m.Version, New: newVersion})
+	}
+	for _, spec := range cfg.BumpYAMLLists {
+		p, keyPath := splitKeyPath(spec)
+		own := findYAMLKey(content, "version")
+		if p != path || len(own) == 0 {
+			continue
+		}
+		for _, m := range findYAMLKey(content, keyPath) {
+			if m.Version == own[0].Version {
+				add(m, newVersion)
+			}
+		}
+	}
+	if !slices.ContainsFunc(slices.Concat(cfg.BumpFiles, cfg.BumpAllFields, cfg.Reconcile), func(spec string) bool {
+		p, _ := splitKeyPath(spec)
+		return p == path
+	}) {
+		return matches, nil
+	}
+	if slices.Contains(cfg.BumpAllFields, path) {
+		for _, m := range findAllVersionFields(path, content) {
+			add(m, newVersion)
+		}
+		return matches, nil
+	}
+	var keyPaths []string
+	for _, spec := range cfg.BumpFiles {
+		if p, keyPath := splitKeyPath(spec); p == path && keyPath != "" {
+			keyPaths = append(keyPaths, keyPath)
+		}
+	}
+	if len(keyPaths) == 0 {
+		sep, patterns := "-", MainVersionPatterns
+		if cfg.PrereleaseSeparator != "" && cfg.PrereleaseSeparator != "-" {
+			sep = cfg.PrereleaseSeparator
+			patterns = withPrereleaseSeparator(patterns, sep)
+			newVersion = separatePrerelease(newVersion, sep)
+		}
+		if m, err := findMainVersionInFile(path, patterns); err == nil {
+			add(m, newVersion)
+		} else if start, end, err := findFirstSemver(content, sep); err == nil && semverFallback(path) {
+			add(VersionMatch{
+				Pattern: "first-semver",
+				Line:    bytes.Count(content[:start], []byte("\n")) + 1,
+				Version: string(content[start:end]),
+			}, newVersion)
+		}
+		return matches, nil
+	}
+	for _, keyPath := range keyPaths {
+		if found := findYAMLKey(content, keyPath); len(found) > 0 {
+			add(found[0], newVersion)
+		}
+	}
+	return matches, nil
+}
+
+// checkBumpYAMLLists returns an error if a BumpYAMLLists spec does not select list
+// elements, as in "Chart.yaml#dependencies[*].version".
+func (cfg Config) checkBumpYAMLLists() error {
+	for _, spec := range cfg.BumpYAMLLists {
+		if _, keyPath := splitKeyPath(spec); !strings.Contains(keyPath, "[*]") {
+			return fmt.Errorf("YAML list target %s must end in #key.path selecting list elements with [*], e.g. Chart.yaml#dependencies[*].version", spec)
+		}
+	}
+	return nil
+}
+
+// goModDir returns the directory of the go.mod updated on major bumps: the
+// directory of ModFile if set, otherwise the nearest go.mod above VersionFile.
+// It returns an error wrapping os.ErrNotExist if there is no such go.mod.
+func (cfg Config) goModDir() (string, error) {
+	if cfg.ModFile == "" {
+		return locateGoModDir(filepath.Dir(cfg.VersionFile))
+	}
+	if filepath.Base(cfg.ModFile) != "go.mod" {
+		return "", fmt.Errorf("mod file %s is not named go.mod", cfg.ModFile)
+	}
+	if _, err := os.Stat(cfg.ModFile); err != nil {
+		return "", fmt.Errorf("mod file: %w", err)
+	}
+	return filepath.Dir(cfg.ModFile), nil
+}
+
+// tagPrefix returns cfg.TagPrefix, or "v" if it is empty.
+func (cfg Config) tagPrefix() string {
+	if cfg.TagPrefix == "" {
+		return "v"
+	}
+	return cfg.TagPrefix
+}
+
+// versionVar returns the name of the Go declaration holding the version in
+// cfg.VersionFile, without any package qualifier.
+func (cfg Config) versionVar() string {
+	return versionVarName(cfg.VersionVar)
+}
+
+// fileVersion returns version as written into the version file and mirror
+// files: with a leading "v" if KeepVPrefix is set, and bare otherwise.
+func (cfg Config) fileVersion(version string) string {
+	if cfg.KeepVPrefix {
+		return "v" + strings.TrimPrefix(version, "v")
+	}
+	return version
+}
+
+// tagName returns the git tag for version: the tag prefix followed by version.
+func (cfg Config) tagName(version string) string {
+	return cfg.tagPrefix() + version
+}
+
+// tagMessage returns the message of a release tag for a release commit with
+// message: message itself with AnnotateTag, and empty, for a lightweight tag,
+// otherwise.
+func (cfg Config) tagMessage(message string) string {
+	if !cfg.AnnotateTag {
+		return ""
+	}
+	return message
+}
+
+// commitTagMessage is tagMessage for the existing release commit sha.
+func (cfg Config) commitTagMessage(g Git, sha string) (string, error) {
+	if !cfg.AnnotateTag {
+		return "", nil
+	}
+	message, err := g.Log(cfg.Dir, "-1", "--format=%B", sha)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the release commit message: %w", err)
+	}
+	return message, nil
+}
+
+// tagGlob returns the glob release tags match: TagPattern if set, and the tag
+// prefix followed by anything otherwise.
+func (cfg Config) tagGlob() string {
+	if cfg.TagPattern != "" {
+		return cfg.TagPattern
+	}
+	return cfg.tagPrefix() + "*"
+}
+
+// describeOptions returns the options selecting which git tags are read as the
+// current version.
+func (cfg Config) describeOptions() DescribeOptions {
+	return DescribeOptions{Match: cfg.TagPattern, FirstParent: cfg.BranchTagsOnly}
+}
@@ -0,0 +1,99 @@
+package goversion
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// discoverFileNames are the file names DiscoverVersionFiles inspects.
+var discoverFileNames = []string{
+	"package.json",
+	"Cargo.toml",
+	"pyproject.toml",
+	"version.go",
+	"VERSION",
+	"Chart.yaml",
+}
+
+// discoverSkipDirs are directories DiscoverVersionFiles never descends into,
+// in addition to hidden ones: they hold dependencies rather than the project.
+var discoverSkipDirs = []string{"vendor", "node_modules", "testdata"}
+
+// VersionFileCandidate is a file found by DiscoverVersionFiles.
+type VersionFileCandidate struct {
+	Path    string // Path relative to the root, with forward slashes.
+	Version string // Current version, without any "v" prefix.
+	Pattern string // How the version was found: a VersionPattern name, "go-version", or "plain-text".
+}
+
+// DiscoverVersionFiles walks the tree at root and returns every file with a
+// common version file name (package.json, Cargo.toml, pyproject.toml, version.go,
+// VERSION, and Chart.yaml) that holds a detectable semantic version, ordered by
+// path. Hidden directories and vendor, node_modules, and testdata are skipped.
+// Files without a version are left out rather than reported as errors.
+func DiscoverVersionFiles(root string) ([]VersionFileCandidate, error) {
+	var candidates []VersionFileCandidate
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || slices.Contains(discoverSkipDirs, d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !slices.Contains(discoverFileNames, d.Name()) {
+			return nil
+		}
+		version, pattern, ok := detectVersion(path)
+		if !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, VersionFileCandidate{
+			Path:    filepath.ToSlash(rel),
+			Version: version,
+			Pattern: pattern,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover version files in %s: %w", root, err)
+	}
+	return candidates, nil
+}
+
+// detectVersion returns the version held by the file at path and how it was
+// found, the same way a version file is read: a Version literal in Go files,
+// a main version field, or the whole content of a plain-text file.
+func detectVersion(path string) (version, pattern string, ok bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false
+	}
+	if isGoFile(path) {
+		if version, err = goVersionLiteral(path, data, "Version"); err != nil {
+			return "", "", false
+		}
+		pattern = "go-version"
+	} else if match, err := FindMainVersionInFile(path); err == nil {
+		version, pattern = match.Version, match.Pattern
+	} else {
+		version, pattern = strings.TrimSpace(string(data)), "plain-text"
+	}
+	version = strings.TrimPrefix(version, "v")
+	if _, _, _, _, err := parseSemVer(version); err != nil || !semver.IsValid("v"+version) {
+		return "", "", false
+	}
+	return version, pattern, true
+}
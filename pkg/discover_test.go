@@ -0,0 +1,43 @@
+package goversion
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDiscoverVersionFiles verifies that common version files with a detectable
+// version are found across a tree, and that dependency directories and files
+// without a version are skipped.
+func TestDiscoverVersionFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFilesT(t, root, map[string]string{
+		"package.json":                    "{\n  \"name\": \"web\",\n  \"version\": \"1.2.3\"\n}\n",
+		"version.go":                      "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"VERSION":                         "v2.0.0\n",
+		"rust/Cargo.toml":                 "[dependencies.serde]\nversion = \"1.0.188\"\n\n[package]\nversion = \"0.3.0\"\n",
+		"py/pyproject.toml":               "[tool.poetry]\nname = \"x\"\nversion = \"0.9.1\"\n",
+		"charts/app/Chart.yaml":           "apiVersion: v2\nname: app\nversion: 4.5.6\nappVersion: 1.2.3\n",
+		"tools/package.json":              "{\n  \"private\": true\n}\n",
+		"internal/version.go":             "package internal\n\nconst Name = \"x\"\n",
+		"README.md":                       "version: 9.9.9\n",
+		"node_modules/dep/package.json":   "{\n  \"version\": \"7.0.0\"\n}\n",
+		"vendor/example.com/m/version.go": "package m\n\nvar Version = \"8.0.0\"\n",
+		".github/VERSION":                 "6.0.0\n",
+	})
+
+	got, err := DiscoverVersionFiles(root)
+	if err != nil {
+		t.Fatalf("DiscoverVersionFiles failed: %v", err)
+	}
+	want := []VersionFileCandidate{
+		{Path: "VERSION", Version: "2.0.0", Pattern: "plain-text"},
+		{Path: "charts/app/Chart.yaml", Version: "4.5.6", Pattern: "yaml-version"},
+		{Path: "package.json", Version: "1.2.3", Pattern: "json-version"},
+		{Path: "py/pyproject.toml", Version: "0.9.1", Pattern: "toml-table-version"},
+		{Path: "rust/Cargo.toml", Version: "0.3.0", Pattern: "toml-table-version"},
+		{Path: "version.go", Version: "1.2.3", Pattern: "go-version"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates:\n%+v\nwant:\n%+v", got, want)
+	}
+}
@@ -4,7 +4,7 @@
 //   - Reading and writing a version file that contains a version constant.
 //   - Normalizing and parsing semantic version strings (ensuring a canonical "v" prefix).
 //   - Bumping versions using standard keywords (e.g. major, minor, patch, premajor,
-//     preminor, prepatch, prerelease, and from-git) or setting an explicit version.
+//     preminor, prepatch, prerelease, from-git, and from-commits) or setting an explicit version.
 //   - Updating the module path in go.mod for major bumps ≥ v2 (e.g. appending `/v2` for v2.0.0),
 //     while leaving go.mod unchanged for v0→v1.
 //   - Integrating with Git to stage changes, commit updates with the new version as the commit
@@ -0,0 +1,198 @@
+package goversion
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// ensureVersion brings the repository to the state a successful run for the explicit
+// version in cfg.VersionArg leaves behind, performing only the steps that are missing:
+// writing the version file, committing it, and tagging the commit. It is safe to re-run
+// after a partial failure, and reports AlreadyReleased when nothing is left to do.
+//
+// An explicit version never changes the module path, so there is no go.mod or
+// import rewrite to resume. A version file written but not committed is refused
+// when cfg.Changelog or cfg.PostBumpScript is set, since whether those steps ran
+// before the failure cannot be told, and running them again is not safe.
+func ensureVersion(cfg Config) (VersionMeta, error) {
+	meta := VersionMeta{BumpType: "ensure"}
+	g := cfg.git()
+
+	if err := cfg.checkGit(); err != nil {
+		return meta, err
+	}
+
+	target := strings.TrimPrefix(cfg.VersionArg, "v")
+	if !semver.IsValid("v" + target) {
+		return meta, fmt.Errorf("ensure mode requires an explicit semver version, got %q", cfg.VersionArg)
+	}
+	meta.NewVersion = target
+
+	current, err := readConfiguredVersion(cfg, true)
+	if err != nil {
+		return meta, err
+	}
+	meta.OldVersion = current
+
+	tagName := cfg.tagName(target)
+	meta.Tag = cfg.releaseTag(meta.BumpType, target)
+	hasTag := tagExists(g, cfg.Dir, tagName)
+
+	// Nothing has happened yet: perform a regular explicit bump.
+	if current != target {
+		if hasTag {
+			return meta, fmt.Errorf("tag %s already exists but %s holds version %s", tagName, cfg.VersionFile, current)
+		}
+		cfg.Ensure = false
+		return RunWithConfig(cfg)
+	}
+
+	meta.UpdatedFiles = []string{cfg.VersionFile}
+
+	// The version file holds the target; find out whether that change is committed.
+	committed, err := fileCommitted(g, cfg.Dir, cfg.VersionFile)
+	if err != nil {
+		return meta, err
+	}
+
+	if !committed {
+		if hasTag {
+			return meta, fmt.Errorf("tag %s already exists but the version change in %s is not committed", tagName, cfg.VersionFile)
+		}
+		if cfg.writesChangelog(meta.BumpType) || cfg.PostBumpScript != "" {
+			return meta, fmt.Errorf("cannot resume the release of %s: %s holds it uncommitted, and the changelog or post-bump script may or may not have run; commit or revert the changes and re-run", target, cfg.VersionFile)
+		}
+		extraFiles, err := existingExtraFiles(cfg)
+		if err != nil {
+			return meta, err
+		}
+		allowed := append([]string{cfg.VersionFile}, extraFiles...)
+		allowed = append(allowed, cfg.MirrorFiles...)
+		allowed = append(allowed, cfg.bumpFiles()...)
+		if err := cfg.checkDirty(allowed); err != nil {
+			return meta, err
+		}
+		if err := checkIgnoredFiles(g, cfg.Dir, allowed); err != nil {
+			return meta, err
+		}
+		if err := checkWritableFiles(cfg.bumpFiles()); err != nil {
+			return meta, err
+		}
+		if err := checkMirrorFiles(cfg.MirrorFiles); err != nil {
+			return meta, err
+		}
+		if cfg.Backup {
+			if err := backupFiles(append(slices.Clone(cfg.MirrorFiles), cfg.bumpFiles()...)); err != nil {
+				return meta, err
+			}
+		}
+		// Mirror and bump files may not have been reached before the earlier failure.
+		for _, mf := range cfg.MirrorFiles {
+			if err := setGoVersionLiteral(mf, "Version", cfg.fileVersion(target)); err != nil {
+				return meta, err
+			}
+			cfg.emit(Event{Kind: EventFileWritten, Path: mf})
+		}
+		for _, bf := range cfg.bumpFiles() {
+			if err := cfg.bumpFile(bf, target); err != nil {
+				return meta, fmt.Errorf("failed to bump version in %s: %w", bf, err)
+			}
+			cfg.emit(Event{Kind: EventFileWritten, Path: bf})
+		}
+		meta.UpdatedFiles = releaseFiles(cfg.VersionFile, "", cfg.MirrorFiles, nil, cfg.bumpFiles(), "")
+		files := append([]string{cfg.VersionFile}, extraFiles...)
+		files = append(files, cfg.MirrorFiles...)
+		files = append(files, cfg.bumpFiles()...)
+		if meta.CommitMessage, err = cfg.commitMessage(meta); err != nil {
+			return meta, err
+		}
+		if err := gitCommit(cfg, target, meta.CommitMessage, files); err != nil {
+			return meta, err
+		}
+		return meta, nil
+	}
+
+	if hasTag || meta.Tag == "" {
+		meta.AlreadyReleased = true
+		meta.UpdatedFiles = nil
+		return meta, nil
+	}
+
+	// Committed but never tagged: tag the commit that last changed the version file.
+	sha, err := g.RevList(cfg.Dir, "-1", "HEAD", "--", filepath.Clean(cfg.VersionFile))
+	if err != nil {
+		return meta, err
+	}
+	message, err := cfg.commitTagMessage(g, sha)
+	if err != nil {
+		return meta, err
+	}
+	if err := g.Tag(cfg.Dir, tagName, sha, message); err != nil {
+		return meta, err
+	}
+	meta.UpdatedFiles = nil
+	return meta, nil
+}
+
+// prereleaseAtHead returns the version in cfg.VersionFile and reports whether it
+// is a prerelease set by HEAD: the file is committed unchanged and HEAD is the
+// last commit that changed it. A prerelease bump retried after such a release
+// must not bump again.
+func prereleaseAtHead(cfg Config) (string, bool) {
+	g := cfg.git()
+	current, err := readConfiguredVersion(cfg, false)
+	if err != nil || semver.Prerelease("v"+current) == "" {
+		return "", false
+	}
+	if committed, err := fileCommitted(g, cfg.Dir, cfg.VersionFile); err != nil || !committed {
+		return "", false
+	}
+	head, err := g.RevParse(cfg.Dir, "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return "", false
+	}
+	last, err := g.RevList(cfg.Dir, "-1", "HEAD", "--", filepath.Clean(cfg.VersionFile))
+	if err != nil || last != head {
+		return "", false
+	}
+	return current, true
+}
+
+// fileCommitted reports whether path is tracked in HEAD of the repository at dir
+// and has no staged or unstaged changes.
+func fileCommitted(g Git, dir, path string) (bool, error) {
+	root, err := g.RevParse(dir, "--show-toplevel")
+	if err != nil {
+		return false, fmt.Errorf("failed to find repository root: %w", err)
+	}
+	root, err = canonicalPath(root)
+	if err != nil {
+		return false, err
+	}
+	abs, err := canonicalPath(path)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return false, err
+	}
+	rel = filepath.ToSlash(rel)
+	if _, err := g.RevParse(dir, "--verify", "--quiet", "HEAD:"+rel); err != nil {
+		return false, nil
+	}
+	status, err := g.Status(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to check git status: %w", err)
+	}
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) > 3 && strings.TrimSpace(line[3:]) == rel {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,215 @@
+package goversion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEnsureVersionPartialStates verifies that ensure mode completes a release from
+// every partially-completed state and is a no-op once the release is complete.
+func TestEnsureVersionPartialStates(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, dir, versionFile string)
+		wantAlready bool
+	}{
+		{
+			name:  "nothing done",
+			setup: func(t *testing.T, dir, versionFile string) {},
+		},
+		{
+			name: "file written but not committed",
+			setup: func(t *testing.T, dir, versionFile string) {
+				if err := writeVersionFile(versionFile, "1.2.4"); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "committed but not tagged",
+			setup: func(t *testing.T, dir, versionFile string) {
+				if err := writeVersionFile(versionFile, "1.2.4"); err != nil {
+					t.Fatal(err)
+				}
+				commitAllT(t, dir, "1.2.4")
+			},
+		},
+		{
+			name: "committed and tagged",
+			setup: func(t *testing.T, dir, versionFile string) {
+				if err := writeVersionFile(versionFile, "1.2.4"); err != nil {
+					t.Fatal(err)
+				}
+				commitAllT(t, dir, "1.2.4")
+				gitT(t, dir, "tag", "v1.2.4")
+			},
+			wantAlready: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := initTestRepo(t)
+			versionFile := filepath.Join(dir, "version.go")
+			if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, dir, "initial commit")
+			tc.setup(t, dir, versionFile)
+			headBefore := gitT(t, dir, "rev-parse", "HEAD")
+			t.Chdir(dir)
+
+			meta, err := RunWithConfig(Config{
+				VersionFile: versionFile,
+				VersionArg:  "1.2.4",
+				ExtraFiles:  []string{versionFile},
+				Ensure:      true,
+			})
+			if err != nil {
+				t.Fatalf("ensure failed: %v", err)
+			}
+			if meta.AlreadyReleased != tc.wantAlready {
+				t.Errorf("AlreadyReleased = %v, want %v", meta.AlreadyReleased, tc.wantAlready)
+			}
+
+			content, err := os.ReadFile(versionFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := readCurrentVersion(versionFile); got != "1.2.4" {
+				t.Errorf("version file holds %q, want 1.2.4:\n%s", got, content)
+			}
+			if status := gitT(t, dir, "status", "--porcelain"); status != "" {
+				t.Errorf("expected clean tree, got:\n%s", status)
+			}
+			if subject := gitT(t, dir, "log", "-1", "--format=%s", "v1.2.4"); subject != "1.2.4" {
+				t.Errorf("tag v1.2.4 points at commit %q, want the 1.2.4 release commit", subject)
+			}
+			if tc.wantAlready && gitT(t, dir, "rev-parse", "HEAD") != headBefore {
+				t.Errorf("complete release should not create a new commit")
+			}
+
+			// Running again must always be a no-op.
+			again, err := RunWithConfig(Config{
+				VersionFile: versionFile,
+				VersionArg:  "1.2.4",
+				ExtraFiles:  []string{versionFile},
+				Ensure:      true,
+			})
+			if err != nil {
+				t.Fatalf("second ensure failed: %v", err)
+			}
+			if !again.AlreadyReleased {
+				t.Errorf("second ensure should report AlreadyReleased")
+			}
+		})
+	}
+}
+
+// TestEnsureVersionRefusesUncommittedResume verifies that ensure mode refuses to
+// commit a version file written by an earlier run when a changelog or post-bump
+// script is configured, since those steps may or may not have run.
+func TestEnsureVersionRefusesUncommittedResume(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"changelog":        {Changelog: "CHANGELOG.md"},
+		"post-bump script": {PostBumpScript: "true"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := initTestRepo(t)
+			writeFilesT(t, dir, map[string]string{"CHANGELOG.md": "# Changelog\n"})
+			versionFile := filepath.Join(dir, "version.go")
+			if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, dir, "initial commit")
+			if err := writeVersionFile(versionFile, "1.2.4"); err != nil {
+				t.Fatal(err)
+			}
+			headBefore := gitT(t, dir, "rev-parse", "HEAD")
+
+			cfg.Dir = dir
+			cfg.VersionFile = "version.go"
+			cfg.VersionArg = "1.2.4"
+			cfg.Ensure = true
+			if _, err := RunWithConfig(cfg); err == nil || !strings.Contains(err.Error(), "cannot resume") {
+				t.Errorf("ensure error = %v, want a refusal to resume", err)
+			}
+			if gitT(t, dir, "rev-parse", "HEAD") != headBefore {
+				t.Error("ensure committed despite refusing")
+			}
+			if tags := gitT(t, dir, "tag", "--list"); tags != "" {
+				t.Errorf("tags = %q, want none", tags)
+			}
+		})
+	}
+}
+
+// TestEnsureVersionRequiresExplicitVersion verifies that ensure mode rejects bump keywords.
+func TestEnsureVersionRequiresExplicitVersion(t *testing.T) {
+	dir := initTestRepo(t)
+	versionFile := filepath.Join(dir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, dir, "initial commit")
+	t.Chdir(dir)
+
+	if _, err := RunWithConfig(Config{VersionFile: versionFile, VersionArg: "patch", Ensure: true}); err == nil {
+		t.Error("expected ensure mode to reject a bump keyword")
+	}
+}
+
+// TestIdempotentPrerelease verifies that a retried prerelease bump does not
+// advance the prerelease counter again, completes a missing tag, and bumps
+// normally once HEAD has moved on.
+func TestIdempotentPrerelease(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	cfg := Config{
+		Dir:                  tmpDir,
+		VersionFile:          "version.go",
+		VersionArg:           "prerelease",
+		ExtraFiles:           []string{"version.go"},
+		IdempotentPrerelease: true,
+	}
+
+	if meta, err := RunWithConfig(cfg); err != nil || meta.NewVersion != "1.2.4-0" {
+		t.Fatalf("first run = %+v, %v; want 1.2.4-0", meta, err)
+	}
+	head := gitT(t, tmpDir, "rev-parse", "HEAD")
+
+	// A retry of the completed release does nothing.
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if !meta.AlreadyReleased || meta.BumpType != "prerelease" {
+		t.Errorf("retry meta = %+v, want AlreadyReleased for a prerelease", meta)
+	}
+	if v, _ := readCurrentVersion(versionFile); v != "1.2.4-0" {
+		t.Errorf("version after retry = %q, want 1.2.4-0", v)
+	}
+	if got := gitT(t, tmpDir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("retry moved HEAD to %s", got)
+	}
+
+	// A retry after tagging failed only adds the tag.
+	gitT(t, tmpDir, "tag", "-d", "v1.2.4-0")
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("retry without the tag failed: %v", err)
+	}
+	if tagged := gitT(t, tmpDir, "rev-parse", "v1.2.4-0^{commit}"); tagged != head {
+		t.Errorf("tag v1.2.4-0 points at %s, want %s", tagged, head)
+	}
+
+	// Once HEAD has moved on, the next prerelease is bumped.
+	writeFilesT(t, tmpDir, map[string]string{"a.go": "package foo\n"})
+	commitAllT(t, tmpDir, "Add a")
+	if meta, err := RunWithConfig(cfg); err != nil || meta.NewVersion != "1.2.4-1" {
+		t.Fatalf("run after a new commit = %+v, %v; want 1.2.4-1", meta, err)
+	}
+}
@@ -0,0 +1,30 @@
+package goversion
+
+// EventKind identifies the kind of progress Event reported during a run.
+type EventKind string
+
+const (
+	// EventFileWritten is emitted after the version file, go.mod, or a bump file is written.
+	EventFileWritten EventKind = "file-written"
+	// EventImportRewritten is emitted after a Go file's self-imports are rewritten.
+	EventImportRewritten EventKind = "import-rewritten"
+	// EventGitStep is emitted after a git add, commit, or tag succeeds, after a
+	// commit is undone ("reset") because its tag could not be created, and after
+	// Undo deletes a tag ("delete-tag") or the release commit ("reset").
+	EventGitStep EventKind = "git-step"
+)
+
+// Event describes a single step completed by RunWithConfig.
+// Events are delivered synchronously, in order, to Config.OnEvent.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	Path string    `json:"path,omitempty"` // File affected, for file and import events.
+	Step string    `json:"step,omitempty"` // Git operation ("add", "commit", "tag", "delete-tag" or "reset"), for git events.
+}
+
+// emit delivers e to cfg.OnEvent if a callback is set.
+func (cfg Config) emit(e Event) {
+	if cfg.OnEvent != nil {
+		cfg.OnEvent(e)
+	}
+}
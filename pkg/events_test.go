@@ -0,0 +1,51 @@
+package goversion
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestRunEmitsEvents verifies that a major bump touching several files reports
+// each write, import rewrite, and git step to Config.OnEvent, in order.
+func TestRunEmitsEvents(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"go.mod":       "module example.com/foo\n\ngo 1.18\n",
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"package.json": "{\n  \"version\": \"1.2.3\"\n}\n",
+		"a/a.go":       "package a\n\nfunc A() {}\n",
+		"b/b.go":       "package b\n\nimport \"example.com/foo/a\"\n\nfunc B() { a.A() }\n",
+		"c/c.go":       "package c\n\nimport \"example.com/foo/a\"\n\nfunc C() { a.A() }\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+
+	versionFile := filepath.Join(tmpDir, "version.go")
+	packageJSON := filepath.Join(tmpDir, "package.json")
+	var events []Event
+	_, err := RunWithConfig(Config{
+		VersionFile: versionFile,
+		VersionArg:  "major",
+		ExtraFiles:  []string{versionFile},
+		BumpFiles:   []string{packageJSON},
+		OnEvent:     func(e Event) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []Event{
+		{Kind: EventFileWritten, Path: versionFile},
+		{Kind: EventFileWritten, Path: filepath.Join(tmpDir, "go.mod")},
+		{Kind: EventImportRewritten, Path: filepath.Join(tmpDir, "b", "b.go")},
+		{Kind: EventImportRewritten, Path: filepath.Join(tmpDir, "c", "c.go")},
+		{Kind: EventFileWritten, Path: packageJSON},
+		{Kind: EventGitStep, Step: "add"},
+		{Kind: EventGitStep, Step: "commit"},
+		{Kind: EventGitStep, Step: "tag"},
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("events mismatch\ngot:  %v\nwant: %v", events, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 // ExampleRun demonstrates how to use the Run function in a Git repository.
@@ -105,3 +106,57 @@ func ExampleRun() {
 	// 	Version = "1.2.4"
 	// )
 }
+
+// ExampleUpdateSelfImports demonstrates a standalone v1 to v2 module path migration:
+// UpdateGoModMajor adds the /v2 suffix to go.mod, and UpdateSelfImports rewrites the
+// module's imports of its own packages. No version file or git repository is needed.
+func ExampleUpdateSelfImports() {
+	modDir, err := os.MkdirTemp("", "goversion_migrate")
+	if err != nil {
+		fmt.Println("failed to create temporary directory:", err)
+		return
+	}
+	defer os.RemoveAll(modDir)
+
+	// A v1 module with a command importing one of its own packages.
+	files := map[string]string{
+		"go.mod":          "module example.com/foo\n\ngo 1.24\n",
+		"lib/lib.go":      "package lib\n\nfunc Hello() string { return \"hello\" }\n",
+		"cmd/foo/main.go": "package main\n\nimport \"example.com/foo/lib\"\n\nfunc main() { println(lib.Hello()) }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(modDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			fmt.Println("failed to create directory:", err)
+			return
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			fmt.Println("failed to write file:", err)
+			return
+		}
+	}
+
+	if err := UpdateGoModMajor(modDir, "v2.0.0"); err != nil {
+		fmt.Println("failed to update go.mod:", err)
+		return
+	}
+	modified, err := UpdateSelfImports(modDir, "example.com/foo", "example.com/foo/v2")
+	if err != nil {
+		fmt.Println("failed to rewrite imports:", err)
+		return
+	}
+
+	goMod, _ := os.ReadFile(filepath.Join(modDir, "go.mod"))
+	fmt.Println(strings.SplitN(string(goMod), "\n", 2)[0])
+	for _, path := range modified {
+		rel, _ := filepath.Rel(modDir, path)
+		fmt.Println("rewrote", filepath.ToSlash(rel))
+	}
+	mainGo, _ := os.ReadFile(filepath.Join(modDir, "cmd", "foo", "main.go"))
+	fmt.Println(strings.Split(string(mainGo), "\n")[2])
+
+	// Output:
+	// module example.com/foo/v2
+	// rewrote cmd/foo/main.go
+	// import "example.com/foo/v2/lib"
+}
@@ -0,0 +1,287 @@
+package goversion
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Git performs the git operations used to read and release versions. Every method
+// runs against the repository containing dir, or the current directory if dir is
+// empty. ExecGit, the default, runs the git binary; set Config.Git to substitute
+// another implementation, such as a test double or a pure-Go backend.
+type Git interface {
+	// Add stages paths.
+	Add(dir string, paths ...string) error
+	// Commit records the staged changes with message, as configured by opts.
+	Commit(dir, message string, opts CommitOptions) error
+	// ResetSoft moves the current branch to rev, keeping the index and working tree.
+	ResetSoft(dir, rev string) error
+	// ResetKeep moves the current branch to rev, updating the index and the
+	// working tree files that differ between HEAD and rev. It fails rather than
+	// discard uncommitted changes to those files.
+	ResetKeep(dir, rev string) error
+	// Tag creates the tag name pointing at rev, or at HEAD if rev is empty: an
+	// annotated tag with message if message is set, or else a lightweight one.
+	Tag(dir, name, rev, message string) error
+	// DeleteTag deletes the tag name.
+	DeleteTag(dir, name string) error
+	// Status returns the changed and untracked files in `git status --porcelain`
+	// format: one "XY path" line per file, with paths relative to the repository root.
+	Status(dir string) (string, error)
+	// Tags returns the names of all tags matching the glob pattern, in no
+	// particular order.
+	Tags(dir, pattern string) ([]string, error)
+	// TagsByDate returns the names of all tags matching the glob pattern, most
+	// recently created first: by the date of an annotated tag, or of the commit
+	// a lightweight tag points at.
+	TagsByDate(dir, pattern string) ([]string, error)
+	// Describe returns the most recent tag reachable from HEAD, restricted as
+	// described by opts.
+	Describe(dir string, opts DescribeOptions) (string, error)
+	// RevParse returns the trimmed output of `git rev-parse args`, for example the
+	// commit for "HEAD" or the root for "--show-toplevel". It returns an error if
+	// a revision does not exist.
+	RevParse(dir string, args ...string) (string, error)
+	// RevList returns the trimmed output of `git rev-list args`, for example
+	// "--count HEAD..@{upstream}".
+	RevList(dir string, args ...string) (string, error)
+	// Log returns the trimmed output of `git log args`, for example
+	// "--format=%s v1.2.3..HEAD".
+	Log(dir string, args ...string) (string, error)
+	// CheckIgnore returns the ignore rule that excludes path, or an error if path is not ignored.
+	CheckIgnore(dir, path string) (string, error)
+}
+
+// CommitOptions configures Git.Commit.
+type CommitOptions struct {
+	Trailers   []string // Trailers ("Key: Value") appended to the message.
+	Signoff    bool     // Append a Signed-off-by trailer for the committer.
+	AllowEmpty bool     // Commit even if nothing is staged (git commit --allow-empty).
+}
+
+// DescribeOptions restricts the tags Git.Describe considers.
+type DescribeOptions struct {
+	Match       string // If set, only tags matching this glob are considered (git describe --match).
+	FirstParent bool   // Only follow the first parent of merge commits (git describe --first-parent).
+}
+
+// ExecGit implements Git by running the git binary.
+type ExecGit struct {
+	// Bin is the git binary to run. If empty, "git" is found on PATH.
+	Bin string
+}
+
+// Add implements Git.
+func (g ExecGit) Add(dir string, paths ...string) error {
+	_, err := g.output(dir, append([]string{"add", "--"}, paths...)...)
+	return err
+}
+
+// Commit implements Git. Trailers require git 2.32 or later.
+func (g ExecGit) Commit(dir, message string, opts CommitOptions) error {
+	args := []string{"commit", "-m", message}
+	for _, trailer := range opts.Trailers {
+		args = append(args, "--trailer", trailer)
+	}
+	if opts.Signoff {
+		args = append(args, "--signoff")
+	}
+	if opts.AllowEmpty {
+		args = append(args, "--allow-empty")
+	}
+	_, err := g.output(dir, args...)
+	return err
+}
+
+// ResetSoft implements Git.
+func (g ExecGit) ResetSoft(dir, rev string) error {
+	_, err := g.output(dir, "reset", "--soft", rev)
+	return err
+}
+
+// ResetKeep implements Git.
+func (g ExecGit) ResetKeep(dir, rev string) error {
+	_, err := g.output(dir, "reset", "--keep", rev)
+	return err
+}
+
+// Tag implements Git.
+func (g ExecGit) Tag(dir, name, rev, message string) error {
+	args := []string{"tag"}
+	if message != "" {
+		args = append(args, "--annotate", "--message", message)
+	}
+	args = append(args, name)
+	if rev != "" {
+		args = append(args, rev)
+	}
+	_, err := g.output(dir, args...)
+	return err
+}
+
+// DeleteTag implements Git.
+func (g ExecGit) DeleteTag(dir, name string) error {
+	_, err := g.output(dir, "tag", "--delete", name)
+	return err
+}
+
+// Tags implements Git.
+func (g ExecGit) Tags(dir, pattern string) ([]string, error) {
+	out, err := g.output(dir, "tag", "--list", pattern)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// TagsByDate implements Git.
+func (g ExecGit) TagsByDate(dir, pattern string) ([]string, error) {
+	out, err := g.output(dir, "tag", "--list", "--sort=-creatordate", pattern)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// Status implements Git.
+func (g ExecGit) Status(dir string) (string, error) {
+	out, err := g.cmd(dir, "status", "--porcelain").Output()
+	return string(out), err
+}
+
+// Describe implements Git.
+func (g ExecGit) Describe(dir string, opts DescribeOptions) (string, error) {
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	if opts.Match != "" {
+		args = append(args, "--match", opts.Match)
+	}
+	if opts.FirstParent {
+		args = append(args, "--first-parent")
+	}
+	return g.output(dir, args...)
+}
+
+// RevParse implements Git.
+func (g ExecGit) RevParse(dir string, args ...string) (string, error) {
+	return g.output(dir, append([]string{"rev-parse"}, args...)...)
+}
+
+// RevList implements Git.
+func (g ExecGit) RevList(dir string, args ...string) (string, error) {
+	return g.output(dir, append([]string{"rev-list"}, args...)...)
+}
+
+// Log implements Git.
+func (g ExecGit) Log(dir string, args ...string) (string, error) {
+	return g.output(dir, append([]string{"log"}, args...)...)
+}
+
+// CheckIgnore implements Git.
+func (g ExecGit) CheckIgnore(dir, path string) (string, error) {
+	return g.output(dir, "check-ignore", "-v", "--", path)
+}
+
+// gitBackends maps the names accepted by Config.GitBackend to their Git
+// implementations. Others, such as the go-git backend of the
+// github.com/bcomnes/goversion/v2/gogit module, are added by RegisterGitBackend.
+var gitBackends = map[string]func(cfg Config) Git{
+	"exec": func(cfg Config) Git { return ExecGit{Bin: cfg.GitBin} },
+}
+
+// RegisterGitBackend makes the Git implementation returned by newGit available
+// as Config.GitBackend name. It is meant to be called from the init function of
+// the package providing the backend, and panics if name is already registered.
+func RegisterGitBackend(name string, newGit func(cfg Config) Git) {
+	if _, ok := gitBackends[name]; ok {
+		panic("goversion: git backend " + name + " registered twice")
+	}
+	gitBackends[name] = newGit
+}
+
+// git returns the Git implementation for cfg: cfg.Git, or ExecGit if it is nil.
+func (cfg Config) git() Git {
+	if cfg.Git != nil {
+		return cfg.Git
+	}
+	return ExecGit{Bin: cfg.GitBin}
+}
+
+// checkGit verifies that git is usable for cfg. A custom cfg.Git other than
+// ExecGit is assumed to be available; otherwise the git binary must run.
+func (cfg Config) checkGit() error {
+	if g, ok := cfg.git().(ExecGit); ok {
+		return g.check()
+	}
+	return nil
+}
+
+// checkGit verifies that the default git binary is available on the system.
+func checkGit() error {
+	return ExecGit{}.check()
+}
+
+// check verifies that the git binary runs. The error wraps ErrGitUnavailable and
+// says whether the binary could not be found or failed, with a hint on how to
+// install git.
+func (g ExecGit) check() error {
+	bin := g.bin()
+	out, err := exec.Command(bin, "--version").CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("%w: %s not found (%v); %s, or set -git-bin (GOVERSION_GIT_BIN) to its path", ErrGitUnavailable, bin, err, gitInstallHint())
+	case errors.As(err, &exitErr):
+		return fmt.Errorf("%w: %s --version failed: %v: %s", ErrGitUnavailable, bin, err, bytes.TrimSpace(out))
+	default:
+		return fmt.Errorf("%w: cannot run %s: %v", ErrGitUnavailable, bin, err)
+	}
+}
+
+// gitInstallHint suggests how to install git on the current operating system.
+func gitInstallHint() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "install it with `xcode-select --install` or `brew install git`"
+	case "windows":
+		return "install it with `winget install Git.Git` or from https://git-scm.com/download/win"
+	case "linux":
+		return "install it with your package manager, e.g. `apt install git`, `dnf install git`, or `apk add git`"
+	default:
+		return "install it from https://git-scm.com/downloads"
+	}
+}
+
+// bin returns the git binary g runs: g.Bin, or "git".
+func (g ExecGit) bin() string {
+	return cmp.Or(g.Bin, "git")
+}
+
+// cmd returns a command running git with args in dir, or in the current
+// directory if dir is empty.
+func (g ExecGit) cmd(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command(g.bin(), args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+// output runs git with the given arguments in dir and returns its trimmed stdout.
+// On failure, the returned error includes git's stderr.
+func (g ExecGit) output(dir string, args ...string) (string, error) {
+	cmd := g.cmd(dir, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v, detail: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
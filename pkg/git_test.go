@@ -0,0 +1,395 @@
+package goversion
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mockGit is a Git that records each call instead of running git. Revisions not
+// listed in revs do not exist, and no file is ignored.
+type mockGit struct {
+	root  string
+	revs  map[string]string
+	calls []string
+}
+
+func (m *mockGit) record(format string, args ...any) {
+	m.calls = append(m.calls, fmt.Sprintf(format, args...))
+}
+
+func (m *mockGit) Add(dir string, paths ...string) error {
+	rel := make([]string, len(paths))
+	for i, p := range paths {
+		rel[i], _ = filepath.Rel(m.root, p)
+	}
+	m.record("add %s", strings.Join(rel, " "))
+	return nil
+}
+
+func (m *mockGit) Commit(dir, message string, opts CommitOptions) error {
+	m.record("commit %s", message)
+	return nil
+}
+
+func (m *mockGit) ResetSoft(dir, rev string) error {
+	m.record("reset --soft %s", rev)
+	return nil
+}
+
+func (m *mockGit) ResetKeep(dir, rev string) error {
+	m.record("reset --keep %s", rev)
+	return nil
+}
+
+func (m *mockGit) Tag(dir, name, rev, message string) error {
+	m.record("tag %s %s", name, rev)
+	return nil
+}
+
+func (m *mockGit) DeleteTag(dir, name string) error {
+	m.record("tag --delete %s", name)
+	return nil
+}
+
+func (m *mockGit) Tags(dir, pattern string) ([]string, error) {
+	m.record("tag --list %s", pattern)
+	return nil, nil
+}
+
+func (m *mockGit) TagsByDate(dir, pattern string) ([]string, error) {
+	m.record("tag --list --sort=-creatordate %s", pattern)
+	return nil, nil
+}
+
+func (m *mockGit) Status(dir string) (string, error) {
+	m.record("status")
+	return "", nil
+}
+
+func (m *mockGit) Describe(dir string, opts DescribeOptions) (string, error) {
+	m.record("describe")
+	return "", errors.New("no tags")
+}
+
+func (m *mockGit) RevParse(dir string, args ...string) (string, error) {
+	m.record("rev-parse %s", strings.Join(args, " "))
+	if args[0] == "--show-toplevel" {
+		return m.root, nil
+	}
+	if rev, ok := m.revs[args[len(args)-1]]; ok {
+		return rev, nil
+	}
+	return "", errors.New("unknown revision")
+}
+
+func (m *mockGit) RevList(dir string, args ...string) (string, error) {
+	m.record("rev-list %s", strings.Join(args, " "))
+	return "0", nil
+}
+
+func (m *mockGit) Log(dir string, args ...string) (string, error) {
+	m.record("log %s", strings.Join(args, " "))
+	return "", nil
+}
+
+func (m *mockGit) CheckIgnore(dir, path string) (string, error) {
+	m.record("check-ignore %s", filepath.Base(path))
+	return "", errors.New("not ignored")
+}
+
+// TestRunWithMockGit verifies the sequence of git operations a release performs,
+// using a mock Git in a directory that is not a git repository.
+func TestRunWithMockGit(t *testing.T) {
+	dir := t.TempDir()
+	versionFile := filepath.Join(dir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	git := &mockGit{root: dir, revs: map[string]string{"HEAD": "abc123"}}
+
+	meta, err := RunWithConfig(Config{
+		Dir:         dir,
+		VersionFile: versionFile,
+		VersionArg:  "minor",
+		ExtraFiles:  []string{versionFile},
+		Git:         git,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.NewVersion != "1.3.0" {
+		t.Errorf("NewVersion = %q, want 1.3.0", meta.NewVersion)
+	}
+
+	want := []string{
+		"rev-parse --verify --quiet HEAD",
+		"status",
+		"rev-parse --show-toplevel",
+		"check-ignore version.go",
+		"check-ignore version.go",
+		"rev-parse -q --verify refs/tags/v1.3.0",
+		"tag --list v*",
+		"rev-parse --abbrev-ref --symbolic-full-name @{upstream}",
+		"add version.go",
+		"commit 1.3.0",
+		"rev-parse HEAD",
+		"tag v1.3.0 abc123",
+	}
+	if !reflect.DeepEqual(git.calls, want) {
+		t.Errorf("git calls:\n%s\nwant:\n%s", strings.Join(git.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !os.IsNotExist(err) {
+		t.Error("the mock must not create a repository")
+	}
+}
+
+// TestRegisterGitBackend verifies that a registered backend is selected by
+// GitBackend, that an unregistered one is rejected, and that a name cannot be
+// registered twice.
+func TestRegisterGitBackend(t *testing.T) {
+	dir := t.TempDir()
+	versionFile := filepath.Join(dir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	git := &mockGit{root: dir, revs: map[string]string{"HEAD": "abc123"}}
+	RegisterGitBackend("mock", func(Config) Git { return git })
+	t.Cleanup(func() { delete(gitBackends, "mock") })
+
+	cfg := Config{Dir: dir, VersionFile: versionFile, VersionArg: "minor", GitBackend: "mock"}
+	if _, err := DryRunWithConfig(cfg); err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if len(git.calls) == 0 {
+		t.Error("the registered backend was not used")
+	}
+	cfg.GitBackend = "go-git"
+	if _, err := DryRunWithConfig(cfg); err == nil || !strings.Contains(err.Error(), "unknown git backend") {
+		t.Errorf("DryRun error = %v, want an unknown git backend", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering exec again should panic")
+		}
+	}()
+	RegisterGitBackend("exec", func(Config) Git { return git })
+}
+
+// TestGitBin verifies that a missing git binary configured through GitBin is
+// reported with its path and an install hint, and that a working binary is used
+// for every git command.
+func TestGitBin(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "no-such-git")
+	wantErr := func(t *testing.T, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrGitUnavailable) {
+			t.Fatalf("error = %v, want ErrGitUnavailable", err)
+		}
+		for _, want := range []string{missing, "not found", "install it", "GOVERSION_GIT_BIN"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not mention %q", err, want)
+			}
+		}
+	}
+
+	t.Run("GitBin", func(t *testing.T) {
+		_, err := RunWithConfig(Config{Dir: t.TempDir(), VersionFile: "version.go", VersionArg: "patch", GitBin: missing})
+		wantErr(t, err)
+	})
+	t.Run("wrapper", func(t *testing.T) {
+		tmpDir := initTestRepo(t)
+		writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+		commitAllT(t, tmpDir, "initial commit")
+		// A wrapper that logs each git command before running the real git.
+		gitPath, err := exec.LookPath("git")
+		if err != nil {
+			t.Skip("git is not available on system")
+		}
+		logFile := filepath.Join(t.TempDir(), "calls.log")
+		wrapper := filepath.Join(t.TempDir(), "git-wrapper")
+		script := fmt.Sprintf("#!/bin/sh\necho \"$1\" >> %q\nexec %q \"$@\"\n", logFile, gitPath)
+		if err := os.WriteFile(wrapper, []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := RunWithConfig(Config{Dir: tmpDir, VersionFile: "version.go", VersionArg: "patch", ExtraFiles: []string{"version.go"}, GitBin: wrapper}); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		calls, _ := os.ReadFile(logFile)
+		for _, want := range []string{"--version", "status", "commit", "tag"} {
+			if !slices.Contains(strings.Fields(string(calls)), want) {
+				t.Errorf("git %s was not run through the wrapper; calls:\n%s", want, calls)
+			}
+		}
+	})
+}
+
+// headMovingGit is an ExecGit that commits in dir just before tagging, as a
+// concurrent process might.
+type headMovingGit struct {
+	ExecGit
+	t   *testing.T
+	dir string
+}
+
+func (g headMovingGit) Tag(dir, name, rev, message string) error {
+	writeFilesT(g.t, g.dir, map[string]string{"other.txt": "concurrent\n"})
+	commitAllT(g.t, g.dir, "concurrent commit")
+	return g.ExecGit.Tag(dir, name, rev, message)
+}
+
+// TestTagTargetsReleaseCommit verifies that the tag points at the commit the
+// release made, even if HEAD moves before the tag is created.
+func TestTagTargetsReleaseCommit(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+
+	if _, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+		Git:         headMovingGit{t: t, dir: tmpDir},
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	release := gitT(t, tmpDir, "rev-parse", "HEAD~1")
+	if msg := gitT(t, tmpDir, "log", "-1", "--format=%s", release); msg != "1.3.0" {
+		t.Fatalf("expected the release commit below the concurrent one, got %q", msg)
+	}
+	if tagged := gitT(t, tmpDir, "rev-parse", "v1.3.0^{commit}"); tagged != release {
+		t.Errorf("tag v1.3.0 points at %s, want the release commit %s", tagged, release)
+	}
+}
+
+// TestReleaseInLinkedWorktree verifies that a release run inside a linked
+// worktree commits on the worktree's branch and creates the tag in the
+// repository it shares with the main checkout.
+func TestReleaseInLinkedWorktree(t *testing.T) {
+	mainDir := initTestRepo(t)
+	writeFilesT(t, mainDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, mainDir, "initial commit")
+	worktree := filepath.Join(t.TempDir(), "release")
+	gitT(t, mainDir, "worktree", "add", "-b", "release", worktree)
+
+	meta, err := RunWithConfig(Config{
+		Dir:         worktree,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.Tag != "v1.3.0" {
+		t.Errorf("tag = %q, want v1.3.0", meta.Tag)
+	}
+	release := gitT(t, mainDir, "rev-parse", "release")
+	if tagged := gitT(t, mainDir, "rev-parse", "v1.3.0^{commit}"); tagged != release {
+		t.Errorf("tag v1.3.0 points at %s in the main checkout, want the release branch %s", tagged, release)
+	}
+	if v, _ := readCurrentVersion(filepath.Join(mainDir, "version.go")); v != "1.2.3" {
+		t.Errorf("main checkout version = %q, want it untouched", v)
+	}
+	if status := gitT(t, worktree, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean worktree, got:\n%s", status)
+	}
+}
+
+// TestTagSort verifies that each TagSort picks its own tag for from-git among
+// tags whose nearest, highest, and newest differ.
+func TestTagSort(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	at := func(date string) { t.Setenv("GIT_COMMITTER_DATE", date+"T12:00:00Z") }
+
+	// v3.0.0 is the highest, v2.0.0 the nearest to HEAD, and v1.5.0, annotated
+	// on a branch HEAD does not contain, the newest.
+	at("2020-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"0.1.0\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v3.0.0")
+	branch := gitT(t, tmpDir, "rev-parse", "--abbrev-ref", "HEAD")
+	gitT(t, tmpDir, "checkout", "-q", "-b", "maintenance")
+	at("2024-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"NOTES.md": "maintenance\n"})
+	commitAllT(t, tmpDir, "maintenance fix")
+	gitT(t, tmpDir, "tag", "-a", "-m", "v1.5.0", "v1.5.0")
+	gitT(t, tmpDir, "checkout", "-q", branch)
+	at("2021-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"README.md": "# foo\n"})
+	commitAllT(t, tmpDir, "docs")
+	gitT(t, tmpDir, "tag", "v2.0.0")
+	at("2022-01-01")
+	writeFilesT(t, tmpDir, map[string]string{"lib.go": "package foo\n"})
+	commitAllT(t, tmpDir, "more work")
+
+	for sort, want := range map[string]string{"": "3.0.0", "topo": "2.0.0", "semver": "3.0.0", "date": "1.5.0"} {
+		meta, err := DryRunWithConfig(Config{
+			Dir:         tmpDir,
+			VersionFile: "version.go",
+			VersionArg:  "from-git",
+			TagSort:     sort,
+		})
+		if err != nil {
+			t.Errorf("TagSort %q: DryRun failed: %v", sort, err)
+			continue
+		}
+		if meta.NewVersion != want {
+			t.Errorf("TagSort %q: NewVersion = %q, want %q", sort, meta.NewVersion, want)
+		}
+	}
+	if _, err := DryRunWithConfig(Config{Dir: tmpDir, VersionFile: "version.go", VersionArg: "from-git", TagSort: "alpha"}); err == nil || !strings.Contains(err.Error(), "unknown tag sort") {
+		t.Errorf("DryRun error = %v, want an unknown tag sort", err)
+	}
+}
+
+// TestAnnotateTag verifies that AnnotateTag creates annotated release tags whose
+// message is the release commit message, both when releasing and when Ensure
+// tags an existing release commit.
+func TestAnnotateTag(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := Config{
+		Dir:                 tmpDir,
+		VersionFile:         "version.go",
+		VersionArg:          "patch",
+		ConventionalMessage: true,
+		AnnotateTag:         true,
+	}
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if kind := gitT(t, tmpDir, "cat-file", "-t", "v1.2.4"); kind != "tag" {
+		t.Errorf("v1.2.4 is a %s, want an annotated tag", kind)
+	}
+	if msg := gitT(t, tmpDir, "tag", "--list", "--format=%(contents)", "v1.2.4"); msg != "chore(release): 1.2.4" {
+		t.Errorf("v1.2.4 message = %q, want the commit message", msg)
+	}
+
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.3.0\"\n)\n"})
+	commitAllT(t, tmpDir, "release 1.3.0 by hand")
+	cfg.VersionArg = "1.3.0"
+	cfg.Ensure = true
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Ensure failed: %v", err)
+	}
+	if kind := gitT(t, tmpDir, "cat-file", "-t", "v1.3.0"); kind != "tag" {
+		t.Errorf("v1.3.0 is a %s, want an annotated tag", kind)
+	}
+	if msg := gitT(t, tmpDir, "tag", "--list", "--format=%(contents)", "v1.3.0"); msg != "release 1.3.0 by hand" {
+		t.Errorf("v1.3.0 message = %q, want the commit message", msg)
+	}
+	if target := gitT(t, tmpDir, "rev-parse", "v1.3.0^{commit}"); target != gitT(t, tmpDir, "rev-parse", "HEAD") {
+		t.Errorf("v1.3.0 points at %s, want HEAD", target)
+	}
+}
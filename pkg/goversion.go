@@ -2,19 +2,27 @@ package goversion
 
 import (
 	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
 	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
@@ -23,10 +31,18 @@ import (
 
 // VersionMeta holds metadata about the version bump operation.
 type VersionMeta struct {
-	OldVersion string // The version before bumping.
-	NewVersion string // The new version after bumping.
-	BumpType   string // How the version was bumped (e.g. "major", "explicit", "from-git", etc.).
-	UpdatedFiles []string  // Paths of all files written (version.go, go.mod, self-imports)
+	OldVersion      string                    // The version before bumping.
+	NewVersion      string                    // The new version after bumping.
+	Tag             string                    // The git tag for NewVersion (the tag prefix followed by NewVersion); empty for a snapshot, or a prerelease with Config.NoTagOnPrerelease.
+	PreviousTag     string                    // The highest release tag before Tag, e.g. for a compare link; empty if there is none or no tag is created.
+	BumpType        string                    // How the version was bumped (e.g. "major", "explicit", "from-git", etc.).
+	UpdatedFiles    []string                  // Paths of all files written (version.go, go.mod, self-imports)
+	AlreadyReleased bool                      // Ensure mode only: the version file, commit, and tag were already in place.
+	ImportChanges   map[string][]ImportChange // Dry-run major bumps only: self-import rewrites per file.
+	BumpFileMatches map[string][]BumpMatch    // Dry run only: the version fields each existing bump file would have replaced; none if nothing matched.
+	CommitMessage   string                    // The release commit message; in a dry run, the message a real run would use.
+	TagExists       bool                      // Dry run only: the tag for NewVersion already exists, so a real run would fail.
+	Timings         map[string]time.Duration  // If Config.Timings is set: the duration of each phase that ran, and "total".
 }
 
 // normalizeVersion ensures the version string starts with a "v" if it's not "dev".
@@ -43,9 +59,11 @@ func normalizeVersion(v string) string {
 
 // parseSemVer extracts the numerical components and prerelease from a semver string.
 // The expected input should be a canonical semver (with a leading "v").
+// Build metadata is discarded, so it never ends up inside the prerelease.
 func parseSemVer(version string) (major, minor, patch int, prerelease string, err error) {
-	// Remove the "v" prefix.
+	// Remove the "v" prefix and any build metadata.
 	vWithoutPrefix := strings.TrimPrefix(version, "v")
+	vWithoutPrefix, _, _ = strings.Cut(vWithoutPrefix, "+")
 	// Split off any prerelease part.
 	parts := strings.SplitN(vWithoutPrefix, "-", 2)
 	numParts := strings.Split(parts[0], ".")
@@ -69,6 +87,29 @@ func parseSemVer(version string) (major, minor, patch int, prerelease string, er
 	return
 }
 
+// padVersion returns version with a missing minor or patch component set to
+// zero, e.g. "1.2" as "1.2.0" and "v1-rc.1" as "v1.0.0-rc.1". Versions that
+// already have three components, and text that does not start with a number,
+// are returned unchanged.
+func padVersion(version string) string {
+	prefix, rest := "", version
+	if strings.HasPrefix(rest, "v") {
+		prefix, rest = "v", rest[1:]
+	}
+	end := strings.IndexAny(rest, "-+")
+	if end < 0 {
+		end = len(rest)
+	}
+	core := strings.Split(rest[:end], ".")
+	if len(core) >= 3 || core[0] == "" || strings.Trim(rest[:end], "0123456789.") != "" {
+		return version
+	}
+	for len(core) < 3 {
+		core = append(core, "0")
+	}
+	return prefix + strings.Join(core, ".") + rest[end:]
+}
+
 // formatSemVer constructs a canonical semver string (with the "v" prefix)
 // from its components.
 func formatSemVer(major, minor, patch int, prerelease string) string {
@@ -81,7 +122,11 @@ func formatSemVer(major, minor, patch int, prerelease string) string {
 
 // bumpVersion takes a valid, normalized semver string (with "v" prefix)
 // and a bump directive to produce a new semver string.
-// Supported bump types are: "major", "minor", "patch", "premajor", "preminor", "prepatch", "prerelease".
+// premajor, preminor, and prepatch always start a fresh prerelease at "0";
+// only prerelease and prerelease-same continue the counter of an existing prerelease.
+// Supported bump types are: "major", "minor", "patch", "premajor", "preminor", "prepatch", "prerelease",
+// and "prerelease-same", which behaves like "prerelease" except that on a final version it
+// starts a prerelease of that same version instead of the next patch.
 func bumpVersion(current, bump string) (string, error) {
 	major, minor, patch, prerelease, err := parseSemVer(current)
 	if err != nil {
@@ -131,6 +176,12 @@ func bumpVersion(current, bump string) (string, error) {
 			patch++
 			prerelease = "0"
 		}
+	case "prerelease-same":
+		if prerelease != "" {
+			return bumpVersion(current, "prerelease")
+		}
+		// Start a prerelease of the same version, e.g. a release candidate for a re-release.
+		prerelease = "0"
 	default:
 		return "", fmt.Errorf("unknown bump argument: %s", bump)
 	}
@@ -138,29 +189,189 @@ func bumpVersion(current, bump string) (string, error) {
 	return formatSemVer(major, minor, patch, prerelease), nil
 }
 
-// checkGit verifies that git is available on the system.
-func checkGit() error {
-	cmd := exec.Command("git", "--version")
-	if err := cmd.Run(); err != nil {
-		return errors.New("git is not available on the system")
+// bumpKeywords lists the bump directives understood by bumpVersion.
+var bumpKeywords = []string{"major", "minor", "patch", "premajor", "preminor", "prepatch", "prerelease", "prerelease-same"}
+
+// NextVersions returns the version (without a "v" prefix) that each bump keyword
+// would produce from current, keyed by keyword. current may have a "v" prefix or be "dev".
+func NextVersions(current string) (map[string]string, error) {
+	normalized := normalizeVersion(current)
+	if !semver.IsValid(normalized) {
+		return nil, fmt.Errorf("current version %q is not valid semver", current)
+	}
+	next := make(map[string]string, len(bumpKeywords))
+	for _, keyword := range bumpKeywords {
+		bumped, err := bumpVersion(normalized, keyword)
+		if err != nil {
+			return nil, err
+		}
+		next[keyword] = strings.TrimPrefix(bumped, "v")
+	}
+	return next, nil
+}
+
+// computeNewVersion determines the new version (without "v") and bump type for
+// cfg.VersionArg given the current version read from the version file.
+// It rejects no-op bumps and explicit downgrades unless cfg.AllowDowngrade is set.
+func computeNewVersion(cfg Config, current string) (newVersion, bumpType string, err error) {
+	if cfg.Base != "" && cfg.VersionArg != "from-commits" {
+		return "", "", fmt.Errorf("a base ref is only used by from-commits, not %q", cfg.VersionArg)
+	}
+	switch cfg.VersionArg {
+	case "major", "minor", "patch", "premajor", "preminor", "prepatch", "prerelease", "prerelease-same":
+		bumped, err := bumpVersion(normalizeVersion(current), cfg.VersionArg)
+		if err != nil {
+			return "", "", err
+		}
+		newVersion = strings.TrimPrefix(bumped, "v")
+		bumpType = cfg.VersionArg
+	case "from-commits":
+		level, err := commitBumpLevel(cfg)
+		if err != nil {
+			return "", "", err
+		}
+		bumped, err := bumpVersion(normalizeVersion(current), level)
+		if err != nil {
+			return "", "", err
+		}
+		newVersion = strings.TrimPrefix(bumped, "v")
+		bumpType = level
+	case "snapshot":
+		newVersion, err = snapshotVersion(cfg, current)
+		if err != nil {
+			return "", "", err
+		}
+		bumpType = "snapshot"
+	case "from-git":
+		fromGit, err := cfg.fromGitVersion()
+		if err != nil {
+			return "", "", err
+		}
+		newVersion = fromGit
+		bumpType = "from-git"
+	default:
+		explicit := cfg.VersionArg
+		if explicit != "dev" && !strings.HasPrefix(explicit, "v") {
+			explicit = "v" + explicit
+		}
+		if explicit != "dev" {
+			if err := checkPrereleaseIdentifiers(explicit); err != nil {
+				return "", "", fmt.Errorf("explicit version %q is not valid semver: %w", explicit, err)
+			}
+		}
+		if explicit != "dev" && !semver.IsValid(explicit) {
+			return "", "", fmt.Errorf("explicit version %q is not valid semver", explicit)
+		}
+		newVersion = strings.TrimPrefix(explicit, "v")
+		bumpType = "explicit"
+	}
+
+	if cfg.VersionHook != "" {
+		if newVersion, err = runVersionHook(cfg, current, newVersion, bumpType); err != nil {
+			return "", "", err
+		}
+	}
+
+	// Prevent no-op
+	if newVersion == current {
+		return "", "", fmt.Errorf("new version (%s) is the same as the current version", newVersion)
+	}
+
+	// Prevent accidental downgrades when setting a version explicitly.
+	if bumpType == "explicit" && !cfg.AllowDowngrade && newVersion != "dev" && current != "dev" &&
+		CompareVersions(newVersion, current, cfg.CompareBuildMetadata) < 0 {
+		return "", "", fmt.Errorf("new version (%s) is lower than the current version (%s); refusing to downgrade without AllowDowngrade (-allow-downgrade)", newVersion, current)
+	}
+
+	return newVersion, bumpType, nil
+}
+
+// snapshotVersion returns the version for a snapshot build of HEAD:
+// <base>-snapshot.<short sha>, where base is the next patch of a final current
+// version, or the version core of a prerelease. A short SHA made only of digits is
+// prefixed with "g" (as git describe does), since a numeric identifier with a
+// leading zero is not valid semver.
+func snapshotVersion(cfg Config, current string) (string, error) {
+	major, minor, patch, prerelease, err := parseSemVer(normalizeVersion(current))
+	if err != nil {
+		return "", err
+	}
+	if prerelease == "" {
+		patch++
+	}
+	sha, err := cfg.git().RevParse(filepath.Dir(cfg.VersionFile), "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD for snapshot: %w", err)
+	}
+	if strings.Trim(sha, "0123456789") == "" {
+		sha = "g" + sha
+	}
+	return fmt.Sprintf("%d.%d.%d-snapshot.%s", major, minor, patch, sha), nil
+}
+
+// releaseTag returns the tag of a release of newVersion by bumpType: empty for a
+// snapshot, which is not a release, and for a prerelease if cfg.NoTagOnPrerelease
+// is set.
+func (cfg Config) releaseTag(bumpType, newVersion string) string {
+	if bumpType == "snapshot" || (cfg.NoTagOnPrerelease && semver.Prerelease(normalizeVersion(newVersion)) != "") {
+		return ""
+	}
+	return cfg.tagName(newVersion)
+}
+
+// createsTag reports whether a release of newVersion by bumpType is tagged. A
+// from-git version already has its tag, and releaseTag may leave it untagged.
+func (cfg Config) createsTag(bumpType, newVersion string) bool {
+	return bumpType != "from-git" && cfg.releaseTag(bumpType, newVersion) != ""
+}
+
+// checkPrereleaseIdentifiers returns an error if a numeric prerelease identifier in
+// version has a leading zero (e.g. 1.2.3-01), which semver.org forbids. It is checked
+// explicitly rather than relying on semver.IsValid, which has accepted such versions.
+func checkPrereleaseIdentifiers(version string) error {
+	core, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), "+")
+	_, prerelease, ok := strings.Cut(core, "-")
+	if !ok {
+		return nil
+	}
+	for _, id := range strings.Split(prerelease, ".") {
+		numeric := id != "" && strings.Trim(id, "0123456789") == ""
+		if numeric && len(id) > 1 && id[0] == '0' {
+			return fmt.Errorf("numeric prerelease identifier %q must not have leading zeros", id)
+		}
+	}
+	return nil
+}
+
+// tagExists reports whether the git tag name exists in the repository at dir.
+func tagExists(g Git, dir, name string) bool {
+	_, err := g.RevParse(dir, "-q", "--verify", "refs/tags/"+name)
+	return err == nil
+}
+
+// checkBranch returns an error unless HEAD of the repository at dir is on the branch named want.
+func checkBranch(g Git, dir, want string) error {
+	branch, err := g.RevParse(dir, "--abbrev-ref", "HEAD")
+	if err != nil || branch == "HEAD" {
+		return fmt.Errorf("refusing to release from a detached HEAD; required branch is %q", want)
+	}
+	if branch != want {
+		return fmt.Errorf("refusing to release from branch %q; required branch is %q", branch, want)
 	}
 	return nil
 }
 
 // determinePackageName returns the package name for the given file path.
-// If the file exists, it extracts the package name using a regex.
+// If the file exists, it parses the package clause with go/parser, so build constraints
+// and comments before the clause are handled correctly.
 // If the file does not exist, it scans the directory for any Go file (ignoring _test.go files)
 // and returns the package name from the first file found. If none is found, it defaults to "version".
 func determinePackageName(path string) (string, error) {
 	// If the file exists, try to extract the package name.
 	if _, err := os.Stat(path); err == nil {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return "", fmt.Errorf("failed to read file %q: %v", path, err)
-		}
-		re := regexp.MustCompile(`(?m)^package\s+(\w+)`)
-		if matches := re.FindSubmatch(data); matches != nil && len(matches) >= 2 {
-			return string(matches[1]), nil
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+		if err == nil && f.Name != nil {
+			return f.Name.Name, nil
 		}
 		// Fall through if we can't parse the package name.
 	}
@@ -184,20 +395,49 @@ func determinePackageName(path string) (string, error) {
 	return "version", nil
 }
 
+// isGoFile reports whether path names a Go source file. Any other version file is
+// either a manifest with a main version field (e.g. setup.py) or plain text holding
+// only the version (e.g. VERSION or version.txt).
+func isGoFile(path string) bool {
+	return strings.HasSuffix(path, ".go")
+}
+
+// writeVersionFile writes newVersion to the version file at path. With cfg.NoGofmt,
+// only the Version string literal of an existing Go file is replaced, keeping the
+// file's formatting exactly; otherwise a Go file is rewritten in gofmt'd form.
+func (cfg Config) writeVersionFile(path, newVersion string) error {
+	if (cfg.NoGofmt || cfg.versionVar() != "Version") && isGoFile(path) {
+		if _, err := os.Stat(path); err == nil {
+			return setGoVersionLiteral(path, cfg.versionVar(), newVersion)
+		}
+	}
+	return writeVersionFile(path, newVersion)
+}
+
 // writeVersionFile writes (or creates) the version file at the given path using the specified
 // new version string (without the "v" prefix) and an appropriate package declaration.
+// An existing manifest (e.g. setup.py or package.json) has only its main version field
+// replaced, and other plain-text version files receive the bare version followed by a newline.
 func writeVersionFile(path, newVersion string) error {
-	pkgName, err := determinePackageName(path)
-	if err != nil {
-		// If an error occurred during package determination, use a default.
-		pkgName = "version"
+	if !isGoFile(path) {
+		if match, err := FindMainVersionInFile(path); err == nil {
+			return ReplaceVersionInFile(path, match, newVersion)
+		}
 	}
-	content := fmt.Sprintf(`package %s
+	content := newVersion + "\n"
+	if isGoFile(path) {
+		pkgName, err := determinePackageName(path)
+		if err != nil {
+			// If an error occurred during package determination, use a default.
+			pkgName = "version"
+		}
+		content = fmt.Sprintf(`package %s
 
 var (
 	Version = "%s"
 )
 `, pkgName, newVersion)
+	}
 	// Ensure the directory exists.
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -206,47 +446,179 @@ var (
 	return os.WriteFile(path, []byte(content), 0644)
 }
 
+// UpdateGoModMajor rewrites the module directive of modDir/go.mod for the major version of
+// newVersion (with or without a "v" prefix): a "/vN" suffix is set for v2 and above,
+// and removed for v0 and v1. Only the module line and replace directives for the
+// module itself are changed.
+func UpdateGoModMajor(modDir, newVersion string) error {
+	return updateGoMod(modDir, strings.TrimPrefix(newVersion, "v"))
+}
+
+// updateGoMod sets the major version suffix of the module path in modDir/go.mod
+// to match newVersion, which must not have a "v" prefix.
 func updateGoMod(modDir, newVersion string) error {
-    modPath := filepath.Join(modDir, "go.mod")
-    data, err := os.ReadFile(modPath)
-    if err != nil {
-        return fmt.Errorf("reading go.mod: %w", err)
-    }
-
-    f, err := modfile.Parse(modPath, data, nil)
-    if err != nil {
-        return fmt.Errorf("parsing go.mod: %w", err)
-    }
-    if f.Module == nil {
-        return fmt.Errorf("module directive not found")
-    }
-
-    basePath, _, _ := module.SplitPathVersion(f.Module.Mod.Path)
-    maj := semver.Major("v" + newVersion)
-
-    var newPath string
-    if maj == "v0" || maj == "v1" {
-        newPath = basePath
-    } else {
-        newPath = basePath + "/" + maj
-    }
-
-    // update both AST and logical path
-    f.Module.Mod.Path = newPath
-    if f.Module.Syntax != nil && len(f.Module.Syntax.Token) >= 2 {
-        f.Module.Syntax.Token[1] = newPath
-    }
-
-    out, err := f.Format()
-    if err != nil {
-        return fmt.Errorf("formatting go.mod: %w", err)
-    }
-    if err := os.WriteFile(modPath, out, 0644); err != nil {
-        return fmt.Errorf("writing go.mod: %w", err)
-    }
-    return nil
+	return rewriteGoModPath(modDir, func(oldPath string) string {
+		return majorModulePath(oldPath, newVersion)
+	})
+}
+
+// setGoModPath replaces the module path in modDir/go.mod with newPath verbatim.
+func setGoModPath(modDir, newPath string) error {
+	return rewriteGoModPath(modDir, func(string) string { return newPath })
+}
+
+// majorModulePath returns modPath with its major version suffix set for newVersion
+// (without a "v" prefix): "/vN" for v2 and above, no suffix for v0 and v1.
+func majorModulePath(modPath, newVersion string) string {
+	basePath, _, _ := module.SplitPathVersion(modPath)
+	maj := semver.Major("v" + newVersion)
+	if maj == "v0" || maj == "v1" {
+		return basePath
+	}
+	return basePath + "/" + maj
+}
+
+// rewriteGoModPath replaces the module path in modDir/go.mod with the result of
+// calling newPath on the current path. Only the module directive and replace
+// directives for the module itself are changed.
+func rewriteGoModPath(modDir string, newPath func(oldPath string) string) error {
+	modPath := filepath.Join(modDir, "go.mod")
+	data, err := os.ReadFile(modPath)
+	if err != nil {
+		return fmt.Errorf("reading go.mod: %w", err)
+	}
+
+	f, err := modfile.Parse(modPath, data, nil)
+	if err != nil {
+		return fmt.Errorf("parsing go.mod: %w", err)
+	}
+	if f.Module == nil {
+		return fmt.Errorf("module directive not found")
+	}
+
+	oldPath := f.Module.Mod.Path
+	path := newPath(oldPath)
+
+	// update both AST and logical path
+	f.Module.Mod.Path = path
+	if f.Module.Syntax != nil && len(f.Module.Syntax.Token) >= 2 {
+		f.Module.Syntax.Token[1] = path
+	}
+	rewriteSelfReplaces(f, oldPath, path)
+
+	out, err := f.Format()
+	if err != nil {
+		return fmt.Errorf("formatting go.mod: %w", err)
+	}
+	if err := os.WriteFile(modPath, out, 0644); err != nil {
+		return fmt.Errorf("writing go.mod: %w", err)
+	}
+	return nil
+}
+
+// rewriteSelfReplaces moves every replace directive in f whose left-hand path is
+// oldPath to newPath, keeping its target, position, and comments. A left-hand
+// version is kept if it is valid for newPath's major version and dropped
+// otherwise, so that the replace applies to every version of the new path
+// rather than to none.
+func rewriteSelfReplaces(f *modfile.File, oldPath, newPath string) {
+	_, pathMajor, _ := module.SplitPathVersion(newPath)
+	for _, r := range f.Replace {
+		if r.Old.Path != oldPath {
+			continue
+		}
+		if r.Old.Version != "" && module.CheckPathMajor(r.Old.Version, pathMajor) != nil {
+			r.Old.Version = ""
+		}
+		r.Old.Path = newPath
+		if r.Syntax == nil {
+			continue
+		}
+		// The tokens are [replace] old [version] => new [version], with the verb
+		// only outside a replace block.
+		arrow := slices.Index(r.Syntax.Token, "=>")
+		if arrow < 0 {
+			continue
+		}
+		var tokens []string
+		if r.Syntax.Token[0] == "replace" {
+			tokens = append(tokens, "replace")
+		}
+		tokens = append(tokens, modfile.AutoQuote(newPath))
+		if r.Old.Version != "" {
+			tokens = append(tokens, r.Old.Version)
+		}
+		r.Syntax.Token = append(tokens, r.Syntax.Token[arrow:]...)
+	}
+}
+
+// checkNewModulePath validates cfg.NewModulePath for a bump of the given type.
+// An override is only meaningful for major bumps and must be a valid module path.
+func checkNewModulePath(cfg Config, bumpType string) error {
+	if cfg.NewModulePath == "" {
+		return nil
+	}
+	if bumpType != "major" {
+		return fmt.Errorf("a new module path can only be set for a major bump, not %q", bumpType)
+	}
+	if err := module.CheckPath(cfg.NewModulePath); err != nil {
+		return fmt.Errorf("invalid new module path: %w", err)
+	}
+	return nil
+}
+
+// readBaseVersion returns the version a bump is computed from: cfg.From if set,
+// validated as semver, or else the current version read by readConfiguredVersion.
+func readBaseVersion(cfg Config, create bool) (string, error) {
+	if cfg.From == "" {
+		return readConfiguredVersion(cfg, create)
+	}
+	from := strings.TrimPrefix(cfg.From, "v")
+	if !semver.IsValid("v" + from) {
+		return "", fmt.Errorf("base version %q is not valid semver", cfg.From)
+	}
+	return from, nil
 }
 
+// readConfiguredVersion reads the current version from cfg.VersionFile,
+// falling back to cfg.InitialVersion (or “dev”) when there is neither a
+// version file nor a git tag. A missing version file is written only if create is set.
+// With cfg.KeepVPrefix, the version file's leading "v" is not part of the version.
+// With cfg.LenientVersion, a missing minor or patch component is read as zero.
+// With cfg.NoCreate, a missing version file is an error instead. A Go version
+// file whose version is held by a custom cfg.VersionVar must exist.
+func readConfiguredVersion(cfg Config, create bool) (string, error) {
+	if cfg.NoCreate {
+		if _, err := os.Stat(cfg.VersionFile); os.IsNotExist(err) {
+			return "", fmt.Errorf("version file %s does not exist; refusing to create it with NoCreate (-no-create)", cfg.VersionFile)
+		}
+	}
+	fallback := "dev"
+	if cfg.InitialVersion != "" {
+		fallback = strings.TrimPrefix(cfg.InitialVersion, "v")
+		if !semver.IsValid("v" + fallback) {
+			return "", fmt.Errorf("initial version %q is not valid semver", cfg.InitialVersion)
+		}
+	}
+	var current string
+	var err error
+	if name := cfg.versionVar(); name != "Version" && isGoFile(cfg.VersionFile) {
+		data, readErr := os.ReadFile(cfg.VersionFile)
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read version file: %w; VersionVar (-version-var) requires an existing file declaring %s", readErr, name)
+		}
+		current, err = goVersionLiteral(cfg.VersionFile, data, name)
+	} else {
+		current, err = readCurrentVersionOr(cfg.git(), cfg.VersionFile, fallback, cfg.tagPrefix(), cfg.describeOptions(), create)
+	}
+	if cfg.KeepVPrefix {
+		current = strings.TrimPrefix(current, "v")
+	}
+	if cfg.LenientVersion {
+		current = padVersion(current)
+	}
+	return current, err
+}
 
 // readCurrentVersion reads the version file at the given path
 // and extracts the version string. If the file does not exist,
@@ -254,183 +626,539 @@ func updateGoMod(modDir, newVersion string) error {
 // writes it into the version file, and returns it.
 // If there are no tags or git fails, it falls back to “dev”.
 func readCurrentVersion(path string) (string, error) {
+	return readCurrentVersionOr(ExecGit{}, path, "dev", "v", DescribeOptions{}, true)
+}
+
+// readCurrentVersionOr is readCurrentVersion with fallback used in place of “dev”
+// and tagPrefix stripped from git tags in place of "v". Only the tags selected by
+// opts are considered. If create is false, a missing version file is not written.
+func readCurrentVersionOr(g Git, path, fallback, tagPrefix string, opts DescribeOptions, create bool) (string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			dir := filepath.Dir(path)
-			if fromGit, gitErr := getVersionFromGitDir(dir); gitErr == nil {
+			if fromGit, gitErr := versionFromLatestTag(g, dir, tagPrefix, opts); gitErr == nil {
+				if !create {
+					return fromGit, nil
+				}
 				if err := writeVersionFile(path, fromGit); err != nil {
 					return "", fmt.Errorf("failed to write version file from git tag: %w", err)
 				}
 				return fromGit, nil
 			}
-			// Fallback to dev
-			defaultVersion := "dev"
-			if err := writeVersionFile(path, defaultVersion); err != nil {
+			// Fallback to dev or the configured initial version
+			if !create {
+				return fallback, nil
+			}
+			if err := writeVersionFile(path, fallback); err != nil {
 				return "", fmt.Errorf("failed to create default version file: %w", err)
 			}
-			return defaultVersion, nil
+			return fallback, nil
 		}
 		return "", fmt.Errorf("failed to read version file: %w", err)
 	}
 
+	// Manifests such as setup.py or package.json are read from their main version
+	// field; other plain-text version files hold nothing but the version.
+	if !isGoFile(path) {
+		if match, err := FindMainVersionInFile(path); err == nil {
+			return match.Version, nil
+		}
+		if v := strings.TrimSpace(string(data)); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("version file %q is empty", path)
+	}
+
 	// File exists: parse out the version string
 	re := regexp.MustCompile(`Version\s*=\s*"([^"]+)"`)
 	if matches := re.FindSubmatch(data); matches != nil && len(matches) >= 2 {
 		return string(matches[1]), nil
 	}
+	if err := checkNonLiteralVersion(path, data); err != nil {
+		return "", err
+	}
 	return "", errors.New("failed to find version string in file")
 }
 
-// gitCommit stages the version file (plus any extra files provided),
-// commits with a message equal to the new version (without the "v" prefix),
-// and then tags the commit with the same version prefixed by "v".
-func gitCommit(newVersion string, extraFiles []string) error {
-	// Ensure that the version file is included.
-	files := extraFiles
-
-	// Stage files.
-	addArgs := append([]string{"add"}, files...)
-	addCmd := exec.Command("git", addArgs...)
-	var stderr bytes.Buffer
-	addCmd.Stderr = &stderr
-	if err := addCmd.Run(); err != nil {
-		return fmt.Errorf("git add failed: %v, detail: %s", err, stderr.String())
+// checkNonLiteralVersion returns a descriptive error if the Go source in data
+// declares Version with a value other than a string literal (for example
+// `var Version = buildVersion()`), which goversion can neither read nor rewrite.
+func checkNonLiteralVersion(path string, data []byte) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, data, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || (gen.Tok != token.VAR && gen.Tok != token.CONST) {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if name.Name != "Version" {
+					continue
+				}
+				if i < len(vs.Values) {
+					if lit, ok := vs.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						continue
+					}
+				}
+				return fmt.Errorf("%s:%d: Version must be assigned a string literal (e.g. Version = \"1.2.3\"); goversion cannot read or update a computed or missing value",
+					path, fset.Position(name.Pos()).Line)
+			}
+		}
 	}
+	return nil
+}
 
-	// Commit changes.
-	commitMsg := newVersion // commit message is the new version (without "v" prefix)
-	commitCmd := exec.Command("git", "commit", "-m", commitMsg)
-	stderr.Reset()
-	commitCmd.Stderr = &stderr
-	if err := commitCmd.Run(); err != nil {
-		return fmt.Errorf("git commit failed: %v, detail: %s", err, stderr.String())
+// ValidateVersionFile checks the version file at path without modifying anything,
+// for use in linters and pre-commit hooks. For a Go file it requires a package-level
+// var or const named varName (default "Version", ignoring a package qualifier such as
+// "buildinfo.") assigned a string literal; other files are read like a version file,
+// from their main version field or as plain text. It returns the version if it is a valid major.minor.patch semantic version
+// and a descriptive error otherwise. Unlike a bump, it never creates the file or
+// falls back to git tags.
+func ValidateVersionFile(path, varName string) (string, error) {
+	varName = versionVarName(varName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read version file: %w", err)
 	}
 
-	// Tag the commit with "v" prefix.
-	tagName := "v" + newVersion
-	tagCmd := exec.Command("git", "tag", tagName)
-	stderr.Reset()
-	tagCmd.Stderr = &stderr
-	if err := tagCmd.Run(); err != nil {
-		return fmt.Errorf("git tag failed: %v, detail: %s", err, stderr.String())
+	var version string
+	if isGoFile(path) {
+		if version, err = goVersionLiteral(path, data, varName); err != nil {
+			return "", err
+		}
+	} else if match, err := FindMainVersionInFile(path); err == nil {
+		version = match.Version
+	} else if version = strings.TrimSpace(string(data)); version == "" {
+		return "", fmt.Errorf("version file %q is empty", path)
 	}
 
-	return nil
+	if _, _, _, _, err := parseSemVer(version); err != nil || !semver.IsValid("v"+strings.TrimPrefix(version, "v")) {
+		return "", fmt.Errorf("%s: %s is %q, which is not a valid semantic version (e.g. 1.2.3)", path, varName, version)
+	}
+	return version, nil
 }
 
-// getVersionFromGitDir retrieves the most recent tag from git in the given directory
-// and strips off any leading "v".
-func getVersionFromGitDir(dir string) (string, error) {
-	cmd := exec.Command("git", "describe", "--tags", "--abbrev=0")
-	cmd.Dir = dir
-	out, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get version from git in %q: %v", dir, err)
+// versionVarName returns the identifier a version var name such as "Version" or
+// "buildinfo.Version" refers to within a file: its last dot-separated segment, as
+// the package qualifier cannot be matched. An empty name means "Version".
+func versionVarName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		return "Version"
 	}
-	tag := strings.TrimSpace(string(out))
-	return strings.TrimPrefix(tag, "v"), nil
+	return name
 }
 
-// Run is the main function for the goversion library.
-// It accepts a path to the Go file containing a version declaration,
-// a version argument (which can be one of the bump keywords or an explicit version),
-// and a slice of extra files to include in the commit.
-// Supported versionArg values are:
-//   [<newversion> | major | minor | patch | premajor | preminor | prepatch | prerelease | from-git]
-// It now returns metadata about the operation.
-// Run bumps the version, updates go.mod for v2+ modules, rewrites self-imports, and commits the changes.
-func Run(versionFilePath, versionArg string, extraFiles []string, bumpFiles []string, postBumpScript string) (VersionMeta, error) {
-	var meta VersionMeta
-
-	// 1. Ensure git is available
-	if err := checkGit(); err != nil {
-		return meta, err
+// goVersionLiteral returns the string literal assigned to the package-level var or
+// const varName in the Go source data.
+func goVersionLiteral(path string, data []byte, varName string) (string, error) {
+	lit, _, err := findGoVersionLiteral(path, data, varName)
+	if err != nil {
+		return "", err
 	}
+	return strconv.Unquote(lit.Value)
+}
 
-	// 2. Read the current version
-	currentVersionRaw, err := readCurrentVersion(versionFilePath)
+// findGoVersionLiteral returns the string literal assigned to the package-level var
+// or const varName in the Go source data, with the file set holding its position.
+func findGoVersionLiteral(path string, data []byte, varName string) (*ast.BasicLit, *token.FileSet, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, data, parser.SkipObjectResolution)
 	if err != nil {
-		return meta, err
+		return nil, nil, fmt.Errorf("failed to parse version file: %w", err)
 	}
-	meta.OldVersion = currentVersionRaw
-
-	// Normalize
-	normalizedCurrent := normalizeVersion(currentVersionRaw)
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || (gen.Tok != token.VAR && gen.Tok != token.CONST) {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if name.Name != varName {
+					continue
+				}
+				if i < len(vs.Values) {
+					if lit, ok := vs.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						return lit, fset, nil
+					}
+				}
+				return nil, nil, fmt.Errorf("%s:%d: %s must be assigned a string literal (e.g. %s = \"1.2.3\")",
+					path, fset.Position(name.Pos()).Line, varName, varName)
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("%s: no package-level %s declaration found", path, varName)
+}
 
-	// 3. Determine new version
-	switch versionArg {
-	case "major", "minor", "patch", "premajor", "preminor", "prepatch", "prerelease":
-		bumped, err := bumpVersion(normalizedCurrent, versionArg)
-		if err != nil {
-			return meta, err
+// checkMirrorFiles ensures that every mirror file is an existing Go file with a
+// Version string literal, so that a bad mirror is reported before anything is written.
+func checkMirrorFiles(paths []string) error {
+	for _, path := range paths {
+		if !isGoFile(path) {
+			return fmt.Errorf("mirror file %s is not a Go file", path)
 		}
-		meta.NewVersion = strings.TrimPrefix(bumped, "v")
-		meta.BumpType = versionArg
-	case "from-git":
-		fromGit, err := getVersionFromGitDir(filepath.Dir(versionFilePath))
+		data, err := os.ReadFile(path)
 		if err != nil {
-			return meta, err
-		}
-		meta.NewVersion = fromGit
-		meta.BumpType = "from-git"
-	default:
-		explicit := versionArg
-		if explicit != "dev" && !strings.HasPrefix(explicit, "v") {
-			explicit = "v" + explicit
+			return fmt.Errorf("failed to read mirror file: %w", err)
 		}
-		if explicit != "dev" && !semver.IsValid(explicit) {
-			return meta, fmt.Errorf("explicit version %q is not valid semver", explicit)
+		if _, _, err := findGoVersionLiteral(path, data, "Version"); err != nil {
+			return err
 		}
-		meta.NewVersion = strings.TrimPrefix(explicit, "v")
-		meta.BumpType = "explicit"
 	}
+	return nil
+}
 
-	// Prevent no-op
-	if meta.NewVersion == meta.OldVersion {
-		return meta, fmt.Errorf("new version (%s) is the same as the current version", meta.NewVersion)
+// setGoVersionLiteral sets the string literal assigned to varName in the existing Go
+// file at path to newVersion. Only the literal is replaced, located through the file's syntax tree,
+// so comments, formatting, other declarations, and a raw-string quote style are kept.
+// It writes mirror files, and the version file if NoGofmt or VersionVar is set.
+func setGoVersionLiteral(path, varName, newVersion string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
 	}
+	lit, fset, err := findGoVersionLiteral(path, data, varName)
+	if err != nil {
+		return err
+	}
+	quoted := strconv.Quote(newVersion)
+	if strings.HasPrefix(lit.Value, "`") {
+		quoted = "`" + newVersion + "`"
+	}
+	start := fset.Position(lit.Pos()).Offset
+	end := start + len(lit.Value)
+	out := append(append(slices.Clone(data[:start]), quoted...), data[end:]...)
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
 
-	// Prepare allowed list for dirty check
-	allowed := make([]string, len(extraFiles))
-	copy(allowed, extraFiles)
-	allowed = append(allowed, versionFilePath)
-
-	// Detect module for major bumps
-	var modDir, oldModPath string
-	if meta.BumpType == "major" {
-		if root, err := locateGoModDir(filepath.Dir(versionFilePath)); err == nil {
-			modDir = root
-			// Read existing module path
-			data, err := os.ReadFile(filepath.Join(modDir, "go.mod"))
-			if err != nil {
-				return meta, fmt.Errorf("reading go.mod: %w", err)
-			}
-			f, err := modfile.Parse("go.mod", data, nil)
-			if err != nil {
-				return meta, fmt.Errorf("parsing go.mod: %w", err)
-			}
-			oldModPath = f.Module.Mod.Path
-			allowed = append(allowed, filepath.Join(modDir, "go.mod"))
+// conventionalTemplate is the commit template used by Config.ConventionalMessage,
+// with %s standing for the scope.
+const conventionalTemplate = "chore(%s): {{.NewVersion}}"
+
+// commitMessage returns the release commit message for the release described by
+// meta: cfg.CommitTemplate, or the conventionalTemplate if cfg.ConventionalMessage
+// is set, executed with meta's OldVersion, NewVersion, Tag, and BumpType, with
+// surrounding whitespace trimmed, or else the new version (without the "v" prefix).
+func (cfg Config) commitMessage(meta VersionMeta) (string, error) {
+	text := cfg.CommitTemplate
+	if cfg.ConventionalMessage {
+		if text != "" {
+			return "", fmt.Errorf("ConventionalMessage (-conventional-message) cannot be combined with a CommitTemplate (-commit-template)")
+		}
+		scope := cmp.Or(cfg.ConventionalScope, "release")
+		if strings.ContainsAny(scope, "(){}:\n") {
+			return "", fmt.Errorf("invalid conventional commit scope %q", scope)
 		}
+		text = fmt.Sprintf(conventionalTemplate, scope)
+	}
+	if text == "" {
+		return meta.NewVersion, nil
+	}
+	tmpl, err := template.New("commit").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
+	}
+	var buf strings.Builder
+	data := VersionMeta{OldVersion: meta.OldVersion, NewVersion: meta.NewVersion, Tag: meta.Tag, BumpType: meta.BumpType}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid commit message template: %w", err)
 	}
+	message := strings.TrimSpace(buf.String())
+	if message == "" {
+		return "", fmt.Errorf("the commit message template rendered an empty message")
+	}
+	return message, nil
+}
 
-	// 5. Check for uncommitted files
-	if err := checkUncommittedFiles(allowed); err != nil {
-		return meta, err
+// gitCommit stages the version file (plus any extra files provided), commits
+// with message, and then tags that commit, named by its SHA, with newVersion
+// prefixed by cfg's tag prefix (annotated with message if cfg.AnnotateTag).
+// For a from-git bump, the tag already exists and is not created again.
+// Trailers and sign-off from cfg are added to the commit, and each completed
+// step is reported to cfg.OnEvent.
+func gitCommit(cfg Config, newVersion, message string, extraFiles []string) error {
+	// Ensure that the version file is included.
+	files := extraFiles
+
+	// Stage files.
+	g := cfg.git()
+	if err := g.Add(cfg.Dir, files...); err != nil {
+		return err
 	}
+	cfg.emit(Event{Kind: EventGitStep, Step: "add"})
 
-	// 6. Write version file
-	if err := writeVersionFile(versionFilePath, meta.NewVersion); err != nil {
-		return meta, err
+	// Commit changes.
+	trailers := cfg.Trailers
+	if cfg.CreditAuthors {
+		credits, err := creditTrailers(cfg)
+		if err != nil {
+			return err
+		}
+		trailers = append(slices.Clone(trailers), credits...)
+	}
+	if err := g.Commit(cfg.Dir, message, CommitOptions{Trailers: trailers, Signoff: cfg.Signoff, AllowEmpty: cfg.AllowEmpty}); err != nil {
+		return err
 	}
+	cfg.emit(Event{Kind: EventGitStep, Step: "commit"})
+
+	// Tag the commit with the tag prefix, unless the version came from that tag
+	// or is left untagged.
+	if !cfg.createsTag(cfg.VersionArg, newVersion) {
+		return nil
+	}
+	// Tag the commit just made by its SHA rather than HEAD, so that the tag cannot
+	// land on another commit if something moves HEAD in the meantime.
+	sha, err := g.RevParse(cfg.Dir, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to read the release commit: %w", err)
+	}
+	if err := g.Tag(cfg.Dir, cfg.tagName(newVersion), sha, cfg.tagMessage(message)); err != nil {
+		if !cfg.CleanupOnTagFailure {
+			return err
+		}
+		// Undo the commit but keep its changes staged, so that nothing is left
+		// committed without its tag.
+		if resetErr := g.ResetSoft(cfg.Dir, "HEAD~1"); resetErr != nil {
+			return fmt.Errorf("%w; undoing the release commit also failed: %v", err, resetErr)
+		}
+		cfg.emit(Event{Kind: EventGitStep, Step: "reset"})
+		return fmt.Errorf("%w; the release commit was undone and its changes are staged", err)
+	}
+	cfg.emit(Event{Kind: EventGitStep, Step: "tag"})
+
+	return nil
+}
+
+// getVersionFromGitDir retrieves the most recent tag from git in the given directory
+// and strips off any leading "v".
+func getVersionFromGitDir(dir string) (string, error) {
+	return versionFromLatestTag(ExecGit{}, dir, "v", DescribeOptions{})
+}
+
+// versionFromLatestTag retrieves the most recent tag from git in the given directory
+// and returns its version, as parsed by ParseTag with tagPrefix.
+// If opts.Match is set, only tags matching that glob (as in git describe --match)
+// are considered, so that a monorepo's "cli/v*" tags do not affect "api/v*" releases;
+// if opts.FirstParent is set, tags on merged branches are ignored.
+func versionFromLatestTag(g Git, dir, tagPrefix string, opts DescribeOptions) (string, error) {
+	tag, err := g.Describe(dir, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to get version from git in %q: %v", dir, err)
+	}
+	version, err := ParseTag(tag, tagPrefix)
+	if err != nil {
+		return "", fmt.Errorf("latest %w", err)
+	}
+	return version, nil
+}
+
+// tagSorts lists the values of Config.TagSort.
+var tagSorts = []string{"semver", "topo", "date"}
+
+// fromGitVersion returns the version of the release tag (one with the tag prefix,
+// matching TagPattern if set) that a from-git bump reads, chosen as cfg.TagSort
+// says: the one with the highest version by default or for "semver", the nearest
+// one reachable from HEAD for "topo", and the most recently created one for
+// "date". Only "topo" is restricted to tags reachable from HEAD.
+func (cfg Config) fromGitVersion() (string, error) {
+	dir := filepath.Dir(cfg.VersionFile)
+	switch cfg.TagSort {
+	case "topo":
+		return versionFromLatestTag(cfg.git(), dir, cfg.tagPrefix(), cfg.describeOptions())
+	case "", "semver":
+		tag, version, err := cfg.highestTag(dir)
+		if err != nil {
+			return "", err
+		}
+		if tag == "" {
+			return "", fmt.Errorf("no release tags match %s", cfg.tagGlob())
+		}
+		return version, nil
+	case "date":
+		tags, err := cfg.git().TagsByDate(dir, cfg.tagGlob())
+		if err != nil {
+			return "", fmt.Errorf("failed to list tags: %w", err)
+		}
+		for _, tag := range tags {
+			if version, err := ParseTag(tag, cfg.tagPrefix()); err == nil {
+				return version, nil
+			}
+		}
+		return "", fmt.Errorf("no release tags match %s", cfg.tagGlob())
+	default:
+		return "", fmt.Errorf("unknown tag sort %q (-tag-sort); use one of %s", cfg.TagSort, strings.Join(tagSorts, ", "))
+	}
+}
+
+// ParseTag returns the bare version of a release tag: tag without exactly prefix
+// ("v" if empty, as for Config.TagPrefix), which must leave a full
+// major.minor.patch semantic version. A tag without the prefix is an error rather
+// than being read as-is, so that "version-1.2.3" is never mistaken for a "v" tag.
+func ParseTag(tag, prefix string) (string, error) {
+	if prefix == "" {
+		prefix = "v"
+	}
+	version, ok := strings.CutPrefix(tag, prefix)
+	if !ok {
+		return "", fmt.Errorf("tag %q does not start with the tag prefix %q", tag, prefix)
+	}
+	if _, _, _, _, err := parseSemVer(version); err != nil || !semver.IsValid("v"+version) {
+		return "", fmt.Errorf("tag %q is not %q followed by a semantic version", tag, prefix)
+	}
+	return version, nil
+}
+
+// Run is the main function for the goversion library.
+// It accepts a path to the Go file containing a version declaration,
+// a version argument (which can be one of the bump keywords or an explicit version),
+// and a slice of extra files to include in the commit.
+// Supported versionArg values are:
+//
+//	[<newversion> | major | minor | patch | premajor | preminor | prepatch | prerelease | prerelease-same | from-git | from-commits | snapshot]
+//
+// It now returns metadata about the operation.
+// Run bumps the version, updates go.mod for v2+ modules, rewrites self-imports, and commits the changes.
+func Run(versionFilePath, versionArg string, extraFiles []string, bumpFiles []string, postBumpScript string) (VersionMeta, error) {
+	return RunWithConfig(Config{
+		VersionFile:    versionFilePath,
+		VersionArg:     versionArg,
+		ExtraFiles:     extraFiles,
+		BumpFiles:      bumpFiles,
+		PostBumpScript: postBumpScript,
+	})
+}
+
+// RunWithConfig performs the same operation as Run using the options in cfg.
+// If cfg.DryRun is set, it behaves like DryRunWithConfig.
+//
+// When cfg.Dir is set, git and the post-bump script run in that directory and
+// relative paths are resolved against it; the process working directory is
+// neither used nor changed, so concurrent calls for different repositories are safe.
+func RunWithConfig(cfg Config) (VersionMeta, error) {
+	cfg, err := cfg.withResolvedPaths()
+	if err != nil {
+		return VersionMeta{}, err
+	}
+	if cfg.DryRun {
+		return DryRunWithConfig(cfg)
+	}
+	if cfg.RequireBranch != "" {
+		if err := checkBranch(cfg.git(), cfg.Dir, cfg.RequireBranch); err != nil {
+			return VersionMeta{}, err
+		}
+	}
+	if cfg.Ensure {
+		return ensureVersion(cfg)
+	}
+	if cfg.IdempotentPrerelease && cfg.VersionArg == "prerelease" {
+		if current, ok := prereleaseAtHead(cfg); ok {
+			cfg.VersionArg = current
+			meta, err := ensureVersion(cfg)
+			meta.BumpType = "prerelease"
+			return meta, err
+		}
+	}
+	var meta VersionMeta
+	done := cfg.timePhase(&meta, "total")
+	meta, err = release(cfg)
+	done()
+	return meta, err
+}
+
+// release performs a release for RunWithConfig once cfg is resolved, recording the
+// duration of each phase in the returned VersionMeta if cfg.Timings is set.
+func release(cfg Config) (VersionMeta, error) {
+	var meta VersionMeta
+	versionFilePath := cfg.VersionFile
+	bumpFiles, postBumpScript := cfg.bumpFiles(), cfg.PostBumpScript
+
+	// 1-5. Read and compute the versions and run every pre-flight guard
+	done := cfg.timePhase(&meta, "preflight")
+	plan, err := preflight(cfg, true)
+	done()
+	meta.OldVersion, meta.NewVersion, meta.BumpType = plan.oldVersion, plan.newVersion, plan.bumpType
+	if err != nil {
+		return meta, err
+	}
+	meta.Tag = cfg.releaseTag(meta.BumpType, meta.NewVersion)
+	meta.PreviousTag = plan.lastTag
+	modDir, oldModPath := plan.modDir, plan.oldModPath
+
+	// 5.9. Back up every file about to be modified, and keep its contents to
+	// restore if the build check fails
+	var toWrite []string
+	if cfg.Backup || cfg.BuildCheck {
+		toWrite = append([]string{versionFilePath}, cfg.MirrorFiles...)
+		toWrite = append(toWrite, bumpFiles...)
+		if cfg.writesChangelog(meta.BumpType) {
+			toWrite = append(toWrite, cfg.Changelog)
+		}
+		if meta.BumpType == "major" && modDir != "" {
+			toWrite = append(toWrite, filepath.Join(modDir, "go.mod"))
+			if cfg.ModTidy || cfg.ModTidyRequired {
+				toWrite = append(toWrite, filepath.Join(modDir, "go.sum"))
+			}
+			changes, err := PreviewSelfImports(modDir, oldModPath, oldModPath)
+			if err != nil {
+				return meta, err
+			}
+			toWrite = append(toWrite, slices.Sorted(maps.Keys(changes))...)
+		}
+	}
+	if cfg.Backup {
+		done := cfg.timePhase(&meta, "backup")
+		if err := backupFiles(toWrite); err != nil {
+			return meta, err
+		}
+		done()
+	}
+	var original fileSnapshot
+	if cfg.BuildCheck {
+		if original, err = snapshotFiles(toWrite); err != nil {
+			return meta, err
+		}
+	}
+
+	// 6. Write version file
+	done = cfg.timePhase(&meta, "version-files")
+	if err := cfg.writeVersionFile(versionFilePath, cfg.fileVersion(meta.NewVersion)); err != nil {
+		return meta, err
+	}
+	cfg.emit(Event{Kind: EventFileWritten, Path: versionFilePath})
+	for _, mf := range cfg.MirrorFiles {
+		if err := setGoVersionLiteral(mf, "Version", cfg.fileVersion(meta.NewVersion)); err != nil {
+			return meta, err
+		}
+		cfg.emit(Event{Kind: EventFileWritten, Path: mf})
+	}
+	done()
 
 	// 6.5. Update go.mod if needed
 	var newModPath string
 	if meta.BumpType == "major" && modDir != "" {
-		if err := updateGoMod(modDir, meta.NewVersion); err != nil {
+		done := cfg.timePhase(&meta, "go.mod")
+		if cfg.NewModulePath != "" {
+			err = setGoModPath(modDir, cfg.NewModulePath)
+		} else {
+			err = updateGoMod(modDir, meta.NewVersion)
+		}
+		if err != nil {
 			return meta, err
 		}
+		cfg.emit(Event{Kind: EventFileWritten, Path: filepath.Join(modDir, "go.mod")})
 		// Re-read new module path
 		data, err := os.ReadFile(filepath.Join(modDir, "go.mod"))
 		if err != nil {
@@ -441,57 +1169,225 @@ func Run(versionFilePath, versionArg string, extraFiles []string, bumpFiles []st
 			return meta, fmt.Errorf("parsing go.mod: %w", err)
 		}
 		newModPath = f.Module.Mod.Path
+		done()
 	}
 
 	// 6.6. Rewrite self-imports
 	var rewritten []string
 	if newModPath != "" {
-		rewritten, err = updateSelfImports(modDir, oldModPath, newModPath)
+		done := cfg.timePhase(&meta, "imports")
+		rewritten, err = rewriteSelfImports(modDir, oldModPath, newModPath, func(path string) {
+			cfg.emit(Event{Kind: EventImportRewritten, Path: path})
+		})
 		if err != nil {
 			return meta, err
 		}
+		done()
+	}
+
+	// 6.65. Tidy the module's requirements and checksums for the new path
+	var tidied []string
+	if newModPath != "" && (cfg.ModTidy || cfg.ModTidyRequired) {
+		done := cfg.timePhase(&meta, "mod-tidy")
+		if _, err := exec.LookPath("go"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: go is not installed; skipping go mod tidy\n")
+		} else {
+			if err := runModTidy(modDir); err != nil {
+				return meta, err
+			}
+			goSum := filepath.Join(modDir, "go.sum")
+			if _, err := os.Stat(goSum); err == nil {
+				tidied = append(tidied, goSum)
+				cfg.emit(Event{Kind: EventFileWritten, Path: goSum})
+			}
+		}
+		done()
 	}
 
 	// 6.7. Process bump files
+	done = cfg.timePhase(&meta, "bump-files")
 	var bumpedFiles []string
 	for _, bf := range bumpFiles {
-		if err := findAndReplaceSemver(bf, meta.NewVersion); err != nil {
+		if err := cfg.bumpFile(bf, meta.NewVersion); err != nil {
 			// Log warning but don't fail
 			fmt.Fprintf(os.Stderr, "Warning: failed to bump version in %s: %v\n", bf, err)
 		} else {
 			bumpedFiles = append(bumpedFiles, bf)
+			cfg.emit(Event{Kind: EventFileWritten, Path: bf})
 		}
 	}
+	done()
+
+	// 6.75. Record the release in the changelog
+	if cfg.writesChangelog(meta.BumpType) {
+		done := cfg.timePhase(&meta, "changelog")
+		if err := updateChangelog(cfg, meta.NewVersion); err != nil {
+			return meta, err
+		}
+		cfg.emit(Event{Kind: EventFileWritten, Path: cfg.Changelog})
+		done()
+	}
 
 	// 6.8. Run post-bump script if provided
 	if postBumpScript != "" {
-		if err := runPostBumpScript(postBumpScript, meta.OldVersion, meta.NewVersion); err != nil {
+		done := cfg.timePhase(&meta, "post-bump")
+		if err := runPostBumpScript(cfg.Dir, postBumpScript, meta.OldVersion, meta.NewVersion); err != nil {
 			return meta, fmt.Errorf("post-bump script failed: %w", err)
 		}
+		done()
+	}
+
+	// 6.9. Make sure the module still builds
+	if cfg.BuildCheck {
+		done := cfg.timePhase(&meta, "build-check")
+		buildDir := modDir
+		if buildDir == "" {
+			if buildDir, err = cfg.goModDir(); err != nil {
+				return meta, fmt.Errorf("build check: no go.mod found: %w", err)
+			}
+		}
+		if err := runBuildCheck(buildDir); err != nil {
+			if restoreErr := original.restore(); restoreErr != nil {
+				return meta, fmt.Errorf("%w; restoring the modified files also failed: %v", err, restoreErr)
+			}
+			return meta, err
+		}
+		done()
 	}
 
 	// 7. Stage, commit, and tag
-	filesToCommit := make([]string, len(extraFiles))
-	copy(filesToCommit, extraFiles)
-	filesToCommit = append(filesToCommit, versionFilePath)
+	var goModPath string
 	if modDir != "" {
-		filesToCommit = append(filesToCommit, filepath.Join(modDir, "go.mod"))
+		goModPath = filepath.Join(modDir, "go.mod")
+	}
+	var changelog string
+	if cfg.writesChangelog(meta.BumpType) {
+		changelog = cfg.Changelog
+	}
+	updated := releaseFiles(versionFilePath, goModPath, cfg.MirrorFiles, rewritten, bumpedFiles, changelog)
+	updated = append(updated, tidied...)
+	if meta.BumpType == "snapshot" && !cfg.CommitSnapshot {
+		meta.UpdatedFiles = updated
+		return meta, nil
 	}
-	filesToCommit = append(filesToCommit, rewritten...)
-	filesToCommit = append(filesToCommit, bumpedFiles...)
-	if err := gitCommit(meta.NewVersion, filesToCommit); err != nil {
+	// The post-bump script may have created extra files, so they are checked again.
+	extraFiles, err := existingExtraFiles(cfg)
+	if err != nil {
 		return meta, err
 	}
-
-	meta.UpdatedFiles = append([]string{versionFilePath}, rewritten...)
-	meta.UpdatedFiles = append(meta.UpdatedFiles, bumpedFiles...)
-	if modDir != "" {
-	  meta.UpdatedFiles = append([]string{filepath.Join(modDir, "go.mod")}, meta.UpdatedFiles...)
+	filesToCommit := slices.Clone(updated)
+	for _, f := range slices.Sorted(slices.Values(extraFiles)) {
+		if !slices.Contains(filesToCommit, f) {
+			filesToCommit = append(filesToCommit, f)
+		}
+	}
+	done = cfg.timePhase(&meta, "git")
+	if meta.CommitMessage, err = cfg.commitMessage(meta); err != nil {
+		return meta, err
 	}
+	if err := gitCommit(cfg, meta.NewVersion, meta.CommitMessage, filesToCommit); err != nil {
+		return meta, err
+	}
+	done()
 
+	meta.UpdatedFiles = updated
 	return meta, nil
 }
 
+// timePhase starts timing phase and returns a function that adds the time
+// elapsed since to meta.Timings. Nothing is recorded unless cfg.Timings is set.
+func (cfg Config) timePhase(meta *VersionMeta, phase string) func() {
+	if !cfg.Timings {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		if meta.Timings == nil {
+			meta.Timings = make(map[string]time.Duration)
+		}
+		meta.Timings[phase] += time.Since(start)
+	}
+}
+
+// backupFiles copies each existing file in paths to a sibling with a ".bak" suffix,
+// overwriting any earlier backup. Files that do not exist yet are skipped.
+func backupFiles(paths []string) error {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+		if err := os.WriteFile(path+".bak", data, 0644); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// fileSnapshot holds the contents of files as they were before a release wrote
+// them, nil for a file that did not exist.
+type fileSnapshot map[string][]byte
+
+// snapshotFiles reads each file in paths into a fileSnapshot.
+func snapshotFiles(paths []string) (fileSnapshot, error) {
+	s := make(fileSnapshot, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		s[path] = data
+	}
+	return s, nil
+}
+
+// restore writes every file in s back with its recorded contents, and removes
+// the files that did not exist.
+func (s fileSnapshot) restore() error {
+	var errs []error
+	for _, path := range slices.Sorted(maps.Keys(s)) {
+		data := s[path]
+		var err error
+		if data == nil {
+			err = os.Remove(path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// releaseFiles returns the files changed by a release in a deterministic order:
+// the version file, then go.mod (if goMod is set), then the mirror files, the
+// rewritten Go files, and the bump files, each sorted, and finally the changelog
+// (if set). A file is listed only once.
+func releaseFiles(versionFile, goMod string, mirrors, imports, bumpFiles []string, changelog string) []string {
+	files := []string{versionFile}
+	if goMod != "" {
+		files = append(files, goMod)
+	}
+	for _, group := range [][]string{mirrors, imports, bumpFiles} {
+		for _, f := range slices.Sorted(slices.Values(group)) {
+			if !slices.Contains(files, f) {
+				files = append(files, f)
+			}
+		}
+	}
+	if changelog != "" && !slices.Contains(files, changelog) {
+		files = append(files, changelog)
+	}
+	return files
+}
+
 // DryRun is a new function that simulates the version bump operation without
 // writing any changes to disk or modifying the git repository. It returns the
 // VersionMeta data that would be generated by a real bump.
@@ -501,138 +1397,146 @@ func Run(versionFilePath, versionArg string, extraFiles []string, bumpFiles []st
 // - any .go files whose imports need rewriting.
 // - any files that would be processed by bump-file flags.
 func DryRun(versionFilePath, versionArg string, bumpFiles []string) (VersionMeta, error) {
-    var meta VersionMeta
-
-    // 1. Read current version
-    cur, err := readCurrentVersion(versionFilePath)
-    if err != nil {
-        return meta, err
-    }
-    meta.OldVersion = cur
-
-    // 2. Compute NewVersion and BumpType (same logic as Run)
-    normalized := normalizeVersion(cur)
-    switch versionArg {
-    case "major", "minor", "patch", "premajor", "preminor", "prepatch", "prerelease":
-        bumped, err := bumpVersion(normalized, versionArg)
-        if err != nil {
-            return meta, err
-        }
-        meta.NewVersion = strings.TrimPrefix(bumped, "v")
-        meta.BumpType = versionArg
-    case "from-git":
-        fromGit, err := getVersionFromGitDir(filepath.Dir(versionFilePath))
-        if err != nil {
-            return meta, err
-        }
-        meta.NewVersion = fromGit
-        meta.BumpType = "from-git"
-    default:
-        expl := versionArg
-        if expl != "dev" && !strings.HasPrefix(expl, "v") {
-            expl = "v" + expl
-        }
-        if expl != "dev" && !semver.IsValid(expl) {
-            return meta, fmt.Errorf("explicit version %q is not valid semver", expl)
-        }
-        meta.NewVersion = strings.TrimPrefix(expl, "v")
-        meta.BumpType = "explicit"
-    }
-
-    // 3. Prevent no-op
-    if meta.NewVersion == meta.OldVersion {
-        return meta, fmt.Errorf("new version (%s) is the same as the current version", meta.NewVersion)
-    }
-
-    // 4. Always include version.go
-    files := []string{versionFilePath}
-
-    // 5. For major bumps, also include go.mod and scan imports
-    if meta.BumpType == "major" {
-        if modDir, err := locateGoModDir(filepath.Dir(versionFilePath)); err == nil {
-            gomodPath := filepath.Join(modDir, "go.mod")
-            files = append(files, gomodPath)
-
-            // Parse old module path
-            data, _ := os.ReadFile(gomodPath)
-            f, _ := modfile.Parse("go.mod", data, nil)
-            oldMod := f.Module.Mod.Path
-
-            // Compute new module path
-            base, _, _ := module.SplitPathVersion(oldMod)
-            maj := semver.Major("v" + meta.NewVersion)
-            var newMod string
-            if maj == "v0" || maj == "v1" {
-                newMod = base
-            } else {
-                newMod = base + "/" + maj
-            }
-
-            // Scan for all .go files needing import updates
-            if more, err := scanSelfImports(modDir, oldMod, newMod); err == nil {
-                files = append(files, more...)
-            }
-        }
-    }
-
-    // 6. Check bump files
-    for _, bf := range bumpFiles {
-        if _, err := os.Stat(bf); err == nil {
-            files = append(files, bf)
-        }
-    }
-
-    meta.UpdatedFiles = files
-    return meta, nil
+	return DryRunWithConfig(Config{
+		VersionFile: versionFilePath,
+		VersionArg:  versionArg,
+		BumpFiles:   bumpFiles,
+		DryRun:      true,
+	})
 }
 
-// findAndReplaceSemver finds the first semantic version in a file and replaces it with newVersion.
-// It uses the official semver regex and does NOT support 'v' prefixes.
-func findAndReplaceSemver(filepath, newVersion string) error {
-	// Read file
-	content, err := os.ReadFile(filepath)
+// CurrentVersion returns the version a bump with cfg starts from, as
+// DryRunWithConfig reports it in OldVersion, without writing anything: a missing
+// version file is not created.
+func CurrentVersion(cfg Config) (string, error) {
+	cfg, err := cfg.withResolvedPaths()
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return "", err
 	}
+	return readBaseVersion(cfg, false)
+}
 
-	// Official semver regex with named capture groups from semver.org
-	// Removed anchors (^ and $) to find versions anywhere in the file
-	semverPattern := `(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?`
+// DryRunWithConfig performs the same simulation as DryRun using the options in cfg.
+func DryRunWithConfig(cfg Config) (VersionMeta, error) {
+	var meta VersionMeta
+	cfg, err := cfg.withResolvedPaths()
+	if err != nil {
+		return meta, err
+	}
+	versionFilePath, bumpFiles := cfg.VersionFile, cfg.bumpFiles()
+	if err := cfg.checkReconcile(); err != nil {
+		return meta, err
+	}
 
-	re, err := regexp.Compile(semverPattern)
+	// 1. Read current version
+	cur, err := readBaseVersion(cfg, true)
 	if err != nil {
-		return fmt.Errorf("failed to compile regex: %w", err)
+		return meta, err
 	}
+	meta.OldVersion = cur
 
-	// Find all matches with their positions
-	allMatches := re.FindAllIndex(content, -1)
-	if len(allMatches) == 0 {
-		return fmt.Errorf("no semantic version found in file")
+	// 2. Compute NewVersion and BumpType (same logic as Run)
+	meta.NewVersion, meta.BumpType, err = computeNewVersion(cfg, cur)
+	if err != nil {
+		return meta, err
 	}
+	meta.Tag = cfg.releaseTag(meta.BumpType, meta.NewVersion)
 
-	// Check each match to find the first one not preceded by 'v' or 'V'
-	var validMatch []int
-	for _, match := range allMatches {
-		start := match[0]
-		// Check if there's a character before this match
-		if start > 0 {
-			prevChar := content[start-1]
-			if prevChar == 'v' || prevChar == 'V' {
-				// Skip this match as it's part of a v-prefixed version
-				continue
+	if err := checkNewModulePath(cfg, meta.BumpType); err != nil {
+		return meta, err
+	}
+
+	// 4. Collect the files that would change, starting with the version file
+	var gomodPath string
+	var imports, bumped []string
+
+	// 5. For major bumps, also include go.mod and scan imports
+	if meta.BumpType == "major" {
+		modDir, err := cfg.goModDir()
+		if err != nil && cfg.ModFile != "" {
+			return meta, err
+		}
+		if err == nil {
+			gomodPath = filepath.Join(modDir, "go.mod")
+
+			// Parse old module path
+			data, _ := os.ReadFile(gomodPath)
+			f, _ := modfile.Parse("go.mod", data, nil)
+			oldMod := f.Module.Mod.Path
+
+			// Compute new module path
+			newMod := majorModulePath(oldMod, meta.NewVersion)
+			if cfg.NewModulePath != "" {
+				newMod = cfg.NewModulePath
+			}
+
+			// Scan for all .go files needing import updates
+			if more, err := scanSelfImports(modDir, oldMod, newMod); err == nil {
+				imports = more
+			}
+			if changes, err := PreviewSelfImports(modDir, oldMod, newMod); err == nil && len(changes) > 0 {
+				meta.ImportChanges = changes
 			}
 		}
-		// This is a valid match
-		validMatch = match
-		break
 	}
 
-	if validMatch == nil {
-		return fmt.Errorf("no semantic version found in file")
+	// 6. Check bump files, recording which fields they would have replaced
+	for _, bf := range bumpFiles {
+		if _, err := os.Stat(bf); err == nil {
+			bumped = append(bumped, bf)
+			matches, err := cfg.bumpFileMatches(bf, meta.NewVersion)
+			if err != nil {
+				return meta, err
+			}
+			if meta.BumpFileMatches == nil {
+				meta.BumpFileMatches = make(map[string][]BumpMatch)
+			}
+			meta.BumpFileMatches[bf] = matches
+		}
+	}
+
+	if err := checkChangelog(cfg); err != nil {
+		return meta, err
+	}
+	var changelog string
+	if cfg.writesChangelog(meta.BumpType) {
+		changelog = cfg.Changelog
+	}
+
+	meta.UpdatedFiles = releaseFiles(versionFilePath, gomodPath, cfg.MirrorFiles, imports, bumped, changelog)
+
+	// 7. Flag a tag collision that would make a real run fail, and a tag out of order
+	if meta.BumpType != "snapshot" || cfg.CommitSnapshot {
+		if meta.CommitMessage, err = cfg.commitMessage(meta); err != nil {
+			return meta, err
+		}
+	}
+
+	if cfg.checkGit() == nil && cfg.createsTag(meta.BumpType, meta.NewVersion) {
+		meta.TagExists = tagExists(cfg.git(), cfg.Dir, meta.Tag)
+		if meta.PreviousTag, err = cfg.checkTagOrder(meta.NewVersion); err != nil {
+			return meta, err
+		}
+	}
+	return meta, nil
+}
+
+// findAndReplaceSemver finds the first semantic version in a file and replaces it with newVersion.
+// It uses the official semver regex and does NOT support 'v' prefixes.
+func findAndReplaceSemver(filepath, newVersion, sep string) error {
+	// Read file
+	content, err := os.ReadFile(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	start, end, err := findFirstSemver(content, sep)
+	if err != nil {
+		return err
 	}
 
 	// Get the matched version string
-	matchedVersion := content[validMatch[0]:validMatch[1]]
+	matchedVersion := content[start:end]
 
 	// Replace only the first valid occurrence
 	newContent := bytes.Replace(content, matchedVersion, []byte(newVersion), 1)
@@ -645,39 +1549,90 @@ func findAndReplaceSemver(filepath, newVersion string) error {
 	return nil
 }
 
+// findFirstSemver returns the offsets of the first semantic version in content
+// that is not preceded by "v" or "V", with its prerelease introduced by sep.
+func findFirstSemver(content []byte, sep string) (start, end int, err error) {
+	// Official semver regex with named capture groups from semver.org
+	// Removed anchors (^ and $) to find versions anywhere in the file
+	semverPattern := `(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?`
+	// Match a prerelease introduced by a custom separator instead of "-"
+	semverPattern = strings.Replace(semverPattern, `(?:-(?P<prerelease>`, `(?:`+regexp.QuoteMeta(sep)+`(?P<prerelease>`, 1)
+
+	re, err := regexp.Compile(semverPattern)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compile regex: %w", err)
+	}
+
+	// Check each match to find the first one not preceded by 'v' or 'V'
+	for _, match := range re.FindAllIndex(content, -1) {
+		if match[0] > 0 {
+			prevChar := content[match[0]-1]
+			if prevChar == 'v' || prevChar == 'V' {
+				// Skip this match as it's part of a v-prefixed version
+				continue
+			}
+		}
+		return match[0], match[1], nil
+	}
+	return 0, 0, fmt.Errorf("no semantic version found in file")
+}
+
 // locateGoModDir walks up from startDir until it finds go.mod.
 // Returns the directory containing go.mod, or ErrNotExist if none found.
 func locateGoModDir(startDir string) (string, error) {
-    d := startDir
-    for {
-        candidate := filepath.Join(d, "go.mod")
-        if _, err := os.Stat(candidate); err == nil {
-            return d, nil
-        }
-        parent := filepath.Dir(d)
-        if parent == d {
-            break
-        }
-        d = parent
-    }
-    return "", os.ErrNotExist
+	d := startDir
+	for {
+		candidate := filepath.Join(d, "go.mod")
+		if _, err := os.Stat(candidate); err == nil {
+			return d, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			break
+		}
+		d = parent
+	}
+	return "", os.ErrNotExist
+}
+
+// checkDirty runs checkUncommittedFiles for cfg, unless cfg.AllowDirty is set, in
+// which case a warning is printed instead.
+func (cfg Config) checkDirty(allowed []string) error {
+	if cfg.AllowDirty {
+		fmt.Fprintln(os.Stderr, "Warning: skipping the dirty working tree check; uncommitted changes to other files will not be included in the release commit unless they are already staged")
+		return nil
+	}
+	return checkUncommittedFiles(cfg.git(), cfg.Dir, allowed, cfg.CleanIndexOnly, cfg.inScope)
 }
 
 // checkUncommittedFiles ensures only allowed files are modified in the working directory.
-func checkUncommittedFiles(allowed []string) error {
-	cmd := exec.Command("git", "status", "--porcelain")
-	out, err := cmd.Output()
+// An allowed directory permits changes to any file beneath it.
+// If indexOnly is set, only staged changes are considered: unstaged modifications and
+// untracked files are ignored, as the release commit leaves them out. Changed files
+// for which inScope reports false are ignored too.
+func checkUncommittedFiles(g Git, dir string, allowed []string, indexOnly bool, inScope func(path string) bool) error {
+	status, err := g.Status(dir)
 	if err != nil {
 		return fmt.Errorf("failed to check git status: %w", err)
 	}
+	out := []byte(status)
+	// Porcelain paths are relative to the repository root.
+	root, err := g.RevParse(dir, "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("failed to find repository root: %w", err)
+	}
 
 	allowedSet := make(map[string]struct{}, len(allowed))
+	var allowedDirs []string
 	for _, f := range allowed {
-		abs, err := filepath.Abs(f)
+		abs, err := canonicalPath(f)
 		if err != nil {
 			return fmt.Errorf("failed to resolve path %q: %w", f, err)
 		}
 		allowedSet[abs] = struct{}{}
+		if info, err := os.Stat(abs); err == nil && info.IsDir() {
+			allowedDirs = append(allowedDirs, abs+string(filepath.Separator))
+		}
 	}
 
 	var disallowed []string
@@ -685,116 +1640,416 @@ func checkUncommittedFiles(allowed []string) error {
 		if len(line) < 4 {
 			continue
 		}
+		// The first porcelain column is the file's status in the index.
+		if indexOnly && (line[0] == ' ' || line[0] == '?') {
+			continue
+		}
 		path := string(bytes.TrimSpace(line[3:]))
-		absPath, err := filepath.Abs(path)
-		if err != nil {
+		absPath, err := canonicalPath(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil || !inScope(absPath) {
 			continue
 		}
-		if _, ok := allowedSet[absPath]; !ok {
+		if _, ok := allowedSet[absPath]; ok {
+			continue
+		}
+		if !slices.ContainsFunc(allowedDirs, func(dir string) bool { return strings.HasPrefix(absPath, dir) }) {
 			disallowed = append(disallowed, path)
 		}
 	}
 
 	if len(disallowed) > 0 {
-		return fmt.Errorf("working directory is dirty; uncommitted files not included in commit: %v", disallowed)
+		return fmt.Errorf("%w; uncommitted files not included in commit: %v", ErrDirtyWorkingTree, disallowed)
 	}
 	return nil
 }
 
-// scanSelfImports returns the list of .go files under modDir
-// whose imports would be rewritten from oldMod → newMod.
-func scanSelfImports(modDir, oldMod, newMod string) ([]string, error) {
-    var matches []string
-    err := filepath.WalkDir(modDir, func(path string, d fs.DirEntry, err error) error {
-        if err != nil || d.IsDir() {
-            if d != nil && d.IsDir() && d.Name() == "vendor" {
-                return filepath.SkipDir
-            }
-            return nil
-        }
-        if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
-            return nil
-        }
-
-        fset := token.NewFileSet()
-        f, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
-        if err != nil {
-            // skip unparsable files
-            return nil
-        }
-        for _, imp := range f.Imports {
-            p, _ := strconv.Unquote(imp.Path.Value)
-            if strings.HasPrefix(p, oldMod) {
-                matches = append(matches, path)
-                break
-            }
-        }
-        return nil
-    })
-    return matches, err
+// canonicalPath returns the absolute form of path with symbolic links in its
+// directory resolved, so that paths reported by git and given by the caller
+// compare equal. The file itself need not exist.
+func canonicalPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		return filepath.Join(dir, filepath.Base(abs)), nil
+	}
+	return abs, nil
 }
 
-// updateSelfImports walks all .go files under modDir, updating imports from oldMod to newMod.
-// Returns the list of files modified.
-func updateSelfImports(modDir, oldMod, newMod string) ([]string, error) {
-	var modified []string
+// checkIgnoredFiles returns an error if any of files is ignored by the git repository
+// at dir. An ignored, untracked file is silently skipped by `git add`, which would
+// leave it out of the release commit while the tag still claims the release.
+func checkIgnoredFiles(g Git, dir string, files []string) error {
+	for _, f := range files {
+		rule, err := g.CheckIgnore(dir, f)
+		if err == nil {
+			return fmt.Errorf("%s is ignored by git (%s) and would be left out of the release commit", f, rule)
+		}
+	}
+	return nil
+}
+
+// existingExtraFiles returns cfg.ExtraFiles without the files that do not exist.
+// A missing file is an error, since git add would fail on it or, with some git
+// versions, skip it and leave it out of the release commit; if cfg.AllowMissingFiles
+// is set, a warning is printed instead. The version file is exempt, as the release
+// writes it.
+func existingExtraFiles(cfg Config) ([]string, error) {
+	var files []string
+	for _, f := range cfg.ExtraFiles {
+		if f != cfg.VersionFile {
+			if _, err := os.Lstat(f); os.IsNotExist(err) {
+				if !cfg.AllowMissingFiles {
+					return nil, fmt.Errorf("extra file %s not found", f)
+				}
+				fmt.Fprintf(os.Stderr, "Warning: extra file %s not found; it will not be committed\n", f)
+				continue
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// checkWritableFiles returns an error if any existing file in files cannot be
+// written, so that a read-only file is reported before anything is modified.
+// Files with no write permission bits are refused even when the process could
+// override them (e.g. as root). Missing files and directories are skipped.
+func checkWritableFiles(files []string) error {
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if info.Mode().Perm()&0222 == 0 {
+			return fmt.Errorf("%s is not writable: file is read-only (mode %v)", f, info.Mode().Perm())
+		}
+		fh, err := os.OpenFile(f, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("%s is not writable: %w", f, err)
+		}
+		fh.Close()
+	}
+	return nil
+}
+
+// selfImportWorkers bounds how many files the self-import functions parse and
+// rewrite at once.
+var selfImportWorkers = runtime.GOMAXPROCS(0)
+
+// goSourceFiles returns the .go files under modDir in lexical order, skipping
+// vendor directories. If ignoreErrors is set, unreadable directories are skipped
+// instead of failing the walk.
+func goSourceFiles(modDir string, ignoreErrors bool) ([]string, error) {
+	var paths []string
 	err := filepath.WalkDir(modDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
+			if ignoreErrors {
+				return nil
+			}
 			return err
 		}
-		// Skip vendor directories
 		if d.IsDir() {
 			if d.Name() == "vendor" {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		// Only consider .go files
-		if !strings.HasSuffix(path, ".go") {
+		if strings.HasSuffix(path, ".go") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// forEachFile calls fn with the index of every path, processing up to
+// selfImportWorkers paths at once. Once a call fails, paths not yet started are
+// skipped. It returns the error for the earliest failed path, if any.
+func forEachFile(paths []string, fn func(i int) error) error {
+	errs := make([]error, len(paths))
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	for range min(max(selfImportWorkers, 1), len(paths)) {
+		wg.Go(func() {
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(paths) || failed.Load() {
+					return
+				}
+				if errs[i] = fn(i); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanSelfImports returns the list of .go files under modDir
+// whose imports would be rewritten from oldMod → newMod.
+func scanSelfImports(modDir, oldMod, newMod string) ([]string, error) {
+	paths, err := goSourceFiles(modDir, true)
+	if err != nil {
+		return nil, err
+	}
+	paths = slices.DeleteFunc(paths, func(path string) bool { return strings.HasSuffix(path, "_test.go") })
+	matched := make([]bool, len(paths))
+	err = forEachFile(paths, func(i int) error {
+		f, err := parser.ParseFile(token.NewFileSet(), paths[i], nil, parser.ImportsOnly)
+		if err != nil {
+			// skip unparsable files
 			return nil
 		}
+		for _, imp := range f.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			if strings.HasPrefix(p, oldMod) {
+				matched[i] = true
+				break
+			}
+		}
+		return nil
+	})
+	var matches []string
+	for i, path := range paths {
+		if matched[i] {
+			matches = append(matches, path)
+		}
+	}
+	return matches, err
+}
 
+// BumpMatch describes a version field that a bump file update would replace.
+type BumpMatch struct {
+	Pattern string // How the field was found: a VersionPattern name, "toml-table-version", "yaml-key", or "first-semver".
+	Line    int    // 1-based line of the field.
+	Old     string // Version before the update, without any "v" prefix kept in the file.
+	New     string // Version written in its place.
+}
+
+// ImportChange describes a single import path that a self-import rewrite would change.
+type ImportChange struct {
+	Line int    // 1-based line of the import spec.
+	Old  string // Import path before the rewrite.
+	New  string // Import path after the rewrite.
+}
+
+// PreviewSelfImports reports, without modifying anything, the import changes that
+// UpdateSelfImports would make for the same arguments. The result maps each affected
+// file path to its changes in source order; unaffected files are omitted.
+func PreviewSelfImports(modDir, oldMod, newMod string) (map[string][]ImportChange, error) {
+	paths, err := goSourceFiles(modDir, false)
+	if err != nil {
+		return map[string][]ImportChange{}, err
+	}
+	found := make([][]ImportChange, len(paths))
+	err = forEachFile(paths, func(i int) error {
 		fset := token.NewFileSet()
-		fileAst, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		f, err := parser.ParseFile(fset, paths[i], nil, parser.ImportsOnly)
 		if err != nil {
 			return err
 		}
-
-		changed := false
-		for _, imp := range fileAst.Imports {
+		for _, imp := range f.Imports {
 			p, err := strconv.Unquote(imp.Path.Value)
-			if err != nil {
+			if err != nil || !strings.HasPrefix(p, oldMod) {
 				continue
 			}
-			if strings.HasPrefix(p, oldMod) {
-				newPath := strings.Replace(p, oldMod, newMod, 1)
-				imp.Path.Value = strconv.Quote(newPath)
-				changed = true
-			}
+			found[i] = append(found[i], ImportChange{
+				Line: fset.Position(imp.Pos()).Line,
+				Old:  p,
+				New:  strings.Replace(p, oldMod, newMod, 1),
+			})
 		}
-
-		if !changed {
-			return nil
+		return nil
+	})
+	changes := make(map[string][]ImportChange)
+	for i, path := range paths {
+		if len(found[i]) > 0 {
+			changes[path] = found[i]
 		}
+	}
+	return changes, err
+}
+
+// UpdateSelfImports rewrites import paths beginning with oldMod to begin with newMod in every
+// .go file (including tests) under modDir, skipping vendor directories. Only the import path
+// literals change, so the diff is limited to the rewritten import lines; a file is reprinted
+// with go/printer only if a literal cannot be located in its source. It returns the paths of
+// the files that were modified.
+//
+// Combined with UpdateGoModMajor, it performs a module path migration (e.g. v1 to v2)
+// without bumping a version file or touching git.
+func UpdateSelfImports(modDir, oldMod, newMod string) ([]string, error) {
+	return updateSelfImports(modDir, oldMod, newMod)
+}
+
+// updateSelfImports walks all .go files under modDir, updating imports from oldMod to newMod.
+// Returns the list of files modified.
+func updateSelfImports(modDir, oldMod, newMod string) ([]string, error) {
+	return rewriteSelfImports(modDir, oldMod, newMod, nil)
+}
 
-		// Overwrite file with updated AST
-		outFile, err := os.Create(path)
+// rewriteSelfImports is updateSelfImports with an optional callback invoked
+// for each rewritten file. Files are parsed and rewritten concurrently, but the
+// result and the callbacks follow the order of the walk.
+func rewriteSelfImports(modDir, oldMod, newMod string, onRewrite func(path string)) ([]string, error) {
+	paths, err := goSourceFiles(modDir, false)
+	if err != nil {
+		return nil, err
+	}
+	rewritten := make([]bool, len(paths))
+	err = forEachFile(paths, func(i int) error {
+		src, err := os.ReadFile(paths[i])
+		if err != nil {
+			return err
+		}
+		fset := token.NewFileSet()
+		fileAst, err := parser.ParseFile(fset, paths[i], src, parser.ParseComments)
 		if err != nil {
 			return err
 		}
-		defer outFile.Close()
-		if err := printer.Fprint(outFile, fset, fileAst); err != nil {
+
+		out, changed := spliceImportPaths(src, fset, fileAst, oldMod, newMod)
+		if !changed {
+			return nil
+		}
+		if out == nil {
+			// The edit could not be made in place: reprint the whole file.
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, fset, fileAst); err != nil {
+				return err
+			}
+			out = buf.Bytes()
+		}
+		if err := os.WriteFile(paths[i], out, 0644); err != nil {
 			return err
 		}
-		modified = append(modified, path)
+		rewritten[i] = true
 		return nil
 	})
 
+	var modified []string
+	for i, path := range paths {
+		if rewritten[i] {
+			modified = append(modified, path)
+			if onRewrite != nil {
+				onRewrite(path)
+			}
+		}
+	}
 	return modified, err
 }
 
-// runPostBumpScript executes the post-bump script with version information in environment variables.
-func runPostBumpScript(scriptPath, oldVersion, newVersion string) error {
+// spliceImportPaths rewrites every import path in f beginning with oldMod to begin
+// with newMod, both in the AST and in src, the source f was parsed from. Only the
+// bytes of each changed path literal are replaced, so the rest of the file keeps
+// its formatting. It reports whether any import changed, and returns nil source
+// if a literal could not be located exactly in src, in which case the file must
+// be reprinted from the updated AST.
+func spliceImportPaths(src []byte, fset *token.FileSet, f *ast.File, oldMod, newMod string) ([]byte, bool) {
+	out := slices.Clone(src)
+	changed := false
+	// Splice from the last import back, so that earlier offsets stay valid.
+	for _, imp := range slices.Backward(f.Imports) {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || !strings.HasPrefix(p, oldMod) {
+			continue
+		}
+		oldLit := imp.Path.Value
+		newLit := strconv.Quote(strings.Replace(p, oldMod, newMod, 1))
+		imp.Path.Value = newLit
+		changed = true
+		if out == nil {
+			continue
+		}
+		start := fset.Position(imp.Path.Pos()).Offset
+		end := start + len(oldLit)
+		if start < 0 || end > len(out) || string(out[start:end]) != oldLit {
+			out = nil
+			continue
+		}
+		out = slices.Concat(out[:start], []byte(newLit), out[end:])
+	}
+	return out, changed
+}
+
+// runBuildCheck runs "go build ./..." in modDir, returning an error that includes
+// the compiler output if the module does not build.
+func runBuildCheck(modDir string) error {
+	cmd := exec.Command("go", "build", "./...")
+	cmd.Dir = modDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("build check failed in %s: %w\n%s", modDir, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// runModTidy runs "go mod tidy" in modDir, returning an error that includes the
+// go command's output if it fails.
+func runModTidy(modDir string) error {
+	cmd := exec.Command("go", "mod", "tidy")
+	cmd.Dir = modDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("go mod tidy failed in %s: %w\n%s", modDir, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// runVersionHook executes cfg.VersionHook in cfg.Dir with the computed version in
+// GOVERSION_NEW_VERSION, along with GOVERSION_OLD_VERSION and GOVERSION_BUMP_TYPE,
+// and returns the version it prints on stdout, or newVersion if it prints nothing.
+// A printed version may have a "v" prefix and must be valid semver. The script's
+// stderr is passed through.
+func runVersionHook(cfg Config, oldVersion, newVersion, bumpType string) (string, error) {
+	info, err := os.Stat(cfg.VersionHook)
+	if err != nil {
+		return "", fmt.Errorf("version hook not found: %w", err)
+	}
+	if info.Mode()&0111 == 0 && runtime.GOOS != "windows" {
+		return "", fmt.Errorf("version hook is not executable: %s", cfg.VersionHook)
+	}
+
+	cmd := exec.Command(cfg.VersionHook)
+	cmd.Dir = cfg.Dir
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("GOVERSION_OLD_VERSION=%s", oldVersion),
+		fmt.Sprintf("GOVERSION_NEW_VERSION=%s", newVersion),
+		fmt.Sprintf("GOVERSION_BUMP_TYPE=%s", bumpType),
+	)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("version hook failed: %w", err)
+	}
+
+	printed := strings.TrimSpace(stdout.String())
+	if printed == "" {
+		return newVersion, nil
+	}
+	version := "v" + strings.TrimPrefix(printed, "v")
+	if err := checkPrereleaseIdentifiers(version); err != nil {
+		return "", fmt.Errorf("version hook printed %q, which is not valid semver: %w", printed, err)
+	}
+	if _, _, _, _, err := parseSemVer(version); err != nil || !semver.IsValid(version) {
+		return "", fmt.Errorf("version hook printed %q, which is not valid semver", printed)
+	}
+	return strings.TrimPrefix(version, "v"), nil
+}
+
+// runPostBumpScript executes the post-bump script in dir (the current directory if empty)
+// with version information in environment variables.
+func runPostBumpScript(dir, scriptPath, oldVersion, newVersion string) error {
 	// Check if script exists and is executable
 	info, err := os.Stat(scriptPath)
 	if err != nil {
@@ -808,6 +2063,7 @@ func runPostBumpScript(scriptPath, oldVersion, newVersion string) error {
 
 	// Prepare the command
 	cmd := exec.Command(scriptPath)
+	cmd.Dir = dir
 
 	// Set environment variables
 	cmd.Env = append(os.Environ(),
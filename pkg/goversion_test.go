@@ -1,12 +1,15 @@
 package goversion
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"slices"
 	"strings"
+	"sync"
 	"testing"
 
 	"golang.org/x/mod/modfile"
@@ -32,9 +35,9 @@ func TestNormalizeVersion(t *testing.T) {
 // TestParseAndFormatSemVer tests the parseSemVer and formatSemVer functions.
 func TestParseAndFormatSemVer(t *testing.T) {
 	tests := []struct {
-		input                              string
+		input                                       string
 		expectedMajor, expectedMinor, expectedPatch int
-		expectedPrerelease                 string
+		expectedPrerelease                          string
 	}{
 		{"v1.2.3", 1, 2, 3, ""},
 		{"v1.2.3-rc1", 1, 2, 3, "rc1"},
@@ -70,8 +73,12 @@ func TestBumpVersion(t *testing.T) {
 		{"v1.2.3", "premajor", "v2.0.0-0"},
 		{"v1.2.3", "preminor", "v1.3.0-0"},
 		{"v1.2.3", "prepatch", "v1.2.4-0"},
-		{"v1.2.3", "prerelease", "v1.2.4-0"}, // no prerelease exists so bump patch and attach prerelease "0"
+		{"v1.2.3", "prerelease", "v1.2.4-0"},   // no prerelease exists so bump patch and attach prerelease "0"
 		{"v1.2.3-0", "prerelease", "v1.2.3-1"}, // bump numeric part of prerelease
+		{"v1.2.3-rc.1", "prerelease", "v1.2.3-rc.2"},
+		{"v1.2.3-rc.9", "prerelease", "v1.2.3-rc.10"},
+		{"v1.2.3-rc.1+build.5", "prerelease", "v1.2.3-rc.2"}, // build metadata is dropped, not treated as prerelease
+		{"v1.2.3+build.5", "patch", "v1.2.4"},
 	}
 	for _, tc := range tests {
 		res, err := bumpVersion(tc.version, tc.bump)
@@ -89,6 +96,114 @@ func TestBumpVersion(t *testing.T) {
 	}
 }
 
+// TestBumpVersionPrereleaseReset verifies that the prerelease counter restarts at 0
+// whenever the major, minor, or patch base changes, and is never carried over.
+func TestBumpVersionPrereleaseReset(t *testing.T) {
+	tests := []struct {
+		version  string
+		bump     string
+		expected string
+	}{
+		{"v1.3.0-5", "prepatch", "v1.3.1-0"},
+		{"v1.3.0-5", "preminor", "v1.4.0-0"},
+		{"v1.3.0-5", "premajor", "v2.0.0-0"},
+		{"v1.3.0-rc.3", "prepatch", "v1.3.1-0"},
+		{"v1.3.0-rc.3", "preminor", "v1.4.0-0"},
+		{"v1.3.0-rc.3", "premajor", "v2.0.0-0"},
+		{"v2.0.0-beta.7", "premajor", "v3.0.0-0"},
+		{"v1.3.0-5", "prerelease", "v1.3.0-6"}, // same base: the counter continues
+	}
+	for _, tc := range tests {
+		res, err := bumpVersion(tc.version, tc.bump)
+		if err != nil {
+			t.Errorf("bumpVersion(%q, %q) returned error: %v", tc.version, tc.bump, err)
+			continue
+		}
+		if res != tc.expected {
+			t.Errorf("bumpVersion(%q, %q) = %q, expected %q", tc.version, tc.bump, res, tc.expected)
+		}
+	}
+
+	// A sequence of pre* bumps resets the counter each time the base moves.
+	version := "v1.2.3"
+	for _, step := range []struct{ bump, want string }{
+		{"preminor", "v1.3.0-0"},
+		{"prerelease", "v1.3.0-1"},
+		{"prerelease", "v1.3.0-2"},
+		{"preminor", "v1.4.0-0"},
+		{"prepatch", "v1.4.1-0"},
+		{"prerelease", "v1.4.1-1"},
+		{"premajor", "v2.0.0-0"},
+	} {
+		next, err := bumpVersion(version, step.bump)
+		if err != nil {
+			t.Fatalf("bumpVersion(%q, %q) returned error: %v", version, step.bump, err)
+		}
+		if next != step.want {
+			t.Errorf("bumpVersion(%q, %q) = %q, expected %q", version, step.bump, next, step.want)
+		}
+		version = next
+	}
+}
+
+// TestBumpVersionPrereleaseSame verifies that prerelease-same starts a prerelease of
+// the current version when it is final, where prerelease would bump the patch, and
+// that both keywords behave alike on an existing prerelease.
+func TestBumpVersionPrereleaseSame(t *testing.T) {
+	tests := []struct {
+		version    string
+		prerelease string // expected result of "prerelease"
+		same       string // expected result of "prerelease-same"
+	}{
+		{"v1.3.0", "v1.3.1-0", "v1.3.0-0"},
+		{"v1.3.0-0", "v1.3.0-1", "v1.3.0-1"},
+		{"v1.3.0-rc.1", "v1.3.0-rc.2", "v1.3.0-rc.2"},
+	}
+	for _, tc := range tests {
+		for bump, want := range map[string]string{"prerelease": tc.prerelease, "prerelease-same": tc.same} {
+			res, err := bumpVersion(tc.version, bump)
+			if err != nil {
+				t.Errorf("bumpVersion(%q, %q) returned error: %v", tc.version, bump, err)
+				continue
+			}
+			if res != want {
+				t.Errorf("bumpVersion(%q, %q) = %q, expected %q", tc.version, bump, res, want)
+			}
+		}
+	}
+}
+
+// TestNextVersions verifies the version each bump keyword produces.
+func TestNextVersions(t *testing.T) {
+	tests := []struct {
+		current string
+		want    map[string]string
+	}{
+		{"1.2.3", map[string]string{
+			"major":           "2.0.0",
+			"minor":           "1.3.0",
+			"patch":           "1.2.4",
+			"premajor":        "2.0.0-0",
+			"preminor":        "1.3.0-0",
+			"prepatch":        "1.2.4-0",
+			"prerelease":      "1.2.4-0",
+			"prerelease-same": "1.2.3-0",
+		}},
+	}
+	for _, tc := range tests {
+		got, err := NextVersions(tc.current)
+		if err != nil {
+			t.Fatalf("NextVersions(%q) failed: %v", tc.current, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("NextVersions(%q) = %v, want %v", tc.current, got, tc.want)
+		}
+	}
+	if _, err := NextVersions("not-a-version"); err == nil {
+		t.Error("expected an error for an invalid current version")
+	}
+}
+
 // TestReadWriteVersionFile tests the file I/O helpers for the version file.
 func TestReadWriteVersionFile(t *testing.T) {
 	// Create a temporary directory.
@@ -386,6 +501,42 @@ func TestRejectsDirtyWorkingDir(t *testing.T) {
 	}
 }
 
+// TestAllowsDirtyFilesInAllowedDirectory verifies that an extra file that is a
+// directory permits changes to any file beneath it, tracked or not, and that
+// they are committed with the release, while changes elsewhere still block it.
+func TestAllowsDirtyFilesInAllowedDirectory(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":      "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"dist/app.js":     "v1\n",
+		"distribution.md": "notes\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	writeFilesT(t, tmpDir, map[string]string{
+		"dist/app.js":       "v2\n",
+		"dist/new/chunk.js": "new\n",
+		"distribution.md":   "edited\n",
+	})
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "patch",
+		ExtraFiles:  []string{"version.go", "dist"},
+	}
+	if _, err := RunWithConfig(cfg); !errors.Is(err, ErrDirtyWorkingTree) || !strings.Contains(err.Error(), "distribution.md") || strings.Contains(err.Error(), "dist/") {
+		t.Fatalf("Run error = %v, want only distribution.md reported as dirty", err)
+	}
+
+	gitT(t, tmpDir, "checkout", "distribution.md")
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected the directory to be committed, got:\n%s", status)
+	}
+}
+
 // TestDryRun validates that DryRun returns the expected metadata and does not update the version file.
 func TestDryRun(t *testing.T) {
 	// Create a temporary directory.
@@ -434,138 +585,198 @@ func TestDryRun(t *testing.T) {
 // leaves the module path unchanged for v1,
 // but appends /vN for majors ≥ 2.
 func TestUpdateGoModSuffix(t *testing.T) {
-    tmpDir, err := os.MkdirTemp("", "goversion_mod_test")
-    if err != nil {
-        t.Fatal(err)
-    }
-    defer os.RemoveAll(tmpDir)
+	tmpDir, err := os.MkdirTemp("", "goversion_mod_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
 
-    // A minimal go.mod to start from
-    initial := `module example.com/m
+	// A minimal go.mod to start from
+	initial := `module example.com/m
 
 go 1.18
 `
-    modFile := filepath.Join(tmpDir, "go.mod")
-
-    tests := []struct {
-        newVersion         string
-        expectedModuleLine string
-    }{
-        {"1.0.0", "module example.com/m"},
-        {"2.0.0", "module example.com/m/v2"},
-        {"3.0.0", "module example.com/m/v3"},
-    }
-
-    for _, tc := range tests {
-        // Reset go.mod
-        if err := os.WriteFile(modFile, []byte(initial), 0644); err != nil {
-            t.Fatalf("writing go.mod for %q: %v", tc.newVersion, err)
-        }
-        // Run the suffix updater
-        if err := updateGoMod(tmpDir, tc.newVersion); err != nil {
-            t.Errorf("updateGoMod(%q) error: %v", tc.newVersion, err)
-            continue
-        }
-        // Read back and verify the module line
-        data, err := os.ReadFile(modFile)
-        if err != nil {
-            t.Errorf("reading go.mod for %q: %v", tc.newVersion, err)
-            continue
-        }
-        firstLine := strings.SplitN(string(data), "\n", 2)[0]
-        if firstLine != tc.expectedModuleLine {
-            t.Errorf("for version %q, got %q; want %q",
-                tc.newVersion, firstLine, tc.expectedModuleLine)
-        }
-    }
+	modFile := filepath.Join(tmpDir, "go.mod")
+
+	tests := []struct {
+		newVersion         string
+		expectedModuleLine string
+	}{
+		{"1.0.0", "module example.com/m"},
+		{"2.0.0", "module example.com/m/v2"},
+		{"3.0.0", "module example.com/m/v3"},
+	}
+
+	for _, tc := range tests {
+		// Reset go.mod
+		if err := os.WriteFile(modFile, []byte(initial), 0644); err != nil {
+			t.Fatalf("writing go.mod for %q: %v", tc.newVersion, err)
+		}
+		// Run the suffix updater
+		if err := updateGoMod(tmpDir, tc.newVersion); err != nil {
+			t.Errorf("updateGoMod(%q) error: %v", tc.newVersion, err)
+			continue
+		}
+		// Read back and verify the module line
+		data, err := os.ReadFile(modFile)
+		if err != nil {
+			t.Errorf("reading go.mod for %q: %v", tc.newVersion, err)
+			continue
+		}
+		firstLine := strings.SplitN(string(data), "\n", 2)[0]
+		if firstLine != tc.expectedModuleLine {
+			t.Errorf("for version %q, got %q; want %q",
+				tc.newVersion, firstLine, tc.expectedModuleLine)
+		}
+	}
+}
+
+// TestUpdateGoModSelfReplace verifies that replace directives for the module
+// itself follow its new major version path in place, while other replaces,
+// including those of nested modules, are untouched.
+func TestUpdateGoModSelfReplace(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace string
+		want    []string
+	}{
+		{
+			name:    "local",
+			replace: "replace example.com/foo => ./foo\n",
+			want:    []string{"example.com/foo/v2 => ./foo"},
+		},
+		{
+			name:    "versioned",
+			replace: "replace example.com/foo v1.0.0 => example.com/foo v1.0.1 // pinned\n",
+			want:    []string{"example.com/foo/v2 => example.com/foo v1.0.1"},
+		},
+		{
+			name:    "other modules",
+			replace: "replace (\n\texample.com/foo/sub => ./sub\n\texample.com/bar => ../bar\n)\n",
+			want:    []string{"example.com/foo/sub => ./sub", "example.com/bar => ../bar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			initial := "module example.com/foo\n\ngo 1.18\n\n" + tt.replace
+			if err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(initial), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := updateGoMod(tmpDir, "2.0.0"); err != nil {
+				t.Fatalf("updateGoMod failed: %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(tmpDir, "go.mod"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := modfile.Parse("go.mod", data, nil)
+			if err != nil {
+				t.Fatalf("updated go.mod does not parse: %v\n%s", err, data)
+			}
+			if f.Module.Mod.Path != "example.com/foo/v2" {
+				t.Errorf("module = %q, want example.com/foo/v2", f.Module.Mod.Path)
+			}
+			var got []string
+			for _, r := range f.Replace {
+				got = append(got, strings.TrimSpace(r.Old.Path+" "+r.Old.Version)+" => "+strings.TrimSpace(r.New.Path+" "+r.New.Version))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("replaces = %q, want %q\n%s", got, tt.want, data)
+			}
+			if strings.Contains(tt.replace, "// pinned") && !strings.Contains(string(data), "// pinned") {
+				t.Errorf("comment on the replace was lost:\n%s", data)
+			}
+		})
+	}
 }
 
 // TestUpdateSelfImportsIntegration ensures that after a v2 bump,
 // imports in other packages under the same module are rewritten.
 func TestUpdateSelfImportsIntegration(t *testing.T) {
-    // 1) Setup a temporary module
-    tmpDir, err := os.MkdirTemp("", "selfimports_test")
-    if err != nil {
-        t.Fatal(err)
-    }
-    defer os.RemoveAll(tmpDir)
+	// 1) Setup a temporary module
+	tmpDir, err := os.MkdirTemp("", "selfimports_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
 
-    // write go.mod for module example.com/foo
-    modContents := `module example.com/foo
+	// write go.mod for module example.com/foo
+	modContents := `module example.com/foo
 
 go 1.18
 `
-    modFile := filepath.Join(tmpDir, "go.mod")
-    if err := os.WriteFile(modFile, []byte(modContents), 0644); err != nil {
-        t.Fatalf("writing go.mod: %v", err)
-    }
-
-    // 2) Create pkg/a/a.go
-    aDir := filepath.Join(tmpDir, "pkg", "a")
-    if err := os.MkdirAll(aDir, 0755); err != nil {
-        t.Fatal(err)
-    }
-    aSrc := `package a
+	modFile := filepath.Join(tmpDir, "go.mod")
+	if err := os.WriteFile(modFile, []byte(modContents), 0644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	// 2) Create pkg/a/a.go
+	aDir := filepath.Join(tmpDir, "pkg", "a")
+	if err := os.MkdirAll(aDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	aSrc := `package a
 
 func A() {}
 `
-    if err := os.WriteFile(filepath.Join(aDir, "a.go"), []byte(aSrc), 0644); err != nil {
-        t.Fatal(err)
-    }
+	if err := os.WriteFile(filepath.Join(aDir, "a.go"), []byte(aSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
 
-    // 3) Create pkg/b/b.go importing example.com/foo/pkg/a
-    bDir := filepath.Join(tmpDir, "pkg", "b")
-    if err := os.MkdirAll(bDir, 0755); err != nil {
-        t.Fatal(err)
-    }
-    bSrc := `package b
+	// 3) Create pkg/b/b.go importing example.com/foo/pkg/a
+	bDir := filepath.Join(tmpDir, "pkg", "b")
+	if err := os.MkdirAll(bDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	bSrc := `package b
 
 import "example.com/foo/pkg/a"
 
 func B() { a.A() }
 `
-    bPath := filepath.Join(bDir, "b.go")
-    if err := os.WriteFile(bPath, []byte(bSrc), 0644); err != nil {
-        t.Fatal(err)
-    }
-
-    // 4) Bump go.mod to v2 (via updateGoMod) and re-parse new module path
-    if err := updateGoMod(tmpDir, "2.0.0"); err != nil {
-        t.Fatalf("updateGoMod failed: %v", err)
-    }
-    data, err := os.ReadFile(modFile)
-    if err != nil {
-        t.Fatalf("reading bumped go.mod: %v", err)
-    }
-    mf, err := modfile.Parse("go.mod", data, nil)
-    if err != nil {
-        t.Fatalf("parsing bumped go.mod: %v", err)
-    }
-    newModPath := mf.Module.Mod.Path // should be "example.com/foo/v2"
-
-    // 5) Rewrite self-imports and collect modified files
-    modified, err := updateSelfImports(tmpDir, "example.com/foo", newModPath)
-    if err != nil {
-        t.Fatalf("updateSelfImports failed: %v", err)
-    }
-
-    // 6) Only pkg/b/b.go should have been touched
-    if !slices.Contains(modified, bPath) {
-        t.Errorf("expected %q in modified list, got: %v", bPath, modified)
-    }
-    if slices.Contains(modified, filepath.Join(aDir, "a.go")) {
-        t.Errorf("pkg/a/a.go should not be rewritten, but was")
-    }
-
-    // 7) Verify that b.go’s import line is updated to example.com/foo/v2/pkg/a
-    out, err := os.ReadFile(bPath)
-    if err != nil {
-        t.Fatalf("reading updated b.go: %v", err)
-    }
-    wantImport := fmt.Sprintf(`import "%s/pkg/a"`, newModPath)
-    if !strings.Contains(string(out), wantImport) {
-        t.Errorf("b.go import not updated, expected %q; got:\n%s", wantImport, string(out))
-    }
+	bPath := filepath.Join(bDir, "b.go")
+	if err := os.WriteFile(bPath, []byte(bSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 4) Bump go.mod to v2 (via updateGoMod) and re-parse new module path
+	if err := updateGoMod(tmpDir, "2.0.0"); err != nil {
+		t.Fatalf("updateGoMod failed: %v", err)
+	}
+	data, err := os.ReadFile(modFile)
+	if err != nil {
+		t.Fatalf("reading bumped go.mod: %v", err)
+	}
+	mf, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		t.Fatalf("parsing bumped go.mod: %v", err)
+	}
+	newModPath := mf.Module.Mod.Path // should be "example.com/foo/v2"
+
+	// 5) Rewrite self-imports and collect modified files
+	modified, err := updateSelfImports(tmpDir, "example.com/foo", newModPath)
+	if err != nil {
+		t.Fatalf("updateSelfImports failed: %v", err)
+	}
+
+	// 6) Only pkg/b/b.go should have been touched
+	if !slices.Contains(modified, bPath) {
+		t.Errorf("expected %q in modified list, got: %v", bPath, modified)
+	}
+	if slices.Contains(modified, filepath.Join(aDir, "a.go")) {
+		t.Errorf("pkg/a/a.go should not be rewritten, but was")
+	}
+
+	// 7) Verify that b.go’s import line is updated to example.com/foo/v2/pkg/a
+	out, err := os.ReadFile(bPath)
+	if err != nil {
+		t.Fatalf("reading updated b.go: %v", err)
+	}
+	wantImport := fmt.Sprintf(`import "%s/pkg/a"`, newModPath)
+	if !strings.Contains(string(out), wantImport) {
+		t.Errorf("b.go import not updated, expected %q; got:\n%s", wantImport, string(out))
+	}
 }
 
 // TestFindAndReplaceSemver tests the findAndReplaceSemver function with various file formats.
@@ -712,63 +923,63 @@ status: deployed`,
   legacy: 1.9.9-beta+exp.sha.5114f85`,
 		},
 		{
-			name: "zero-padded numeric prerelease",
-			content: `release = "1.0.0-0.3.7"`,
-			newVersion: "1.0.0-0.3.8",
+			name:        "zero-padded numeric prerelease",
+			content:     `release = "1.0.0-0.3.7"`,
+			newVersion:  "1.0.0-0.3.8",
 			wantContent: `release = "1.0.0-0.3.8"`,
 		},
 		{
-			name: "complex prerelease identifiers",
-			content: `version: "1.0.0-x.7.z.92"`,
-			newVersion: "1.0.0-x.7.z.93",
+			name:        "complex prerelease identifiers",
+			content:     `version: "1.0.0-x.7.z.92"`,
+			newVersion:  "1.0.0-x.7.z.93",
 			wantContent: `version: "1.0.0-x.7.z.93"`,
 		},
 		{
-			name: "prerelease with hyphens",
-			content: `{"version": "1.0.0-x-y-z.--"}`,
-			newVersion: "1.0.0",
+			name:        "prerelease with hyphens",
+			content:     `{"version": "1.0.0-x-y-z.--"}`,
+			newVersion:  "1.0.0",
 			wantContent: `{"version": "1.0.0"}`,
 		},
 		{
-			name: "semver.org example 1",
-			content: `version = "1.0.0-alpha"`,
-			newVersion: "1.0.0-alpha.1",
+			name:        "semver.org example 1",
+			content:     `version = "1.0.0-alpha"`,
+			newVersion:  "1.0.0-alpha.1",
 			wantContent: `version = "1.0.0-alpha.1"`,
 		},
 		{
-			name: "semver.org example 2",
-			content: `version = "1.0.0-alpha.1"`,
-			newVersion: "1.0.0-alpha.beta",
+			name:        "semver.org example 2",
+			content:     `version = "1.0.0-alpha.1"`,
+			newVersion:  "1.0.0-alpha.beta",
 			wantContent: `version = "1.0.0-alpha.beta"`,
 		},
 		{
-			name: "semver.org example 3",
-			content: `version = "1.0.0-0.3.7"`,
-			newVersion: "1.0.0-rc.1",
+			name:        "semver.org example 3",
+			content:     `version = "1.0.0-0.3.7"`,
+			newVersion:  "1.0.0-rc.1",
 			wantContent: `version = "1.0.0-rc.1"`,
 		},
 		{
-			name: "semver.org example 4",
-			content: `version = "1.0.0-x.7.z.92"`,
-			newVersion: "1.0.0",
+			name:        "semver.org example 4",
+			content:     `version = "1.0.0-x.7.z.92"`,
+			newVersion:  "1.0.0",
 			wantContent: `version = "1.0.0"`,
 		},
 		{
-			name: "semver.org example 5",
-			content: `version = "1.0.0-alpha+001"`,
-			newVersion: "1.0.0",
+			name:        "semver.org example 5",
+			content:     `version = "1.0.0-alpha+001"`,
+			newVersion:  "1.0.0",
 			wantContent: `version = "1.0.0"`,
 		},
 		{
-			name: "semver.org example 6",
-			content: `version = "1.0.0+20130313144700"`,
-			newVersion: "1.0.1",
+			name:        "semver.org example 6",
+			content:     `version = "1.0.0+20130313144700"`,
+			newVersion:  "1.0.1",
 			wantContent: `version = "1.0.1"`,
 		},
 		{
-			name: "semver.org example 7",
-			content: `version = "1.0.0-beta+exp.sha.5114f85"`,
-			newVersion: "1.0.0-beta.2",
+			name:        "semver.org example 7",
+			content:     `version = "1.0.0-beta+exp.sha.5114f85"`,
+			newVersion:  "1.0.0-beta.2",
 			wantContent: `version = "1.0.0-beta.2"`,
 		},
 		{
@@ -812,7 +1023,7 @@ cli_version = "3.0.0"`,
 			}
 
 			// Run findAndReplaceSemver
-			err = findAndReplaceSemver(tmpFile.Name(), tc.newVersion)
+			err = findAndReplaceSemver(tmpFile.Name(), tc.newVersion, "-")
 
 			// Check error
 			if tc.wantErr {
@@ -1022,6 +1233,51 @@ func TestDryRunWithBumpFiles(t *testing.T) {
 	}
 }
 
+// TestDryRunBumpFileMatches verifies that a dry run reports which field of each
+// bump file would be replaced, and how it was found, without modifying anything.
+func TestDryRunBumpFileMatches(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	files := map[string]string{
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		// The first semver is in a comment, but the version field wins.
+		"weird.conf": "# Migrated from tool 9.9.9-rc.1\n[core]\n  version = \"1.2.3\"\n",
+		// No version field, so the first semver without a "v" is replaced.
+		"legacy.txt": "Upgrade from v1.0.0.\nrelease 1.2.3 (stable)\n",
+		"Chart.yaml": "apiVersion: v2\nversion: 1.2.3\nappVersion: \"1.2.3\"\n",
+		"empty.txt":  "nothing to see here\n",
+	}
+	writeFilesT(t, tmpDir, files)
+	commitAllT(t, tmpDir, "initial commit")
+
+	meta, err := DryRunWithConfig(Config{
+		Dir:           tmpDir,
+		VersionFile:   "version.go",
+		VersionArg:    "patch",
+		BumpFiles:     []string{"weird.conf", "legacy.txt", "empty.txt"},
+		BumpAllFields: []string{"Chart.yaml"},
+	})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	want := map[string][]BumpMatch{
+		filepath.Join(tmpDir, "weird.conf"): {{Pattern: "toml-version", Line: 3, Old: "1.2.3", New: "1.2.4"}},
+		filepath.Join(tmpDir, "legacy.txt"): {{Pattern: "first-semver", Line: 2, Old: "1.2.3", New: "1.2.4"}},
+		filepath.Join(tmpDir, "Chart.yaml"): {
+			{Pattern: "yaml-version", Line: 2, Old: "1.2.3", New: "1.2.4"},
+			{Pattern: "yaml-app-version", Line: 3, Old: "1.2.3", New: "1.2.4"},
+		},
+		filepath.Join(tmpDir, "empty.txt"): nil,
+	}
+	if !reflect.DeepEqual(meta.BumpFileMatches, want) {
+		t.Errorf("BumpFileMatches = %+v, want %+v", meta.BumpFileMatches, want)
+	}
+	for name, content := range files {
+		if got, _ := os.ReadFile(filepath.Join(tmpDir, name)); string(got) != content {
+			t.Errorf("%s was modified by a dry run:\n%s", name, got)
+		}
+	}
+}
+
 // TestPostBumpScript tests the post-bump script functionality.
 func TestPostBumpScript(t *testing.T) {
 	if err := checkGit(); err != nil {
@@ -1129,6 +1385,64 @@ echo "Post-bump script executed"
 	}
 }
 
+// TestVersionHook verifies that a version printed by the version hook replaces the
+// computed version in the version file, the commit, and the tag, that a silent
+// hook keeps the computed version, and that an invalid version is rejected.
+func TestVersionHook(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr string
+	}{
+		{name: "rewrite", script: "echo \"v${GOVERSION_NEW_VERSION}-acme.${GOVERSION_BUMP_TYPE}\"\n", want: "1.3.0-acme.minor"},
+		{name: "keep", script: "echo \"from $GOVERSION_OLD_VERSION\" >&2\n", want: "1.3.0"},
+		{name: "invalid", script: "echo 1.3\n", wantErr: `"1.3", which is not valid semver`},
+		{name: "failure", script: "exit 3\n", wantErr: "version hook failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{
+				"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+			})
+			if err := os.WriteFile(filepath.Join(tmpDir, "hook.sh"), []byte("#!/bin/sh\n"+tt.script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, tmpDir, "initial commit")
+
+			meta, err := RunWithConfig(Config{
+				Dir:         tmpDir,
+				VersionFile: "version.go",
+				VersionArg:  "minor",
+				ExtraFiles:  []string{"version.go"},
+				VersionHook: "hook.sh",
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Run error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+					t.Errorf("version file = %q, want it untouched", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if meta.NewVersion != tt.want {
+				t.Errorf("NewVersion = %q, want %q", meta.NewVersion, tt.want)
+			}
+			if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != tt.want {
+				t.Errorf("version file = %q, want %q", v, tt.want)
+			}
+			if got := gitT(t, tmpDir, "tag", "--points-at", "HEAD"); got != "v"+tt.want {
+				t.Errorf("tag = %q, want v%s", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestPostBumpScriptFailure tests that a failing post-bump script aborts the operation.
 func TestPostBumpScriptFailure(t *testing.T) {
 	if err := checkGit(); err != nil {
@@ -1220,3 +1534,2156 @@ exit 1
 		t.Errorf("git tag should not have been created after script failure")
 	}
 }
+
+// TestPlainTextVersionFile verifies that a non-Go version file such as an embedded
+// version.txt is bumped as plain text while the Go wrapper is left untouched.
+func TestPlainTextVersionFile(t *testing.T) {
+	tmpDir := initTestRepo(t)
+
+	versionTxt := filepath.Join(tmpDir, "version.txt")
+	if err := os.WriteFile(versionTxt, []byte("1.2.3\n"), 0644); err != nil {
+		t.Fatalf("failed to write version.txt: %v", err)
+	}
+	embedGo := filepath.Join(tmpDir, "version.go")
+	embedContent := "package main\n\nimport _ \"embed\"\n\n//go:embed version.txt\nvar Version string\n"
+	if err := os.WriteFile(embedGo, []byte(embedContent), 0644); err != nil {
+		t.Fatalf("failed to write version.go: %v", err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+
+	meta, err := Run(versionTxt, "patch", []string{versionTxt}, nil, "")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.OldVersion != "1.2.3" || meta.NewVersion != "1.2.4" {
+		t.Errorf("got %s -> %s, want 1.2.3 -> 1.2.4", meta.OldVersion, meta.NewVersion)
+	}
+
+	got, err := os.ReadFile(versionTxt)
+	if err != nil {
+		t.Fatalf("failed to read version.txt: %v", err)
+	}
+	if string(got) != "1.2.4\n" {
+		t.Errorf("version.txt = %q, want %q", got, "1.2.4\n")
+	}
+	gotGo, err := os.ReadFile(embedGo)
+	if err != nil {
+		t.Fatalf("failed to read version.go: %v", err)
+	}
+	if string(gotGo) != embedContent {
+		t.Errorf("version.go was modified:\n%s", gotGo)
+	}
+	if tags := gitT(t, tmpDir, "tag"); !slices.Contains(strings.Split(tags, "\n"), "v1.2.4") {
+		t.Errorf("expected tag v1.2.4, got: %s", tags)
+	}
+}
+
+// TestManifestVersionFile verifies that Python packaging files can be the primary
+// version file: the version is read from and written to their version field only.
+func TestManifestVersionFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		wantContent string
+	}{
+		{
+			name:        "setup.py",
+			file:        "setup.py",
+			content:     "from setuptools import setup\n\nsetup(\n    name=\"tool\",\n    version=\"0.1.0\",\n    install_requires=[\"requests>=2.31.0\"],\n)\n",
+			wantContent: "from setuptools import setup\n\nsetup(\n    name=\"tool\",\n    version=\"0.2.0\",\n    install_requires=[\"requests>=2.31.0\"],\n)\n",
+		},
+		{
+			name:        "setup.cfg",
+			file:        "setup.cfg",
+			content:     "[metadata]\nname = tool\nversion = 0.1.0\n\n[options]\npython_requires = >=3.8.0\n",
+			wantContent: "[metadata]\nname = tool\nversion = 0.2.0\n\n[options]\npython_requires = >=3.8.0\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{tc.file: tc.content})
+			commitAllT(t, tmpDir, "initial commit")
+			t.Chdir(tmpDir)
+
+			versionFile := filepath.Join(tmpDir, tc.file)
+			meta, err := Run(versionFile, "minor", []string{versionFile}, nil, "")
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if meta.OldVersion != "0.1.0" || meta.NewVersion != "0.2.0" {
+				t.Errorf("got %s -> %s, want 0.1.0 -> 0.2.0", meta.OldVersion, meta.NewVersion)
+			}
+			got, err := os.ReadFile(versionFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.wantContent {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, tc.wantContent)
+			}
+			if tag := gitT(t, tmpDir, "describe", "--tags", "--exact-match"); tag != "v0.2.0" {
+				t.Errorf("tag = %q, want v0.2.0", tag)
+			}
+		})
+	}
+}
+
+// TestRequireBranch verifies that a release is refused from any branch other than
+// the one required, and allowed from the required branch.
+func TestRequireBranch(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "branch", "-M", "main")
+	gitT(t, tmpDir, "checkout", "-b", "feature")
+	t.Chdir(tmpDir)
+
+	cfg := Config{
+		VersionFile:   versionFile,
+		VersionArg:    "patch",
+		ExtraFiles:    []string{versionFile},
+		RequireBranch: "main",
+	}
+	_, err := RunWithConfig(cfg)
+	if err == nil || !strings.Contains(err.Error(), `refusing to release from branch "feature"`) {
+		t.Fatalf("expected refusal from feature branch, got: %v", err)
+	}
+	if v, _ := readCurrentVersion(versionFile); v != "1.2.3" {
+		t.Errorf("version file modified despite refusal: %s", v)
+	}
+
+	gitT(t, tmpDir, "checkout", "main")
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("release from required branch failed: %v", err)
+	}
+}
+
+// TestDeterminePackageNameBuildConstraints verifies that the package clause is found
+// after build constraints and is not confused by "package" inside a comment.
+func TestDeterminePackageNameBuildConstraints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "version.go")
+	content := `//go:build linux || darwin
+// +build linux darwin
+
+/*
+package bogus
+*/
+
+package buildinfo
+
+var (
+	Version = "1.2.3"
+)
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := determinePackageName(path)
+	if err != nil {
+		t.Fatalf("determinePackageName failed: %v", err)
+	}
+	if name != "buildinfo" {
+		t.Errorf("determinePackageName = %q, want %q", name, "buildinfo")
+	}
+}
+
+// TestNewModulePathOverride verifies that a major bump with NewModulePath writes the
+// given path to go.mod and rewrites self-imports to it.
+func TestNewModulePathOverride(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	files := map[string]string{
+		"go.mod":     "module example.com/foo\n\ngo 1.18\n",
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"a/a.go":     "package a\n\nfunc A() {}\n",
+		"b/b.go":     "package b\n\nimport \"example.com/foo/a\"\n\nfunc B() { a.A() }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+
+	versionFile := filepath.Join(tmpDir, "version.go")
+	cfg := Config{
+		VersionFile:   versionFile,
+		VersionArg:    "major",
+		ExtraFiles:    []string{versionFile},
+		NewModulePath: "example.org/foo/v2",
+	}
+
+	if _, err := DryRunWithConfig(Config{VersionFile: versionFile, VersionArg: "minor", NewModulePath: "example.org/foo/v2"}); err == nil {
+		t.Error("expected NewModulePath to be rejected for a minor bump")
+	}
+	if _, err := DryRunWithConfig(Config{VersionFile: versionFile, VersionArg: "major", NewModulePath: "not a path"}); err == nil {
+		t.Error("expected an invalid NewModulePath to be rejected")
+	}
+
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	modData, err := os.ReadFile(filepath.Join(tmpDir, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mf, err := modfile.Parse("go.mod", modData, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mf.Module.Mod.Path != "example.org/foo/v2" {
+		t.Errorf("go.mod module = %q, want %q", mf.Module.Mod.Path, "example.org/foo/v2")
+	}
+
+	bPath := filepath.Join(tmpDir, "b", "b.go")
+	bData, err := os.ReadFile(bPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bData), `"example.org/foo/v2/a"`) {
+		t.Errorf("b.go import not rewritten:\n%s", bData)
+	}
+	if !slices.Contains(meta.UpdatedFiles, bPath) {
+		t.Errorf("expected %s in UpdatedFiles, got %v", bPath, meta.UpdatedFiles)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected all changes committed, got:\n%s", status)
+	}
+}
+
+// TestRejectsIgnoredVersionFile verifies that Run refuses to release when the version
+// file is ignored by git, rather than tagging a commit that lacks the version change.
+func TestRejectsIgnoredVersionFile(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("gen/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+
+	versionFile := filepath.Join(tmpDir, "gen", "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(tmpDir)
+
+	_, err := Run(versionFile, "patch", []string{versionFile}, nil, "")
+	if err == nil || !strings.Contains(err.Error(), "is ignored by git") {
+		t.Fatalf("expected ignored file error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), ".gitignore") {
+		t.Errorf("expected error to name the matching ignore source, got: %v", err)
+	}
+	if v, _ := readCurrentVersion(versionFile); v != "1.2.3" {
+		t.Errorf("version file modified despite error: %s", v)
+	}
+	if tags := gitT(t, tmpDir, "tag"); tags != "" {
+		t.Errorf("expected no tags, got: %s", tags)
+	}
+}
+
+// TestInitialVersion verifies that InitialVersion replaces "dev" as the starting
+// version when there is no version file and no git tag, and that it must be semver.
+func TestInitialVersion(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"README.md": "# foo\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+	versionFile := filepath.Join(tmpDir, "version.go")
+
+	bad := Config{VersionFile: versionFile, VersionArg: "patch", InitialVersion: "one"}
+	if _, err := RunWithConfig(bad); err == nil {
+		t.Error("expected an invalid initial version to be rejected")
+	}
+
+	meta, err := RunWithConfig(Config{
+		VersionFile:    versionFile,
+		VersionArg:     "patch",
+		ExtraFiles:     []string{versionFile},
+		InitialVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.OldVersion != "1.0.0" || meta.NewVersion != "1.0.1" {
+		t.Errorf("bumped %s -> %s, want 1.0.0 -> 1.0.1", meta.OldVersion, meta.NewVersion)
+	}
+	if tag := gitT(t, tmpDir, "describe", "--tags"); tag != "v1.0.1" {
+		t.Errorf("tag = %q, want v1.0.1", tag)
+	}
+}
+
+// TestPreviewSelfImports verifies that the preview lists each import that would be
+// rewritten, leaves files untouched, and is surfaced by a major-bump dry run.
+func TestPreviewSelfImports(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	bSrc := "package b\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/foo/a\"\n)\n\nfunc B() { fmt.Println(a.A) }\n"
+	writeFilesT(t, tmpDir, map[string]string{
+		"go.mod":     "module example.com/foo\n\ngo 1.18\n",
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"a/a.go":     "package a\n\nconst A = 1\n",
+		"b/b.go":     bSrc,
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+
+	bPath := filepath.Join(tmpDir, "b", "b.go")
+	want := map[string][]ImportChange{
+		bPath: {{Line: 6, Old: "example.com/foo/a", New: "example.com/foo/v2/a"}},
+	}
+
+	changes, err := PreviewSelfImports(tmpDir, "example.com/foo", "example.com/foo/v2")
+	if err != nil {
+		t.Fatalf("PreviewSelfImports failed: %v", err)
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("preview = %v, want %v", changes, want)
+	}
+	if data, _ := os.ReadFile(bPath); string(data) != bSrc {
+		t.Errorf("preview modified b.go:\n%s", data)
+	}
+
+	meta, err := DryRunWithConfig(Config{VersionFile: filepath.Join(tmpDir, "version.go"), VersionArg: "major"})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if !reflect.DeepEqual(meta.ImportChanges, want) {
+		t.Errorf("DryRun ImportChanges = %v, want %v", meta.ImportChanges, want)
+	}
+}
+
+// TestReadCurrentVersionNonLiteral verifies that a Version declared with a
+// computed value produces an error explaining that a string literal is required.
+func TestReadCurrentVersionNonLiteral(t *testing.T) {
+	versionFile := filepath.Join(t.TempDir(), "version.go")
+	src := "package version\n\nvar Version = someFunc()\n\nfunc someFunc() string { return \"1.2.3\" }\n"
+	if err := os.WriteFile(versionFile, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := readCurrentVersion(versionFile)
+	if err == nil {
+		t.Fatal("expected an error for a computed Version")
+	}
+	if want := "version.go:3: Version must be assigned a string literal"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %q, want it to contain %q", err, want)
+	}
+}
+
+// TestExplicitPrereleaseThenPrerelease verifies that setting an explicit
+// prerelease and then bumping with the prerelease keyword increments its
+// numeric tail in both the version file and the tag.
+func TestExplicitPrereleaseThenPrerelease(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.2\"\n)\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+
+	versionFile := filepath.Join(tmpDir, "version.go")
+	for _, step := range []struct{ arg, want string }{
+		{"1.2.3-rc.1", "1.2.3-rc.1"},
+		{"prerelease", "1.2.3-rc.2"},
+		{"prerelease", "1.2.3-rc.3"},
+	} {
+		meta, err := Run(versionFile, step.arg, []string{versionFile}, nil, "")
+		if err != nil {
+			t.Fatalf("Run(%q) failed: %v", step.arg, err)
+		}
+		if meta.NewVersion != step.want {
+			t.Errorf("Run(%q) = %s, want %s", step.arg, meta.NewVersion, step.want)
+		}
+		if v, _ := readCurrentVersion(versionFile); v != step.want {
+			t.Errorf("version file = %s after %q, want %s", v, step.arg, step.want)
+		}
+		if tag := gitT(t, tmpDir, "describe", "--tags", "--exact-match"); tag != "v"+step.want {
+			t.Errorf("tag = %s after %q, want v%s", tag, step.arg, step.want)
+		}
+	}
+}
+
+// TestDryRunTagExists verifies that DryRun flags a tag collision that would make
+// a real run fail, without failing itself.
+func TestDryRunTagExists(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v1.2.4")
+	t.Chdir(tmpDir)
+
+	meta, err := DryRun(versionFile, "patch", nil)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if !meta.TagExists {
+		t.Error("expected TagExists for v1.2.4")
+	}
+
+	meta, err = DryRun(versionFile, "minor", nil)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if meta.TagExists {
+		t.Error("unexpected TagExists for v1.3.0")
+	}
+}
+
+// TestCommitTrailers verifies that Trailers and Signoff are added to the release commit.
+func TestCommitTrailers(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+
+	_, err := RunWithConfig(Config{
+		VersionFile: versionFile,
+		VersionArg:  "minor",
+		ExtraFiles:  []string{versionFile},
+		Trailers:    []string{"Release-As: 1.3.0", "Refs: #42"},
+		Signoff:     true,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	msg := gitT(t, tmpDir, "log", "-1", "--format=%B")
+	for _, want := range []string{"Release-As: 1.3.0", "Refs: #42", "Signed-off-by: Test User <test@example.com>"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("commit message missing %q:\n%s", want, msg)
+		}
+	}
+	if subject := gitT(t, tmpDir, "log", "-1", "--format=%s"); subject != "1.3.0" {
+		t.Errorf("commit subject = %q, want %q", subject, "1.3.0")
+	}
+}
+
+// TestRejectsReadOnlyVersionFile verifies that a read-only version file is
+// reported before any file is modified.
+func TestRejectsReadOnlyVersionFile(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	packageJSON := filepath.Join(tmpDir, "package.json")
+	if err := os.WriteFile(packageJSON, []byte(`{"version": "1.2.3"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	if err := os.Chmod(versionFile, 0444); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(tmpDir)
+
+	_, err := Run(versionFile, "patch", []string{versionFile}, []string{packageJSON}, "")
+	if err == nil || !strings.Contains(err.Error(), "not writable") {
+		t.Fatalf("expected a not writable error, got %v", err)
+	}
+	if data, _ := os.ReadFile(packageJSON); string(data) != `{"version": "1.2.3"}` {
+		t.Errorf("package.json was modified before the failure: %s", data)
+	}
+	if v, _ := readCurrentVersion(versionFile); v != "1.2.3" {
+		t.Errorf("version file = %s, want 1.2.3", v)
+	}
+	if tags := gitT(t, tmpDir, "tag"); tags != "" {
+		t.Errorf("expected no tags, got %q", tags)
+	}
+}
+
+// TestRunWithDir verifies that Run works against a repository other than the
+// current directory when Config.Dir is set, resolving relative paths against it.
+func TestRunWithDir(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"package.json": "{\n  \"version\": \"1.2.3\"\n}\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	if wd, _ := os.Getwd(); wd == tmpDir {
+		t.Fatal("test must not run from the repository directory")
+	}
+
+	meta, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+		BumpFiles:   []string{"package.json"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.NewVersion != "1.3.0" {
+		t.Errorf("NewVersion = %s, want 1.3.0", meta.NewVersion)
+	}
+	if tag := gitT(t, tmpDir, "describe", "--tags", "--exact-match"); tag != "v1.3.0" {
+		t.Errorf("tag = %q, want v1.3.0", tag)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree after the release, got:\n%s", status)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "package.json")); !strings.Contains(string(data), `"1.3.0"`) {
+		t.Errorf("package.json not bumped:\n%s", data)
+	}
+}
+
+// TestRunConcurrentDirs verifies that Runs against two repositories can proceed
+// at the same time without changing the process working directory.
+func TestRunConcurrentDirs(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := []struct {
+		dir, arg, want string
+	}{
+		{initTestRepo(t), "minor", "1.3.0"},
+		{initTestRepo(t), "major", "2.0.0"},
+	}
+	for _, r := range repos {
+		writeFilesT(t, r.dir, map[string]string{
+			"go.mod":       "module example.com/foo\n\ngo 1.18\n",
+			"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+			"a/a.go":       "package a\n\nfunc A() {}\n",
+			"b/b.go":       "package b\n\nimport \"example.com/foo/a\"\n\nfunc B() { a.A() }\n",
+			"bump.sh":      "#!/bin/sh\necho \"$GOVERSION_NEW_VERSION\" > released.txt\n",
+			"released.txt": "\n",
+		})
+		if err := os.Chmod(filepath.Join(r.dir, "bump.sh"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		commitAllT(t, r.dir, "initial commit")
+	}
+
+	errs := make([]error, len(repos))
+	var wg sync.WaitGroup
+	for i, r := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = RunWithConfig(Config{
+				Dir:            r.dir,
+				VersionFile:    "version.go",
+				VersionArg:     r.arg,
+				ExtraFiles:     []string{"version.go", "released.txt"},
+				PostBumpScript: "./bump.sh",
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i, r := range repos {
+		if errs[i] != nil {
+			t.Fatalf("Run in %s failed: %v", r.dir, errs[i])
+		}
+		if tag := gitT(t, r.dir, "describe", "--tags", "--exact-match"); tag != "v"+r.want {
+			t.Errorf("tag in %s = %q, want v%s", r.dir, tag, r.want)
+		}
+		if data, _ := os.ReadFile(filepath.Join(r.dir, "released.txt")); strings.TrimSpace(string(data)) != r.want {
+			t.Errorf("post-bump script output in %s = %q, want %s", r.dir, data, r.want)
+		}
+		if status := gitT(t, r.dir, "status", "--porcelain"); status != "" {
+			t.Errorf("expected a clean tree in %s, got:\n%s", r.dir, status)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(repos[1].dir, "b", "b.go")); !strings.Contains(string(data), "example.com/foo/v2/a") {
+		t.Errorf("major bump did not rewrite imports:\n%s", data)
+	}
+	if got, _ := os.Getwd(); got != wd {
+		t.Errorf("working directory changed from %s to %s", wd, got)
+	}
+}
+
+// TestRunFrom verifies that From overrides the version file's value as the base
+// of the bump, while the result is still written and tagged.
+func TestRunFrom(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	t.Chdir(tmpDir)
+
+	if _, err := RunWithConfig(Config{VersionFile: versionFile, VersionArg: "minor", From: "1.5"}); err == nil {
+		t.Error("expected an invalid From version to be rejected")
+	}
+
+	meta, err := RunWithConfig(Config{
+		VersionFile: versionFile,
+		VersionArg:  "minor",
+		ExtraFiles:  []string{versionFile},
+		From:        "v1.5.0",
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.OldVersion != "1.5.0" || meta.NewVersion != "1.6.0" {
+		t.Errorf("bumped %s -> %s, want 1.5.0 -> 1.6.0", meta.OldVersion, meta.NewVersion)
+	}
+	if v, _ := readCurrentVersion(versionFile); v != "1.6.0" {
+		t.Errorf("version file = %s, want 1.6.0", v)
+	}
+	if tag := gitT(t, tmpDir, "describe", "--tags", "--exact-match"); tag != "v1.6.0" {
+		t.Errorf("tag = %q, want v1.6.0", tag)
+	}
+}
+
+// TestRunBumpAllFields verifies that files listed in BumpAllFields have every main
+// version field bumped and are committed, while BumpFiles only get their first.
+func TestRunBumpAllFields(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":       "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"chart/Chart.yaml": "name: tool\nversion: 1.2.3\nappVersion: 1.2.3\n",
+		"other/Chart.yaml": "name: other\nversion: 1.2.3\nappVersion: 1.2.3\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	_, err := RunWithConfig(Config{
+		Dir:           tmpDir,
+		VersionFile:   "version.go",
+		VersionArg:    "patch",
+		ExtraFiles:    []string{"version.go"},
+		BumpFiles:     []string{"other/Chart.yaml"},
+		BumpAllFields: []string{"chart/Chart.yaml"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for file, want := range map[string]string{
+		"chart/Chart.yaml": "name: tool\nversion: 1.2.4\nappVersion: 1.2.4\n",
+		"other/Chart.yaml": "name: other\nversion: 1.2.4\nappVersion: 1.2.3\n",
+	} {
+		if got, _ := os.ReadFile(filepath.Join(tmpDir, file)); string(got) != want {
+			t.Errorf("%s =\n%s\nwant:\n%s", file, got, want)
+		}
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected all bumped files to be committed, got:\n%s", status)
+	}
+}
+
+// TestTagPrefixRoundTrip verifies that a custom tag prefix is used when tagging and
+// stripped again when the version is read back with from-git.
+func TestTagPrefixRoundTrip(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := Config{Dir: tmpDir, VersionFile: versionFile, ExtraFiles: []string{versionFile}, TagPrefix: "release-"}
+
+	cfg.VersionArg = "1.3.0"
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.Tag != "release-1.3.0" {
+		t.Errorf("meta.Tag = %q, want release-1.3.0", meta.Tag)
+	}
+	if tags := gitT(t, tmpDir, "tag"); tags != "release-1.3.0" {
+		t.Errorf("tags = %q, want release-1.3.0", tags)
+	}
+
+	// Let the version file drift from the tag, then restore it from git.
+	if err := writeVersionFile(versionFile, "1.2.9"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "drift")
+
+	cfg.VersionArg = "from-git"
+	meta, err = RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("from-git Run failed: %v", err)
+	}
+	if meta.NewVersion != "1.3.0" {
+		t.Errorf("from-git NewVersion = %q, want 1.3.0", meta.NewVersion)
+	}
+	if v, _ := readCurrentVersion(versionFile); v != "1.3.0" {
+		t.Errorf("version file = %s, want 1.3.0", v)
+	}
+	if tags := gitT(t, tmpDir, "tag"); tags != "release-1.3.0" {
+		t.Errorf("from-git should not create tags, got %q", tags)
+	}
+}
+
+// TestModFileSelectsModule verifies that ModFile picks which of several go.mod
+// files a major bump updates, instead of the nearest one above the version file.
+func TestModFileSelectsModule(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"go.mod":              "module example.com/root\n\ngo 1.24\n",
+		"version.go":          "package root\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"sub/go.mod":          "module example.com/sub\n\ngo 1.24\n",
+		"sub/lib/lib.go":      "package lib\n",
+		"sub/cmd/sub/main.go": "package main\n\nimport _ \"example.com/sub/lib\"\n\nfunc main() {}\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "major",
+		ExtraFiles:  []string{"version.go"},
+		ModFile:     "sub/go.mod",
+	}
+
+	meta, err := DryRunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if !slices.Contains(meta.UpdatedFiles, filepath.Join(tmpDir, "sub", "go.mod")) {
+		t.Errorf("dry run should list sub/go.mod, got %v", meta.UpdatedFiles)
+	}
+
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "sub", "go.mod")); !strings.Contains(string(data), "module example.com/sub/v2") {
+		t.Errorf("sub/go.mod not updated:\n%s", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "go.mod")); !strings.Contains(string(data), "module example.com/root\n") {
+		t.Errorf("root go.mod should be untouched:\n%s", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "sub", "cmd", "sub", "main.go")); !strings.Contains(string(data), `"example.com/sub/v2/lib"`) {
+		t.Errorf("self-import not rewritten:\n%s", data)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree after the release, got:\n%s", status)
+	}
+
+	cfg.ModFile = "missing/go.mod"
+	if _, err := DryRunWithConfig(cfg); err == nil {
+		t.Error("expected an error for a missing mod file")
+	}
+}
+
+// TestModTidy verifies that ModTidy runs go mod tidy in the module after a
+// major bump and commits the go.sum it writes, and that without the go command
+// it is skipped with ModTidy but refused up front with ModTidyRequired. A stub
+// go command stands in for the toolchain.
+func TestModTidy(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not available")
+	}
+	newRepo := func(t *testing.T) (string, Config) {
+		tmpDir := initTestRepo(t)
+		writeFilesT(t, tmpDir, map[string]string{
+			"go.mod":     "module example.com/foo\n\ngo 1.24\n",
+			"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		})
+		commitAllT(t, tmpDir, "initial commit")
+		return tmpDir, Config{Dir: tmpDir, VersionFile: "version.go", VersionArg: "major", ExtraFiles: []string{"version.go"}, ModTidy: true}
+	}
+	// binDir returns a directory holding git and, if stub is set, a go command
+	// that records its arguments in go.sum.
+	binDir := func(t *testing.T, stub bool) string {
+		dir := t.TempDir()
+		if err := os.Symlink(gitPath, filepath.Join(dir, "git")); err != nil {
+			t.Fatal(err)
+		}
+		if stub {
+			writeFilesT(t, dir, map[string]string{"go": "#!/bin/sh\necho \"$*\" > go.sum\n"})
+			if err := os.Chmod(filepath.Join(dir, "go"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+
+	tmpDir, cfg := newRepo(t)
+	t.Setenv("PATH", binDir(t, true))
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !slices.Contains(meta.UpdatedFiles, filepath.Join(tmpDir, "go.sum")) {
+		t.Errorf("UpdatedFiles = %v, want go.sum", meta.UpdatedFiles)
+	}
+	if got := gitT(t, tmpDir, "show", "HEAD:go.sum"); got != "mod tidy" {
+		t.Errorf("committed go.sum = %q, want the output of go mod tidy", got)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree, got:\n%s", status)
+	}
+
+	t.Setenv("PATH", binDir(t, false))
+	tmpDir, cfg = newRepo(t)
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run without go failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "go.sum")); !os.IsNotExist(err) {
+		t.Errorf("go.sum should not exist without go, stat error: %v", err)
+	}
+
+	tmpDir, cfg = newRepo(t)
+	cfg.ModTidyRequired = true
+	if _, err := RunWithConfig(cfg); err == nil || !strings.Contains(err.Error(), "needs the go command") {
+		t.Fatalf("Run error = %v, want the go command to be required", err)
+	}
+	if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+		t.Errorf("version file = %q, want it untouched", v)
+	}
+}
+
+// TestBuildCheck verifies that BuildCheck refuses to commit a major bump that leaves
+// the module unbuildable, here because a file still imports the old module path,
+// and restores the files the bump wrote.
+func TestBuildCheck(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain is not available")
+	}
+	tests := []struct {
+		name    string
+		missed  bool
+		wantErr bool
+	}{
+		{name: "builds", missed: false, wantErr: false},
+		{name: "missed import", missed: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			// The post-bump script stands in for anything that leaves an old import behind.
+			script := "#!/bin/sh\n"
+			if tt.missed {
+				script += "printf 'package main\\n\\nimport _ \"example.com/foo/lib\"\\n' > cmd/foo/missed.go\n"
+			}
+			writeFilesT(t, tmpDir, map[string]string{
+				"go.mod":          "module example.com/foo\n\ngo 1.24\n",
+				"version.go":      "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+				"lib/lib.go":      "package lib\n",
+				"cmd/foo/main.go": "package main\n\nimport _ \"example.com/foo/lib\"\n\nfunc main() {}\n",
+				"post-bump.sh":    script,
+			})
+			if err := os.Chmod(filepath.Join(tmpDir, "post-bump.sh"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, tmpDir, "initial commit")
+
+			_, err := RunWithConfig(Config{
+				Dir:            tmpDir,
+				VersionFile:    "version.go",
+				VersionArg:     "major",
+				ExtraFiles:     []string{"version.go"},
+				PostBumpScript: "post-bump.sh",
+				BuildCheck:     true,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run error = %v, wantErr %v", err, tt.wantErr)
+			}
+			tags := gitT(t, tmpDir, "tag")
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "build check failed") {
+					t.Errorf("error = %v, want a build check failure", err)
+				}
+				if tags != "" {
+					t.Errorf("no tag should be created when the build check fails, got %q", tags)
+				}
+				if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+					t.Errorf("version.go holds %q after the failed build check, want 1.2.3 restored", v)
+				}
+				if data, _ := os.ReadFile(filepath.Join(tmpDir, "go.mod")); string(data) != "module example.com/foo\n\ngo 1.24\n" {
+					t.Errorf("go.mod after the failed build check = %q, want it restored", data)
+				}
+				if status := gitT(t, tmpDir, "status", "--porcelain", "--untracked-files=no"); status != "" {
+					t.Errorf("tracked files should be unchanged after the failed build check, got:\n%s", status)
+				}
+			} else if tags != "v2.0.0" {
+				t.Errorf("tags = %q, want v2.0.0", tags)
+			}
+		})
+	}
+}
+
+// TestVersionFromLatestTagPrefix verifies that from-git strips exactly the configured
+// tag prefix and rejects a latest tag that does not match it.
+func TestVersionFromLatestTagPrefix(t *testing.T) {
+	tests := []struct {
+		tag     string
+		prefix  string
+		want    string
+		wantErr string
+	}{
+		{tag: "v1.2.3-rc.1", prefix: "v", want: "1.2.3-rc.1"},
+		{tag: "release-1.2.3", prefix: "release-", want: "1.2.3"},
+		{tag: "1.2.3", prefix: "v", wantErr: "does not start with the tag prefix"},
+		{tag: "version-1.2.3", prefix: "v", wantErr: "not \"v\" followed by a semantic version"},
+		{tag: "v1.2.3", prefix: "release-", wantErr: "does not start with the tag prefix"},
+		{tag: "v1.2", prefix: "v", wantErr: "not \"v\" followed by a semantic version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{"README": "readme\n"})
+			commitAllT(t, tmpDir, "initial commit")
+			gitT(t, tmpDir, "tag", tt.tag)
+
+			got, err := versionFromLatestTag(ExecGit{}, tmpDir, tt.prefix, DescribeOptions{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("version = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateVersionFile verifies that a version file is checked without being
+// created or read from git.
+func TestValidateVersionFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		varName string
+		want    string
+		wantErr string
+	}{
+		{name: "valid", file: "version.go", content: "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n", want: "1.2.3"},
+		{name: "custom const", file: "version.go", content: "package foo\n\nconst AppVersion = `2.0.0-rc.1`\n", varName: "AppVersion", want: "2.0.0-rc.1"},
+		{name: "missing constant", file: "version.go", content: "package foo\n\nvar Name = \"foo\"\n", wantErr: "no package-level Version declaration"},
+		{name: "non-literal", file: "version.go", content: "package foo\n\nvar Version = build()\n\nfunc build() string { return \"\" }\n", wantErr: "must be assigned a string literal"},
+		{name: "invalid semver", file: "version.go", content: "package foo\n\nvar Version = \"1.2\"\n", wantErr: "not a valid semantic version"},
+		{name: "plain text", file: "VERSION", content: "1.4.0\n", want: "1.4.0"},
+		{name: "missing file", file: "", wantErr: "failed to read version file"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "version.go")
+			if tc.file != "" {
+				path = filepath.Join(dir, tc.file)
+				if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := ValidateVersionFile(path, tc.varName)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if got != tc.want {
+				t.Errorf("version = %q, want %q", got, tc.want)
+			}
+			if tc.file == "" {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("ValidateVersionFile must not create %s", path)
+				}
+			}
+		})
+	}
+}
+
+// TestTagPatternFiltersNamespaces verifies that TagPattern limits from-git to the
+// tags of one component in a repository with several tag namespaces.
+func TestTagPatternFiltersNamespaces(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"api/version.go": "package api\n\nvar (\n\tVersion = \"1.0.0\"\n)\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "api/v1.2.0")
+	writeFilesT(t, tmpDir, map[string]string{"cli/main.go": "package main\n"})
+	commitAllT(t, tmpDir, "add cli")
+	gitT(t, tmpDir, "tag", "cli/v3.0.0")
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "api/version.go",
+		VersionArg:  "from-git",
+		ExtraFiles:  []string{"api/version.go"},
+		TagPrefix:   "api/v",
+		TagSort:     "topo",
+	}
+	if _, err := DryRunWithConfig(cfg); err == nil || !strings.Contains(err.Error(), `"cli/v3.0.0"`) {
+		t.Fatalf("without a pattern the nearest cli tag should be rejected, got %v", err)
+	}
+
+	cfg.TagPattern = "api/v*"
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.NewVersion != "1.2.0" {
+		t.Errorf("NewVersion = %q, want 1.2.0", meta.NewVersion)
+	}
+	if v, _ := readCurrentVersion(filepath.Join(tmpDir, "api", "version.go")); v != "1.2.0" {
+		t.Errorf("version file = %q, want 1.2.0", v)
+	}
+}
+
+// TestScope verifies that two modules of a monorepo are released independently:
+// changes outside the scope do not block a release, and each module's tags are
+// prefixed with its directory.
+func TestScope(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"moduleA/go.mod":     "module example.com/repo/moduleA\n\ngo 1.21\n",
+		"moduleA/version.go": "package a\n\nvar (\n\tVersion = \"1.0.0\"\n)\n",
+		"moduleB/go.mod":     "module example.com/repo/moduleB\n\ngo 1.21\n",
+		"moduleB/version.go": "package b\n\nvar (\n\tVersion = \"1.0.0\"\n)\n",
+		"moduleB/b.go":       "package b\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "moduleA/v1.0.0")
+	gitT(t, tmpDir, "tag", "moduleB/v1.0.0")
+	writeFilesT(t, tmpDir, map[string]string{
+		"moduleB/b.go":    "package b\n\n// Work in progress.\n",
+		"moduleB/wip.txt": "notes\n",
+	})
+
+	cfgA := Config{
+		Dir:         tmpDir,
+		VersionFile: "moduleA/version.go",
+		VersionArg:  "patch",
+		ExtraFiles:  []string{"moduleA/version.go"},
+	}
+	if _, err := RunWithConfig(cfgA); err == nil {
+		t.Fatal("expected changes in moduleB to block an unscoped release")
+	}
+	cfgA.Scope = "moduleA"
+	meta, err := RunWithConfig(cfgA)
+	if err != nil {
+		t.Fatalf("scoped release of moduleA failed: %v", err)
+	}
+	if meta.NewVersion != "1.0.1" {
+		t.Errorf("NewVersion = %q, want 1.0.1", meta.NewVersion)
+	}
+	if got := gitT(t, tmpDir, "tag", "--points-at", "HEAD"); got != "moduleA/v1.0.1" {
+		t.Errorf("tags at HEAD = %q, want moduleA/v1.0.1", got)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); !strings.Contains(status, "moduleB/b.go") || !strings.Contains(status, "moduleB/wip.txt") {
+		t.Errorf("changes in moduleB should be left uncommitted, got:\n%s", status)
+	}
+
+	writeFilesT(t, tmpDir, map[string]string{"moduleA/a.go": "package a\n"})
+	if _, err := RunWithConfig(cfgA); err == nil || !strings.Contains(err.Error(), "a.go") {
+		t.Errorf("expected changes inside the scope to block the release, got %v", err)
+	}
+	os.Remove(filepath.Join(tmpDir, "moduleA", "a.go"))
+
+	commitAllT(t, tmpDir, "moduleB work")
+	meta, err = RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "moduleB/version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"moduleB/version.go"},
+		Scope:       "moduleB",
+	})
+	if err != nil {
+		t.Fatalf("scoped release of moduleB failed: %v", err)
+	}
+	if meta.NewVersion != "1.1.0" {
+		t.Errorf("NewVersion = %q, want 1.1.0", meta.NewVersion)
+	}
+	tags := strings.Fields(gitT(t, tmpDir, "tag", "--list"))
+	want := []string{"moduleA/v1.0.0", "moduleA/v1.0.1", "moduleB/v1.0.0", "moduleB/v1.1.0"}
+	if !slices.Equal(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+
+	_, err = DryRunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "moduleA/version.go",
+		VersionArg:  "from-git",
+		Scope:       "moduleA",
+	})
+	if err == nil || !strings.Contains(err.Error(), "(1.0.1) is the same as the current version") {
+		t.Errorf("from-git should read moduleA's latest tag, v1.0.1, got %v", err)
+	}
+}
+
+// TestBranchTagsOnly verifies that BranchTagsOnly ignores a tag that reached the
+// current branch only through a merge from another branch.
+func TestBranchTagsOnly(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"0.9.0\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	gitT(t, tmpDir, "tag", "v1.0.0")
+	gitT(t, tmpDir, "checkout", "-q", "-b", "feature")
+	writeFilesT(t, tmpDir, map[string]string{"feature.go": "package foo\n"})
+	commitAllT(t, tmpDir, "add feature")
+	gitT(t, tmpDir, "checkout", "-q", "-")
+	writeFilesT(t, tmpDir, map[string]string{"main.go": "package foo\n"})
+	commitAllT(t, tmpDir, "add main")
+	gitT(t, tmpDir, "tag", "v1.1.0")
+	gitT(t, tmpDir, "checkout", "-q", "feature")
+	gitT(t, tmpDir, "merge", "-q", "--no-edit", "--no-ff", "v1.1.0")
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "from-git",
+		ExtraFiles:  []string{"version.go"},
+		TagSort:     "topo",
+	}
+	meta, err := DryRunWithConfig(cfg)
+	if err != nil || meta.NewVersion != "1.1.0" {
+		t.Fatalf("without BranchTagsOnly: NewVersion = %q (%v), want the merged 1.1.0", meta.NewVersion, err)
+	}
+	cfg.BranchTagsOnly = true
+	meta, err = DryRunWithConfig(cfg)
+	if err != nil || meta.NewVersion != "1.0.0" {
+		t.Errorf("with BranchTagsOnly: NewVersion = %q (%v), want 1.0.0", meta.NewVersion, err)
+	}
+}
+
+// TestUpdatedFilesOrder verifies that dry and real runs report changed files in
+// the same stable order: version file, go.mod, sorted imports, sorted bump files.
+func TestUpdatedFilesOrder(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"go.mod":       "module example.com/foo\n\ngo 1.24\n",
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"lib/lib.go":   "package lib\n",
+		"z/z.go":       "package z\n\nimport _ \"example.com/foo/lib\"\n",
+		"a/a.go":       "package a\n\nimport _ \"example.com/foo/lib\"\n",
+		"package.json": "{\n  \"version\": \"1.2.3\"\n}\n",
+		"Cargo.toml":   "[package]\nversion = \"1.2.3\"\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "major",
+		ExtraFiles:  []string{"version.go"},
+		BumpFiles:   []string{"package.json", "Cargo.toml"},
+	}
+	var want []string
+	for _, f := range []string{"version.go", "go.mod", "a/a.go", "z/z.go", "Cargo.toml", "package.json"} {
+		want = append(want, filepath.Join(tmpDir, f))
+	}
+
+	dry, err := DryRunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if !reflect.DeepEqual(dry.UpdatedFiles, want) {
+		t.Errorf("dry run UpdatedFiles = %v, want %v", dry.UpdatedFiles, want)
+	}
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !reflect.DeepEqual(meta.UpdatedFiles, want) {
+		t.Errorf("UpdatedFiles = %v, want %v", meta.UpdatedFiles, want)
+	}
+}
+
+// TestExplicitPrereleaseLeadingZeros verifies that explicit versions with a numeric
+// prerelease identifier that has a leading zero are rejected with a clear error.
+func TestExplicitPrereleaseLeadingZeros(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "1.2.3-0", wantErr: false},
+		{version: "1.2.3-rc.10", wantErr: false},
+		{version: "1.2.3-0a", wantErr: false},
+		{version: "1.2.3-00", wantErr: true},
+		{version: "1.2.3-01.beta", wantErr: true},
+		{version: "v1.2.3-rc.01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			_, _, err := computeNewVersion(Config{VersionArg: tt.version}, "1.0.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("computeNewVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "leading zeros") {
+				t.Errorf("error = %v, want it to mention leading zeros", err)
+			}
+		})
+	}
+}
+
+// TestSnapshot verifies that a snapshot version carries the short HEAD SHA, is
+// written without being committed by default, and is never tagged.
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		base    string
+		commit  bool
+	}{
+		{name: "final", current: "1.2.3", base: "1.2.4", commit: false},
+		{name: "prerelease", current: "2.0.0-rc.1", base: "2.0.0", commit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			versionFile := filepath.Join(tmpDir, "version.go")
+			if err := writeVersionFile(versionFile, tt.current); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, tmpDir, "initial commit")
+			sha := gitT(t, tmpDir, "rev-parse", "--short", "HEAD")
+			if strings.Trim(sha, "0123456789") == "" {
+				sha = "g" + sha
+			}
+
+			meta, err := RunWithConfig(Config{
+				Dir:            tmpDir,
+				VersionFile:    versionFile,
+				VersionArg:     "snapshot",
+				ExtraFiles:     []string{versionFile},
+				CommitSnapshot: tt.commit,
+			})
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			want := tt.base + "-snapshot." + sha
+			if meta.NewVersion != want {
+				t.Errorf("NewVersion = %q, want %q", meta.NewVersion, want)
+			}
+			if meta.Tag != "" {
+				t.Errorf("Tag = %q, want none for a snapshot", meta.Tag)
+			}
+			if v, _ := readCurrentVersion(versionFile); v != want {
+				t.Errorf("version file = %q, want %q", v, want)
+			}
+			if tags := gitT(t, tmpDir, "tag"); tags != "" {
+				t.Errorf("a snapshot must not be tagged, got %q", tags)
+			}
+			status := gitT(t, tmpDir, "status", "--porcelain")
+			if tt.commit && status != "" {
+				t.Errorf("expected the snapshot to be committed, got status:\n%s", status)
+			}
+			if !tt.commit && status == "" {
+				t.Error("expected the snapshot to be left uncommitted")
+			}
+		})
+	}
+}
+
+// TestBackup verifies that Backup leaves a ".bak" copy of each modified file
+// holding its pre-bump content, and that the backups are not committed.
+func TestBackup(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	files := map[string]string{
+		"go.mod":          "module example.com/foo\n\ngo 1.24\n",
+		"version.go":      "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"lib/lib.go":      "package lib\n",
+		"cmd/foo/main.go": "package main\n\nimport _ \"example.com/foo/lib\"\n\nfunc main() {}\n",
+		"package.json":    "{\n  \"version\": \"1.2.3\"\n}\n",
+	}
+	writeFilesT(t, tmpDir, files)
+	commitAllT(t, tmpDir, "initial commit")
+
+	if _, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "major",
+		ExtraFiles:  []string{"version.go"},
+		BumpFiles:   []string{"package.json"},
+		Backup:      true,
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for _, name := range []string{"go.mod", "version.go", "cmd/foo/main.go", "package.json"} {
+		data, err := os.ReadFile(filepath.Join(tmpDir, name+".bak"))
+		if err != nil {
+			t.Errorf("missing backup of %s: %v", name, err)
+			continue
+		}
+		if string(data) != files[name] {
+			t.Errorf("backup of %s = %q, want the pre-bump content %q", name, data, files[name])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "lib", "lib.go.bak")); !os.IsNotExist(err) {
+		t.Error("unmodified lib/lib.go should not be backed up")
+	}
+	if committed := gitT(t, tmpDir, "show", "--name-only", "--format=", "HEAD"); strings.Contains(committed, ".bak") {
+		t.Errorf("backups must not be committed, got:\n%s", committed)
+	}
+}
+
+// TestMirrorFiles verifies that a mirror file's Version literal is set to the new
+// version without disturbing the rest of the file, and that it is committed.
+func TestMirrorFiles(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	mirror := "package main\n\n// Version mirrors the root version.\nconst Version = `1.2.3` // keep in sync\n\nvar Name = \"tool\"\n"
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":          "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"cmd/tool/version.go": mirror,
+		"cmd/bad/version.go":  "package main\n\nvar Name = \"bad\"\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+		MirrorFiles: []string{"cmd/bad/version.go"},
+	}
+	if _, err := RunWithConfig(cfg); err == nil || !strings.Contains(err.Error(), "no package-level Version") {
+		t.Fatalf("expected a mirror without Version to be rejected, got %v", err)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Fatalf("a rejected mirror must leave the tree untouched, got:\n%s", status)
+	}
+
+	cfg.MirrorFiles = []string{"cmd/tool/version.go"}
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := strings.Replace(mirror, "`1.2.3`", "`1.3.0`", 1)
+	if data, _ := os.ReadFile(filepath.Join(tmpDir, "cmd", "tool", "version.go")); string(data) != want {
+		t.Errorf("mirror file = %q, want %q", data, want)
+	}
+	if !slices.Contains(meta.UpdatedFiles, filepath.Join(tmpDir, "cmd", "tool", "version.go")) {
+		t.Errorf("UpdatedFiles = %v, want it to include the mirror file", meta.UpdatedFiles)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected the mirror file to be committed, got:\n%s", status)
+	}
+}
+
+// TestMissingExtraFile verifies that a missing extra file refuses the release
+// before anything is written, unless the post-bump script creates it or
+// AllowMissingFiles is set.
+func TestMissingExtraFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		allow      bool
+		wantErr    bool
+		wantCommit bool
+	}{
+		{name: "missing", wantErr: true},
+		{name: "created by post-bump script", script: "#!/bin/sh\necho notes > NOTES.md\n", wantCommit: true},
+		{name: "not created by post-bump script", script: "#!/bin/sh\ntrue\n", wantErr: true},
+		{name: "allowed", allow: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{
+				"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+				"bump.sh":    tt.script,
+			})
+			if err := os.Chmod(filepath.Join(tmpDir, "bump.sh"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, tmpDir, "initial commit")
+
+			cfg := Config{
+				Dir:               tmpDir,
+				VersionFile:       "version.go",
+				VersionArg:        "patch",
+				ExtraFiles:        []string{"version.go", "NOTES.md"},
+				AllowMissingFiles: tt.allow,
+			}
+			if tt.script != "" {
+				cfg.PostBumpScript = "bump.sh"
+			}
+			_, err := RunWithConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "NOTES.md not found") {
+					t.Errorf("error = %v, want it to name the missing file", err)
+				}
+				if tags := gitT(t, tmpDir, "tag"); tags != "" {
+					t.Errorf("no tag should be created, got %q", tags)
+				}
+				if tt.script == "" {
+					if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+						t.Errorf("version file = %q, want it untouched", v)
+					}
+				}
+				return
+			}
+			committed := gitT(t, tmpDir, "show", "--name-only", "--format=", "HEAD")
+			if got := strings.Contains(committed, "NOTES.md"); got != tt.wantCommit {
+				t.Errorf("NOTES.md committed = %v, want %v; commit has:\n%s", got, tt.wantCommit, committed)
+			}
+		})
+	}
+}
+
+// TestCleanupOnTagFailure verifies that a release commit whose tag cannot be
+// created is undone, with its changes left staged, when CleanupOnTagFailure is set.
+func TestCleanupOnTagFailure(t *testing.T) {
+	for _, cleanup := range []bool{false, true} {
+		t.Run(fmt.Sprintf("cleanup=%v", cleanup), func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{
+				"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+				// Take the tag after the pre-flight check so that tagging fails.
+				"bump.sh": "#!/bin/sh\ngit tag v1.2.4\n",
+			})
+			if err := os.Chmod(filepath.Join(tmpDir, "bump.sh"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, tmpDir, "initial commit")
+			before := gitT(t, tmpDir, "rev-parse", "HEAD")
+
+			_, err := RunWithConfig(Config{
+				Dir:                 tmpDir,
+				VersionFile:         "version.go",
+				VersionArg:          "patch",
+				ExtraFiles:          []string{"version.go"},
+				PostBumpScript:      "bump.sh",
+				CleanupOnTagFailure: cleanup,
+			})
+			if err == nil {
+				t.Fatal("expected the tag step to fail")
+			}
+			head := gitT(t, tmpDir, "rev-parse", "HEAD")
+			if cleanup {
+				if head != before {
+					t.Errorf("HEAD = %s, want the release commit undone (%s)", head, before)
+				}
+				if !strings.Contains(err.Error(), "release commit was undone") {
+					t.Errorf("error = %v, want it to report the undone commit", err)
+				}
+				if staged := gitT(t, tmpDir, "diff", "--cached", "--name-only"); staged != "version.go" {
+					t.Errorf("staged files = %q, want version.go", staged)
+				}
+			} else if head == before {
+				t.Error("without cleanup the release commit should remain")
+			}
+		})
+	}
+}
+
+// TestAllowEmpty verifies that AllowEmpty creates and tags a release commit even
+// when no file changed, which git refuses by default.
+func TestAllowEmpty(t *testing.T) {
+	for _, allowEmpty := range []bool{false, true} {
+		t.Run(fmt.Sprintf("allowEmpty=%v", allowEmpty), func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{
+				"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+				// Discard the bump so that the release commit has no changes.
+				"bump.sh": "#!/bin/sh\ngit checkout -- version.go\n",
+			})
+			if err := os.Chmod(filepath.Join(tmpDir, "bump.sh"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			commitAllT(t, tmpDir, "initial commit")
+
+			_, err := RunWithConfig(Config{
+				Dir:            tmpDir,
+				VersionFile:    "version.go",
+				VersionArg:     "major",
+				ExtraFiles:     []string{"version.go"},
+				PostBumpScript: "bump.sh",
+				AllowEmpty:     allowEmpty,
+			})
+			if !allowEmpty {
+				if err == nil {
+					t.Fatal("expected git to refuse an empty commit")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if changed := gitT(t, tmpDir, "show", "--name-only", "--format=", "HEAD"); changed != "" {
+				t.Errorf("expected an empty release commit, got changes:\n%s", changed)
+			}
+			if subject := gitT(t, tmpDir, "log", "-1", "--format=%s"); subject != "2.0.0" {
+				t.Errorf("commit subject = %q, want 2.0.0", subject)
+			}
+			if tagged := gitT(t, tmpDir, "rev-parse", "v2.0.0^{commit}"); tagged != gitT(t, tmpDir, "rev-parse", "HEAD") {
+				t.Error("tag v2.0.0 should point at the empty release commit")
+			}
+		})
+	}
+}
+
+// TestTimings verifies that Timings records the duration of each phase that ran,
+// and nothing is recorded without it.
+func TestTimings(t *testing.T) {
+	for _, timings := range []bool{false, true} {
+		t.Run(fmt.Sprintf("timings=%v", timings), func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{
+				"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+			})
+			commitAllT(t, tmpDir, "initial commit")
+
+			meta, err := RunWithConfig(Config{
+				Dir:         tmpDir,
+				VersionFile: "version.go",
+				VersionArg:  "patch",
+				ExtraFiles:  []string{"version.go"},
+				Timings:     timings,
+			})
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if !timings {
+				if meta.Timings != nil {
+					t.Errorf("Timings = %v, want nil", meta.Timings)
+				}
+				return
+			}
+			for _, phase := range []string{"preflight", "version-files", "bump-files", "git", "total"} {
+				if _, ok := meta.Timings[phase]; !ok {
+					t.Errorf("Timings has no %q phase: %v", phase, meta.Timings)
+				}
+			}
+			for _, phase := range []string{"backup", "go.mod", "imports", "changelog", "post-bump", "build-check"} {
+				if _, ok := meta.Timings[phase]; ok {
+					t.Errorf("Timings has %q, a phase that did not run", phase)
+				}
+			}
+			if meta.Timings["total"] < meta.Timings["git"] {
+				t.Errorf("total %v is less than the git phase %v", meta.Timings["total"], meta.Timings["git"])
+			}
+		})
+	}
+}
+
+// TestSelfImportsConcurrent verifies that scanning, previewing, and rewriting
+// self-imports concurrently gives the same result as doing it sequentially.
+func TestSelfImportsConcurrent(t *testing.T) {
+	defer func(n int) { selfImportWorkers = n }(selfImportWorkers)
+
+	type result struct {
+		scanned, modified []string
+		preview           map[string][]ImportChange
+		files             map[string]string
+	}
+	run := func(workers int) result {
+		selfImportWorkers = workers
+		dir := t.TempDir()
+		writeSyntheticModule(t, dir, 200)
+		var r result
+		var err error
+		if r.scanned, err = scanSelfImports(dir, "example.com/foo", "example.com/foo/v2"); err != nil {
+			t.Fatalf("scanSelfImports failed: %v", err)
+		}
+		if r.preview, err = PreviewSelfImports(dir, "example.com/foo", "example.com/foo/v2"); err != nil {
+			t.Fatalf("PreviewSelfImports failed: %v", err)
+		}
+		if r.modified, err = updateSelfImports(dir, "example.com/foo", "example.com/foo/v2"); err != nil {
+			t.Fatalf("updateSelfImports failed: %v", err)
+		}
+		// Make every path relative so that the two runs compare equal.
+		rel := func(path string) string {
+			p, _ := filepath.Rel(dir, path)
+			return filepath.ToSlash(p)
+		}
+		for i := range r.scanned {
+			r.scanned[i] = rel(r.scanned[i])
+		}
+		for i := range r.modified {
+			r.modified[i] = rel(r.modified[i])
+		}
+		preview := make(map[string][]ImportChange)
+		for path, changes := range r.preview {
+			preview[rel(path)] = changes
+		}
+		r.preview = preview
+		r.files = make(map[string]string)
+		paths, err := goSourceFiles(dir, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.files[rel(path)] = string(data)
+		}
+		return r
+	}
+
+	sequential, concurrent := run(1), run(8)
+	if len(sequential.modified) != 298 {
+		t.Fatalf("sequential run modified %d files, want 298", len(sequential.modified))
+	}
+	if !slices.Equal(sequential.scanned, concurrent.scanned) {
+		t.Errorf("scanned files differ:\nsequential: %v\nconcurrent: %v", sequential.scanned, concurrent.scanned)
+	}
+	if !slices.Equal(sequential.modified, concurrent.modified) {
+		t.Errorf("modified files differ:\nsequential: %v\nconcurrent: %v", sequential.modified, concurrent.modified)
+	}
+	if !reflect.DeepEqual(sequential.preview, concurrent.preview) {
+		t.Error("previews differ between the sequential and concurrent runs")
+	}
+	if !reflect.DeepEqual(sequential.files, concurrent.files) {
+		t.Error("rewritten files differ between the sequential and concurrent runs")
+	}
+	if !strings.Contains(concurrent.files["p7/p7.go"], `prev "example.com/foo/v2/p6"`) {
+		t.Errorf("p7/p7.go not rewritten:\n%s", concurrent.files["p7/p7.go"])
+	}
+}
+
+// TestSelfImportsConcurrentError verifies that a file that fails to parse is
+// reported even when the other files are processed concurrently.
+func TestSelfImportsConcurrentError(t *testing.T) {
+	dir := t.TempDir()
+	writeSyntheticModule(t, dir, 50)
+	writeFilesT(t, dir, map[string]string{"p25/broken.go": "package p25\n\nimport (\n"})
+	if _, err := updateSelfImports(dir, "example.com/foo", "example.com/foo/v2"); err == nil || !strings.Contains(err.Error(), "broken.go") {
+		t.Fatalf("updateSelfImports error = %v, want a parse error for broken.go", err)
+	}
+}
+
+// BenchmarkUpdateSelfImports measures rewriting the self-imports of a module
+// with hundreds of files.
+func BenchmarkUpdateSelfImports(b *testing.B) {
+	for b.Loop() {
+		b.StopTimer()
+		dir := b.TempDir()
+		writeSyntheticModule(b, dir, 500)
+		b.StartTimer()
+		if _, err := updateSelfImports(dir, "example.com/foo", "example.com/foo/v2"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestUpdateSelfImportsMinimalDiff verifies that rewriting self-imports in a file
+// that is not gofmt'd changes only the import lines, leaving its formatting alone.
+func TestUpdateSelfImportsMinimalDiff(t *testing.T) {
+	dir := t.TempDir()
+	src := "package b\n" +
+		"import (\n" +
+		"    \"fmt\"\n" +
+		"  a   \"example.com/foo/pkg/a\"   // the a package\n" +
+		")\n" +
+		"import `example.com/foo/pkg/c`\n" +
+		"\n\n\n" +
+		"func B( )  {fmt.Println(a.A ,  c.C)}\n" +
+		"var   x=\"example.com/foo/pkg/a\"\n"
+	writeFilesT(t, dir, map[string]string{"b/b.go": src})
+
+	if _, err := updateSelfImports(dir, "example.com/foo", "example.com/foo/v2"); err != nil {
+		t.Fatalf("updateSelfImports failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "b", "b.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldLines, newLines := strings.Split(src, "\n"), strings.Split(string(got), "\n")
+	if len(oldLines) != len(newLines) {
+		t.Fatalf("line count changed from %d to %d:\n%s", len(oldLines), len(newLines), got)
+	}
+	var changed []int
+	for i := range oldLines {
+		if oldLines[i] != newLines[i] {
+			changed = append(changed, i+1)
+		}
+	}
+	if !slices.Equal(changed, []int{4, 6}) {
+		t.Errorf("changed lines = %v, want only the import lines [4 6]:\n%s", changed, got)
+	}
+	if want := "  a   \"example.com/foo/v2/pkg/a\"   // the a package"; newLines[3] != want {
+		t.Errorf("line 4 = %q, want %q", newLines[3], want)
+	}
+	if want := "import \"example.com/foo/v2/pkg/c\""; newLines[5] != want {
+		t.Errorf("line 6 = %q, want %q", newLines[5], want)
+	}
+}
+
+// TestKeepVPrefix verifies that KeepVPrefix writes the version file with a leading
+// "v", reads it back without one, and still tags with exactly one "v".
+func TestKeepVPrefix(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go": "package foo\n\nvar (\n\tVersion = \"v1.2.3\"\n)\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	for _, want := range []string{"1.2.4", "1.2.5"} {
+		meta, err := RunWithConfig(Config{
+			Dir:         tmpDir,
+			VersionFile: "version.go",
+			VersionArg:  "patch",
+			ExtraFiles:  []string{"version.go"},
+			KeepVPrefix: true,
+		})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if meta.NewVersion != want || meta.Tag != "v"+want {
+			t.Errorf("NewVersion = %q, Tag = %q, want %q and %q", meta.NewVersion, meta.Tag, want, "v"+want)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, "version.go"))
+		if !strings.Contains(string(data), `Version = "v`+want+`"`) {
+			t.Errorf("version file does not hold v%s:\n%s", want, data)
+		}
+	}
+	if got := gitT(t, tmpDir, "tag", "--list"); got != "v1.2.4\nv1.2.5" {
+		t.Errorf("tags = %q, want v1.2.4 and v1.2.5 without a doubled v", got)
+	}
+}
+
+// TestCleanIndexOnly verifies that CleanIndexOnly ignores unstaged changes and
+// untracked files, leaving them out of the release commit, but still refuses
+// staged changes to files that are not released.
+func TestCleanIndexOnly(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"NOTES.md":   "notes\n",
+		"README.md":  "readme\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	// Staged changes to a released file, and unstaged noise.
+	writeFilesT(t, tmpDir, map[string]string{"README.md": "readme for 1.2.4\n"})
+	gitT(t, tmpDir, "add", "README.md")
+	writeFilesT(t, tmpDir, map[string]string{
+		"NOTES.md":    "work in progress\n",
+		"scratch.txt": "untracked\n",
+	})
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "patch",
+		ExtraFiles:  []string{"version.go", "README.md"},
+	}
+	if _, err := RunWithConfig(cfg); !errors.Is(err, ErrDirtyWorkingTree) {
+		t.Fatalf("Run error = %v, want ErrDirtyWorkingTree without CleanIndexOnly", err)
+	}
+
+	cfg.CleanIndexOnly = true
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if files := gitT(t, tmpDir, "show", "--name-only", "--format=", "HEAD"); files != "README.md\nversion.go" {
+		t.Errorf("release commit holds %q, want only README.md and version.go", files)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "M NOTES.md\n?? scratch.txt" {
+		t.Errorf("status = %q, want the unstaged noise left in place", status)
+	}
+
+	gitT(t, tmpDir, "add", "NOTES.md")
+	if _, err := RunWithConfig(cfg); !errors.Is(err, ErrDirtyWorkingTree) {
+		t.Fatalf("Run error = %v, want ErrDirtyWorkingTree for a staged unrelated file", err)
+	}
+}
+
+// TestAllowDirty verifies that a repository with uncommitted changes to other
+// files is released only with AllowDirty, and that those changes stay out of
+// the release commit.
+func TestAllowDirty(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"main.go":    "package foo\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	writeFilesT(t, tmpDir, map[string]string{"main.go": "package foo\n\n// Work in progress.\n"})
+
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "patch",
+		ExtraFiles:  []string{"version.go"},
+	}
+	if _, err := RunWithConfig(cfg); !errors.Is(err, ErrDirtyWorkingTree) {
+		t.Fatalf("Run error = %v, want ErrDirtyWorkingTree without AllowDirty", err)
+	}
+
+	cfg.AllowDirty = true
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run with AllowDirty failed: %v", err)
+	}
+	if files := gitT(t, tmpDir, "show", "--name-only", "--format=", "HEAD"); files != "version.go" {
+		t.Errorf("release commit holds %q, want only version.go", files)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "M main.go" {
+		t.Errorf("status = %q, want main.go still modified", status)
+	}
+}
+
+// TestFilesFrom verifies that the files listed in FilesFrom are committed along
+// with ExtraFiles, ignoring blank lines and comments in the list.
+func TestFilesFrom(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":        "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"docs/install.md":   "Install 1.2.3\n",
+		"NOTES.txt":         "1.2.3\n",
+		"CHANGES.txt":       "1.2.3\n",
+		"release-files.txt": "# Files released with every version.\n\ndocs/install.md\n  NOTES.txt  \n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	writeFilesT(t, tmpDir, map[string]string{
+		"docs/install.md": "Install 1.2.4\n",
+		"NOTES.txt":       "1.2.4\n",
+		"CHANGES.txt":     "1.2.4\n",
+	})
+
+	if _, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "patch",
+		ExtraFiles:  []string{"version.go", "CHANGES.txt"},
+		FilesFrom:   "release-files.txt",
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	files := strings.Fields(gitT(t, tmpDir, "show", "--name-only", "--format=", "HEAD"))
+	want := []string{"CHANGES.txt", "NOTES.txt", "docs/install.md", "version.go"}
+	if !slices.Equal(files, want) {
+		t.Errorf("release commit holds %v, want %v", files, want)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree, got:\n%s", status)
+	}
+}
+
+// TestCommitTemplate verifies that a dry run renders the commit message template
+// exactly as a real run commits it, and that a broken template is rejected before
+// anything is written.
+func TestCommitTemplate(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+
+	cfg := Config{
+		Dir:            tmpDir,
+		VersionFile:    "version.go",
+		VersionArg:     "minor",
+		ExtraFiles:     []string{"version.go"},
+		CommitTemplate: "chore: release {{.Tag}}\n\nBumps {{.OldVersion}} to {{.NewVersion}} ({{.BumpType}}).\n",
+	}
+	const want = "chore: release v1.3.0\n\nBumps 1.2.3 to 1.3.0 (minor)."
+	meta, err := DryRunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if meta.CommitMessage != want {
+		t.Errorf("dry run CommitMessage = %q, want %q", meta.CommitMessage, want)
+	}
+	if meta.Tag != "v1.3.0" {
+		t.Errorf("dry run Tag = %q, want v1.3.0", meta.Tag)
+	}
+
+	broken := cfg
+	broken.CommitTemplate = "release {{.Nope}}"
+	if _, err := RunWithConfig(broken); err == nil || !strings.Contains(err.Error(), "invalid commit message template") {
+		t.Fatalf("Run error = %v, want an invalid template error", err)
+	}
+	if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+		t.Errorf("version file = %q, want it untouched", v)
+	}
+
+	if meta, err = RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := gitT(t, tmpDir, "log", "-1", "--format=%B"); got != want {
+		t.Errorf("commit message = %q, want %q", got, want)
+	}
+	if meta.CommitMessage != want {
+		t.Errorf("CommitMessage = %q, want %q", meta.CommitMessage, want)
+	}
+}
+
+// TestConventionalMessage verifies that ConventionalMessage commits the release as
+// chore(<scope>): <version>, and that it cannot be combined with a CommitTemplate.
+func TestConventionalMessage(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	cfg := Config{
+		Dir:                 tmpDir,
+		VersionFile:         "version.go",
+		VersionArg:          "minor",
+		ExtraFiles:          []string{"version.go"},
+		ConventionalMessage: true,
+	}
+
+	both := cfg
+	both.CommitTemplate = "Release {{.Tag}}"
+	if _, err := RunWithConfig(both); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("Run error = %v, want a conflict with CommitTemplate", err)
+	}
+
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := gitT(t, tmpDir, "log", "-1", "--format=%B"); got != "chore(release): 1.3.0" {
+		t.Errorf("commit message = %q, want chore(release): 1.3.0", got)
+	}
+
+	cfg.VersionArg = "patch"
+	cfg.ConventionalScope = "api"
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := gitT(t, tmpDir, "log", "-1", "--format=%s"); got != "chore(api): 1.3.1" {
+		t.Errorf("commit subject = %q, want chore(api): 1.3.1", got)
+	}
+}
+
+// TestParseTag verifies that ParseTag strips exactly the tag prefix, defaulting
+// to "v", and validates the rest as a semantic version.
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag, prefix string
+		want        string
+		wantErr     string
+	}{
+		{tag: "v1.2.3", prefix: "v", want: "1.2.3"},
+		{tag: "v2.0.0-rc.1+build.5", prefix: "", want: "2.0.0-rc.1+build.5"},
+		{tag: "api/v1.4.0", prefix: "api/v", want: "1.4.0"},
+		{tag: "cli/v1.4.0", prefix: "api/v", wantErr: "does not start with the tag prefix \"api/v\""},
+		{tag: "vv1.2.3", prefix: "v", wantErr: "not \"v\" followed by a semantic version"},
+		{tag: "v1.2", prefix: "", wantErr: "not \"v\" followed by a semantic version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := ParseTag(tt.tag, tt.prefix)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("version = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNoGofmt verifies that NoGofmt replaces only the Version literal of a
+// deliberately mis-formatted version file, while by default the file is rewritten.
+func TestNoGofmt(t *testing.T) {
+	const src = "// Code generated by gen; DO NOT EDIT.\npackage   foo\nconst Name=\"tool\"\nvar Version   =  \"1.2.3\"   // set by goversion\n"
+	for _, noGofmt := range []bool{false, true} {
+		t.Run(fmt.Sprintf("noGofmt=%v", noGofmt), func(t *testing.T) {
+			tmpDir := initTestRepo(t)
+			writeFilesT(t, tmpDir, map[string]string{"version.go": src})
+			commitAllT(t, tmpDir, "initial commit")
+
+			if _, err := RunWithConfig(Config{
+				Dir:         tmpDir,
+				VersionFile: "version.go",
+				VersionArg:  "patch",
+				ExtraFiles:  []string{"version.go"},
+				NoGofmt:     noGofmt,
+			}); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			want := "package foo\n\nvar (\n\tVersion = \"1.2.4\"\n)\n"
+			if noGofmt {
+				want = strings.Replace(src, `"1.2.3"`, `"1.2.4"`, 1)
+			}
+			if got, _ := os.ReadFile(filepath.Join(tmpDir, "version.go")); string(got) != want {
+				t.Errorf("version file mismatch\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestVersionVar verifies that a dotted VersionVar finds its last segment in the
+// version file and that only that literal is bumped.
+func TestVersionVar(t *testing.T) {
+	const src = "package buildinfo\n\n// Version is the API version, not the release.\nconst Version = \"3.0.0\"\n\n// Release is bumped by goversion.\nconst Release = \"1.4.0\"\n"
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"buildinfo/info.go": src})
+	commitAllT(t, tmpDir, "initial commit")
+	path := filepath.Join(tmpDir, "buildinfo", "info.go")
+
+	if v, err := ValidateVersionFile(path, "buildinfo.Release"); err != nil || v != "1.4.0" {
+		t.Fatalf("ValidateVersionFile = %q, %v; want 1.4.0", v, err)
+	}
+	meta, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "buildinfo/info.go",
+		VersionVar:  "buildinfo.Release",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"buildinfo/info.go"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if meta.OldVersion != "1.4.0" || meta.NewVersion != "1.5.0" {
+		t.Errorf("versions = %s -> %s, want 1.4.0 -> 1.5.0", meta.OldVersion, meta.NewVersion)
+	}
+	want := strings.Replace(src, `"1.4.0"`, `"1.5.0"`, 1)
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("version file mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if tag := gitT(t, tmpDir, "tag", "--points-at", "HEAD"); tag != "v1.5.0" {
+		t.Errorf("tag = %q, want v1.5.0", tag)
+	}
+}
+
+// TestNoCreate verifies that NoCreate makes a missing version file an error,
+// leaving nothing written, committed, or tagged.
+func TestNoCreate(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	head := gitT(t, tmpDir, "rev-parse", "HEAD")
+
+	_, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "verison.go",
+		VersionArg:  "patch",
+		NoCreate:    true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "verison.go does not exist") {
+		t.Fatalf("Run error = %v, want a missing version file error", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "verison.go")); !os.IsNotExist(err) {
+		t.Error("the mistyped version file must not be created")
+	}
+	if gitT(t, tmpDir, "rev-parse", "HEAD") != head || gitT(t, tmpDir, "tag", "--list") != "" {
+		t.Error("nothing must be committed or tagged")
+	}
+}
+
+// TestNoTagOnPrerelease verifies that NoTagOnPrerelease commits a prerelease
+// without tagging it, while a final release is still tagged.
+func TestNoTagOnPrerelease(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	versionFile := filepath.Join(tmpDir, "version.go")
+	if err := writeVersionFile(versionFile, "1.2.3"); err != nil {
+		t.Fatal(err)
+	}
+	commitAllT(t, tmpDir, "initial commit")
+	cfg := Config{
+		Dir:               tmpDir,
+		VersionFile:       versionFile,
+		VersionArg:        "prerelease",
+		ExtraFiles:        []string{versionFile},
+		NoTagOnPrerelease: true,
+	}
+
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("prerelease failed: %v", err)
+	}
+	if meta.NewVersion != "1.2.4-0" || meta.Tag != "" {
+		t.Errorf("prerelease = %q tagged %q, want 1.2.4-0 untagged", meta.NewVersion, meta.Tag)
+	}
+	if subject := gitT(t, tmpDir, "log", "-1", "--format=%s"); subject != "1.2.4-0" {
+		t.Errorf("HEAD subject = %q, want the prerelease commit", subject)
+	}
+	if tags := gitT(t, tmpDir, "tag"); tags != "" {
+		t.Errorf("a prerelease must not be tagged, got %q", tags)
+	}
+
+	cfg.VersionArg = "minor"
+	if meta, err = RunWithConfig(cfg); err != nil {
+		t.Fatalf("minor failed: %v", err)
+	}
+	if meta.NewVersion != "1.3.0" || meta.Tag != "v1.3.0" {
+		t.Errorf("minor = %q tagged %q, want 1.3.0 tagged v1.3.0", meta.NewVersion, meta.Tag)
+	}
+	if tags := gitT(t, tmpDir, "tag", "--points-at", "HEAD"); tags != "v1.3.0" {
+		t.Errorf("tags at HEAD = %q, want v1.3.0", tags)
+	}
+}
+
+// TestPadVersion verifies that missing minor and patch components are padded
+// with zeros, keeping any prefix, prerelease, and build metadata.
+func TestPadVersion(t *testing.T) {
+	tests := map[string]string{
+		"1.2":          "1.2.0",
+		"1":            "1.0.0",
+		"v1.2":         "v1.2.0",
+		"1.2-rc.1+b.5": "1.2.0-rc.1+b.5",
+		"1.2.3":        "1.2.3",
+		"dev":          "dev",
+		"":             "",
+	}
+	for in, want := range tests {
+		if got := padVersion(in); got != want {
+			t.Errorf("padVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestLenientVersion verifies that a two-part version is an error by default,
+// and with LenientVersion is read as x.y.0 and written back in full.
+func TestLenientVersion(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"VERSION": "1.2\n"})
+	commitAllT(t, tmpDir, "initial commit")
+	cfg := Config{Dir: tmpDir, VersionFile: "VERSION", VersionArg: "minor", ExtraFiles: []string{"VERSION"}}
+
+	if _, err := RunWithConfig(cfg); err == nil || !strings.Contains(err.Error(), "unexpected version format") {
+		t.Fatalf("strict Run error = %v, want an unexpected version format error", err)
+	}
+
+	cfg.LenientVersion = true
+	meta, err := RunWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("lenient Run failed: %v", err)
+	}
+	if meta.OldVersion != "1.2.0" || meta.NewVersion != "1.3.0" {
+		t.Errorf("bump = %s -> %s, want 1.2.0 -> 1.3.0", meta.OldVersion, meta.NewVersion)
+	}
+	if got, _ := os.ReadFile(filepath.Join(tmpDir, "VERSION")); strings.TrimSpace(string(got)) != "1.3.0" {
+		t.Errorf("VERSION = %q, want 1.3.0", got)
+	}
+}
+
+// TestCurrentVersion verifies that CurrentVersion reads the version a bump
+// starts from without creating a missing version file.
+func TestCurrentVersion(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n"})
+	commitAllT(t, tmpDir, "initial commit")
+
+	if got, err := CurrentVersion(Config{Dir: tmpDir, VersionFile: "version.go"}); err != nil || got != "1.2.3" {
+		t.Errorf("CurrentVersion = %q (%v), want 1.2.3", got, err)
+	}
+	if got, err := CurrentVersion(Config{Dir: tmpDir, VersionFile: "version.go", From: "v2.0.0"}); err != nil || got != "2.0.0" {
+		t.Errorf("CurrentVersion with From = %q (%v), want 2.0.0", got, err)
+	}
+	got, err := CurrentVersion(Config{Dir: tmpDir, VersionFile: "other.go", InitialVersion: "0.1.0"})
+	if err != nil || got != "0.1.0" {
+		t.Errorf("CurrentVersion of a missing file = %q (%v), want 0.1.0", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "other.go")); !os.IsNotExist(err) {
+		t.Errorf("CurrentVersion must not create the version file (%v)", err)
+	}
+}
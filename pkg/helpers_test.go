@@ -0,0 +1,86 @@
+package goversion
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initTestRepo creates a temporary git repository with a configured identity
+// and returns its path. Tests are skipped when git is unavailable.
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	if err := checkGit(); err != nil {
+		t.Skip("git is not available on system")
+	}
+	dir := t.TempDir()
+	gitT(t, dir, "init")
+	gitT(t, dir, "config", "user.email", "test@example.com")
+	gitT(t, dir, "config", "user.name", "Test User")
+	gitT(t, dir, "config", "commit.gpgsign", "false")
+	gitT(t, dir, "config", "tag.gpgsign", "false")
+	return dir
+}
+
+// gitT runs git with args in dir, failing the test on error, and returns its trimmed output.
+func gitT(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitAllT stages everything in dir and commits it with msg.
+func commitAllT(t *testing.T, dir, msg string) {
+	t.Helper()
+	gitT(t, dir, "add", "-A")
+	gitT(t, dir, "commit", "-m", msg)
+}
+
+// writeFilesT writes each name/content pair under dir, creating parent directories.
+func writeFilesT(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeSyntheticModule writes module example.com/foo to dir with n packages,
+// each importing the previous one, plus a test file in every other package and
+// a vendored copy of a package that must never be rewritten.
+func writeSyntheticModule(tb testing.TB, dir string, n int) {
+	tb.Helper()
+	files := map[string]string{
+		"go.mod":                        "module example.com/foo\n\ngo 1.18\n",
+		"vendor/example.com/foo/x/x.go": "package x\n\nimport _ \"example.com/foo/p0\"\n",
+		"p0/p0.go":                      "package p0\n\n// V is exported.\nconst V = 0\n",
+	}
+	for i := 1; i < n; i++ {
+		files[fmt.Sprintf("p%d/p%d.go", i, i)] = fmt.Sprintf("package p%d\n\nimport (\n\t\"fmt\"\n\n\t// The previous package.\n\tprev \"example.com/foo/p%d\"\n)\n\n// V is exported.\nvar V = fmt.Sprint(prev.V)\n", i, i-1)
+		if i%2 == 0 {
+			files[fmt.Sprintf("p%d/p%d_test.go", i, i)] = fmt.Sprintf("package p%d_test\n\nimport \"example.com/foo/p%d\"\n\nvar _ = p%d.V\n", i, i, i)
+		}
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package goversion
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// jsonExtensions are the file extensions read with findJSONVersion.
+var jsonExtensions = []string{".json", ".jsonc"}
+
+// jsonVersionValue matches a semantic version string value, optionally prefixed with "v".
+var jsonVersionValue = regexp.MustCompile(`^v?(` + semverCore + `)$`)
+
+// skipJSONSpace returns the offset of the first byte at or after i in content
+// that is neither whitespace nor part of a // or /* */ comment.
+func skipJSONSpace(content []byte, i int) int {
+	for i < len(content) {
+		switch {
+		case content[i] == ' ' || content[i] == '\t' || content[i] == '\r' || content[i] == '\n':
+			i++
+		case bytes.HasPrefix(content[i:], []byte("//")):
+			end := bytes.IndexByte(content[i:], '\n')
+			if end < 0 {
+				return len(content)
+			}
+			i += end + 1
+		case bytes.HasPrefix(content[i:], []byte("/*")):
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				return len(content)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// scanJSONString returns the offset just past the string starting with the
+// quote at content[i], or -1 if it is not terminated.
+func scanJSONString(content []byte, i int) int {
+	for i++; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		case '\n':
+			return -1
+		}
+	}
+	return -1
+}
+
+// findJSONVersion returns the top-level "version" field of a JSON document,
+// tolerating the // and /* */ comments and trailing commas of JSONC files such
+// as tsconfig.json. Fields of nested objects, such as dependency versions, and
+// text in comments or other strings are never matched, and the rest of the
+// document is left as is. It reports false if there is no such field.
+func findJSONVersion(content []byte) (VersionMatch, bool) {
+	i := skipJSONSpace(content, 0)
+	if i >= len(content) || content[i] != '{' {
+		return VersionMatch{}, false
+	}
+	depth := 0
+	for i < len(content) {
+		switch c := content[i]; {
+		case c == '{' || c == '[':
+			depth++
+			i++
+		case c == '}' || c == ']':
+			depth--
+			i++
+			if depth == 0 {
+				return VersionMatch{}, false
+			}
+		case c == '"':
+			keyStart := i
+			keyEnd := scanJSONString(content, i)
+			if keyEnd < 0 {
+				return VersionMatch{}, false
+			}
+			i = skipJSONSpace(content, keyEnd)
+			if depth != 1 || string(content[keyStart:keyEnd]) != `"version"` || i >= len(content) || content[i] != ':' {
+				continue
+			}
+			valueStart := skipJSONSpace(content, i+1)
+			if valueStart >= len(content) || content[valueStart] != '"' {
+				continue
+			}
+			valueEnd := scanJSONString(content, valueStart)
+			if valueEnd < 0 {
+				return VersionMatch{}, false
+			}
+			m := jsonVersionValue.FindSubmatchIndex(content[valueStart+1 : valueEnd-1])
+			if m == nil {
+				i = valueEnd
+				continue
+			}
+			start, end := valueStart+1+m[2], valueStart+1+m[3]
+			return VersionMatch{
+				Pattern:   "json-version",
+				Line:      bytes.Count(content[:keyStart], []byte("\n")) + 1,
+				Version:   string(content[start:end]),
+				FullMatch: string(content[keyStart:valueEnd]),
+				Prefix:    string(content[keyStart:start]),
+				Suffix:    `"`,
+				Start:     start,
+				End:       end,
+			}, true
+		default:
+			if next := skipJSONSpace(content, i); next != i {
+				i = next
+			} else {
+				i++
+			}
+		}
+	}
+	return VersionMatch{}, false
+}
+
+// isJSONFile reports whether path has a JSON or JSONC extension.
+func isJSONFile(path string) bool {
+	return slices.Contains(jsonExtensions, strings.ToLower(filepath.Ext(path)))
+}
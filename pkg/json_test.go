@@ -0,0 +1,77 @@
+package goversion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCommentedJSONC = `// Project settings.
+/* "version": "9.9.9" is not the project version. */
+{
+	"name": "widget", // package name
+	"engines": {
+		"version": "8.8.8",
+	},
+	"description": "set \"version\": \"7.7.7\" here",
+	/* The release version, bumped by goversion. */
+	"version" : "1.2.3", // keep in sync
+	"files": [
+		"dist",
+	],
+}
+`
+
+// TestBumpCommentedJSONC verifies that only the top-level version of a JSONC
+// file with comments and trailing commas changes, leaving the rest as it was.
+func TestBumpCommentedJSONC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.jsonc")
+	if err := os.WriteFile(path, []byte(testCommentedJSONC), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+		t.Fatalf("BumpVersionInFile failed: %v", err)
+	}
+	want := strings.Replace(testCommentedJSONC, `"version" : "1.2.3"`, `"version" : "1.3.0"`, 1)
+	got, _ := os.ReadFile(path)
+	if string(got) != want {
+		t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFindJSONVersion verifies which "version" field is chosen.
+func TestFindJSONVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{name: "package.json", content: "{\n  \"name\": \"x\",\n  \"version\": \"1.0.0\"\n}\n", want: "1.0.0", wantOK: true},
+		{name: "v prefix", content: `{"version":"v2.1.0"}`, want: "2.1.0", wantOK: true},
+		{name: "after nested", content: `{"a": {"version": "0.1.0"}, "b": ["version"], "version": "3.0.0"}`, want: "3.0.0", wantOK: true},
+		{name: "line comment", content: "{\n// \"version\": \"0.0.1\"\n\"version\": \"0.2.0\",\n}", want: "0.2.0", wantOK: true},
+		{name: "nested only", content: `{"dependencies": {"version": "1.0.0"}}`, wantOK: false},
+		{name: "not a version", content: `{"version": "latest"}`, wantOK: false},
+		{name: "not an object", content: `["version", "1.0.0"]`, wantOK: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			content := []byte(tc.content)
+			m, ok := findJSONVersion(content)
+			if ok != tc.wantOK {
+				t.Fatalf("found = %v, want %v (match %+v)", ok, tc.wantOK, m)
+			}
+			if !ok {
+				return
+			}
+			if m.Version != tc.want {
+				t.Errorf("version = %q, want %q", m.Version, tc.want)
+			}
+			if string(content[m.Start:m.End]) != m.Version {
+				t.Errorf("offsets %d:%d do not span %q", m.Start, m.End, m.Version)
+			}
+		})
+	}
+}
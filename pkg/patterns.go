@@ -0,0 +1,286 @@
+package goversion
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// semverCore is the official semver.org regex without anchors or named groups,
+// suitable for embedding in larger patterns.
+const semverCore = `(?:0|[1-9]\d*)\.(?:0|[1-9]\d*)\.(?:0|[1-9]\d*)(?:-(?:(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?:[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?`
+
+// VersionPattern describes a regular expression that locates a version inside a
+// non-Go file. Regex must contain a capture group named "version" that spans
+// exactly the version string to be replaced.
+type VersionPattern struct {
+	Name  string         // Short identifier reported in matches (e.g. "json-version").
+	Regex *regexp.Regexp // Pattern with a named "version" group.
+}
+
+// VersionMatch describes a single version found in a file by a VersionPattern.
+type VersionMatch struct {
+	Pattern   string // Name of the pattern that matched.
+	Line      int    // 1-based line number where the match starts.
+	Version   string // The matched version string.
+	FullMatch string // The full text matched by the pattern.
+	Prefix    string // Text of FullMatch before the version.
+	Suffix    string // Text of FullMatch after the version.
+	Start     int    // Byte offset of the version within the file.
+	End       int    // Byte offset just past the version within the file.
+}
+
+// newVersionPattern compiles a VersionPattern, substituting SEMVER in expr with semverCore.
+// An optional "v" before the version is matched as part of the prefix so that it is
+// preserved when the version is replaced.
+func newVersionPattern(name, expr string) VersionPattern {
+	expr = regexp.MustCompile(`SEMVER`).ReplaceAllLiteralString(expr, `v?(?P<version>`+semverCore+`)`)
+	return VersionPattern{Name: name, Regex: regexp.MustCompile(expr)}
+}
+
+// MainVersionPatterns match fields that conventionally hold a project's own version,
+// such as the top-level "version" in package.json or `ARG VERSION=` in a Dockerfile.
+// When bumping a file, the earliest match from any of these patterns is replaced.
+var MainVersionPatterns = []VersionPattern{
+	newVersionPattern("json-version", `"version"\s*:\s*"SEMVER"`),
+	newVersionPattern("toml-version", `(?m)^[ \t]*version[ \t]*=[ \t]*["']SEMVER["']`),
+	newVersionPattern("ini-version", `(?m)^[ \t]*version[ \t]*=[ \t]*SEMVER[ \t]*\r?$`),
+	newVersionPattern("properties-version-name", `(?m)^[ \t]*versionName[ \t]*[=:][ \t]*SEMVER[ \t]*\r?$`),
+	newVersionPattern("yaml-version", `(?m)^version[ \t]*:[ \t]*["']?SEMVER`),
+	newVersionPattern("version-assignment", `(?m)^[ \t]*(?:export[ \t]+)?VERSION[ \t]*=[ \t]*["']?SEMVER`),
+	newVersionPattern("makefile-version", `(?m)^[ \t]*(?:(?:export|override)[ \t]+)*VERSION[ \t]*(?:::?|\?)=[ \t]*["']?SEMVER`),
+	newVersionPattern("python-dunder-version", `(?m)^[ \t]*__version__[ \t]*(?::[ \t]*[\w.\[\]]+[ \t]*)?=[ \t]*["']SEMVER["']`),
+	newVersionPattern("dockerfile-arg", `(?m)^[ \t]*ARG[ \t]+VERSION=["']?SEMVER`),
+	newVersionPattern("dockerfile-label", `\borg\.opencontainers\.image\.version=["']?SEMVER`),
+	newVersionPattern("xml-version", `<(?i:version|versionprefix)>\s*SEMVER\s*</(?i:version|versionprefix)>`),
+}
+
+// SecondaryVersionPatterns match fields that conventionally track the project version
+// alongside the main one, such as appVersion in a Helm Chart.yaml. They are only
+// updated by BumpAllVersionsInFile.
+var SecondaryVersionPatterns = []VersionPattern{
+	newVersionPattern("yaml-app-version", `(?m)^appVersion[ \t]*:[ \t]*["']?SEMVER`),
+}
+
+// CommonVersionPatterns match any key/value pair whose key mentions a version,
+// including dependency and tooling versions. They are used to report every
+// version-like field in a file rather than to pick the one to bump.
+var CommonVersionPatterns = []VersionPattern{
+	newVersionPattern("key-value", `(?i)[\w.-]*version["']?\s*[:=]\s*["']?SEMVER`),
+	newVersionPattern("xml-version", `<(?i:version|versionprefix)>\s*SEMVER\s*</(?i:version|versionprefix)>`),
+}
+
+// findPatternMatches returns every match of the given patterns in content, ordered by position.
+// Overlapping matches for the same version span are reported once.
+func findPatternMatches(content []byte, patterns []VersionPattern) []VersionMatch {
+	var matches []VersionMatch
+	seen := make(map[int]bool)
+	for _, p := range patterns {
+		vIdx := p.Regex.SubexpIndex("version")
+		for _, loc := range p.Regex.FindAllSubmatchIndex(content, -1) {
+			start, end := loc[2*vIdx], loc[2*vIdx+1]
+			if seen[start] {
+				continue
+			}
+			seen[start] = true
+			matches = append(matches, VersionMatch{
+				Pattern:   p.Name,
+				Line:      bytes.Count(content[:loc[0]], []byte("\n")) + 1,
+				Version:   string(content[start:end]),
+				FullMatch: string(content[loc[0]:loc[1]]),
+				Prefix:    string(content[loc[0]:start]),
+				Suffix:    string(content[end:loc[1]]),
+				Start:     start,
+				End:       end,
+			})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
+
+// FindVersionsInFile returns every version found in the file at path by CommonVersionPatterns,
+// ordered by their position in the file.
+func FindVersionsInFile(path string) ([]VersionMatch, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return findPatternMatches(content, CommonVersionPatterns), nil
+}
+
+// FindMainVersionInFile returns the earliest match of any MainVersionPatterns in the file at path.
+// In a ".toml" file, the version key of the [package], [workspace.package], [project], or
+// [tool.poetry] table is preferred, so that dependency tables are never matched.
+// In a ".json" or ".jsonc" file, the top-level "version" field is preferred, and
+// comments and trailing commas are tolerated.
+// If none match and the file is an OpenAPI or Swagger document, its info.version is returned.
+// It returns an error if no main version field is found.
+func FindMainVersionInFile(path string) (VersionMatch, error) {
+	return findMainVersionInFile(path, MainVersionPatterns)
+}
+
+// findMainVersionInFile is FindMainVersionInFile with patterns in place of MainVersionPatterns.
+func findMainVersionInFile(path string, patterns []VersionPattern) (VersionMatch, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return VersionMatch{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		if match, ok := findTOMLVersion(content); ok {
+			return match, nil
+		}
+	}
+	if isJSONFile(path) {
+		if match, ok := findJSONVersion(content); ok {
+			return match, nil
+		}
+	}
+	matches := findPatternMatches(content, patterns)
+	if len(matches) == 0 {
+		if match, ok := findOpenAPIVersion(content); ok {
+			return match, nil
+		}
+		return VersionMatch{}, fmt.Errorf("no main version field found in %s", path)
+	}
+	return matches[0], nil
+}
+
+// ReplaceVersionInFile replaces the version described by match with newVersion,
+// leaving the rest of the file untouched. The file must not have changed since
+// match was produced. A "v" prefix on the original field is kept (it is part of
+// match.Prefix), and any "v" on newVersion is dropped so the field's style is preserved.
+func ReplaceVersionInFile(path string, match VersionMatch, newVersion string) error {
+	newVersion = strings.TrimPrefix(newVersion, "v")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if match.End > len(content) || string(content[match.Start:match.End]) != match.Version {
+		return fmt.Errorf("version %q not found at expected position in %s", match.Version, path)
+	}
+	var out bytes.Buffer
+	out.Write(content[:match.Start])
+	out.WriteString(newVersion)
+	out.Write(content[match.End:])
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// BumpVersionInFile sets the project version in an arbitrary text file to newVersion.
+// It prefers a field matched by MainVersionPatterns and falls back to replacing
+// the first semantic version in the file, except in a ".cff" file, whose first
+// version is usually its cff-version.
+func BumpVersionInFile(path, newVersion string) error {
+	return BumpVersionInFileWithSeparator(path, newVersion, "-")
+}
+
+// BumpVersionInFileWithSeparator is BumpVersionInFile for files that separate the
+// prerelease from the version core with sep instead of semver's "-", such as "."
+// in "1.2.3.rc1" or "_" in "1.2.3_beta". Versions are matched with sep, and sep is
+// written in place of the "-" in newVersion.
+func BumpVersionInFileWithSeparator(path, newVersion, sep string) error {
+	patterns := MainVersionPatterns
+	if sep != "-" {
+		patterns = withPrereleaseSeparator(patterns, sep)
+		newVersion = separatePrerelease(newVersion, sep)
+	}
+	match, err := findMainVersionInFile(path, patterns)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || !semverFallback(path) {
+			return err
+		}
+		return findAndReplaceSemver(path, newVersion, sep)
+	}
+	return ReplaceVersionInFile(path, match, newVersion)
+}
+
+// semverFallback reports whether the first semantic version in the file at path
+// may be bumped when no main version field is found. It may not in a CITATION.cff
+// file, where it is usually the cff-version, the version of the format itself.
+func semverFallback(path string) bool {
+	return !strings.EqualFold(filepath.Ext(path), ".cff")
+}
+
+// semverPrereleaseStart is the start of the prerelease part of semverCore.
+const semverPrereleaseStart = `(?:-(?:`
+
+// withPrereleaseSeparator returns copies of patterns that match a prerelease
+// introduced by sep instead of "-".
+func withPrereleaseSeparator(patterns []VersionPattern, sep string) []VersionPattern {
+	core := strings.Replace(semverCore, semverPrereleaseStart, `(?:`+regexp.QuoteMeta(sep)+`(?:`, 1)
+	out := make([]VersionPattern, len(patterns))
+	for i, p := range patterns {
+		expr := strings.ReplaceAll(p.Regex.String(), semverCore, core)
+		out[i] = VersionPattern{Name: p.Name, Regex: regexp.MustCompile(expr)}
+	}
+	return out
+}
+
+// separatePrerelease returns version with sep in place of the "-" that starts its
+// prerelease, if it has one.
+func separatePrerelease(version, sep string) string {
+	i := strings.IndexAny(version, "-+")
+	if i < 0 || version[i] != '-' {
+		return version
+	}
+	return version[:i] + sep + version[i+1:]
+}
+
+// findAllVersionFields returns the fields of content, read from path, that
+// BumpAllVersionsInFile sets.
+func findAllVersionFields(path string, content []byte) []VersionMatch {
+	switch {
+	case strings.EqualFold(filepath.Ext(path), ".toml"):
+		if match, ok := findTOMLVersion(content); ok {
+			return []VersionMatch{match}
+		}
+		return nil
+	case isJSONFile(path):
+		if match, ok := findJSONVersion(content); ok {
+			return []VersionMatch{match}
+		}
+		return nil
+	}
+	return findPatternMatches(content, slices.Concat(MainVersionPatterns, SecondaryVersionPatterns))
+}
+
+// BumpAllVersionsInFile sets every field in the file at path matched by
+// MainVersionPatterns or SecondaryVersionPatterns to newVersion, for files such as a
+// Helm Chart.yaml whose version and appVersion move together. Fields that only match
+// CommonVersionPatterns, such as dependency versions, are left alone. In a JSON or
+// TOML file, only the top-level version field (as FindMainVersionInFile reads it)
+// is set, so that the versions of nested dependencies in package.json,
+// package-lock.json, or Cargo.toml are never touched. A "v" prefix on each field
+// is preserved. It returns an error if no field is found.
+func BumpAllVersionsInFile(path, newVersion string) error {
+	newVersion = strings.TrimPrefix(newVersion, "v")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	matches := findAllVersionFields(path, content)
+	if len(matches) == 0 {
+		return fmt.Errorf("no version field found in %s", path)
+	}
+	var out bytes.Buffer
+	last := 0
+	for _, m := range matches {
+		out.Write(content[last:m.Start])
+		out.WriteString(newVersion)
+		last = m.End
+	}
+	out.Write(content[last:])
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
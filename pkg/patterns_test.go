@@ -0,0 +1,506 @@
+package goversion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBumpVersionInFileDockerfile verifies that Dockerfile ARG and LABEL version
+// fields are bumped while unrelated versions are left alone.
+func TestBumpVersionInFileDockerfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		newVersion  string
+		wantContent string
+		wantPattern string
+	}{
+		{
+			name: "ARG VERSION",
+			content: `FROM golang:1.21.0 AS build
+ARG GO_VERSION=1.21.0
+ARG VERSION=1.2.3
+RUN go build -ldflags "-X main.Version=${VERSION}" ./...
+`,
+			newVersion: "1.3.0",
+			wantContent: `FROM golang:1.21.0 AS build
+ARG GO_VERSION=1.21.0
+ARG VERSION=1.3.0
+RUN go build -ldflags "-X main.Version=${VERSION}" ./...
+`,
+			wantPattern: "dockerfile-arg",
+		},
+		{
+			name: "LABEL image version",
+			content: `FROM alpine:3.18.4
+ARG ALPINE_PATCH=3.18.4
+LABEL org.opencontainers.image.title="tool" \
+      org.opencontainers.image.version="1.2.3"
+`,
+			newVersion: "2.0.0",
+			wantContent: `FROM alpine:3.18.4
+ARG ALPINE_PATCH=3.18.4
+LABEL org.opencontainers.image.title="tool" \
+      org.opencontainers.image.version="2.0.0"
+`,
+			wantPattern: "dockerfile-label",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Dockerfile")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("failed to write Dockerfile: %v", err)
+			}
+
+			match, err := FindMainVersionInFile(path)
+			if err != nil {
+				t.Fatalf("FindMainVersionInFile failed: %v", err)
+			}
+			if match.Pattern != tc.wantPattern {
+				t.Errorf("matched pattern %q, want %q", match.Pattern, tc.wantPattern)
+			}
+
+			if err := BumpVersionInFile(path, tc.newVersion); err != nil {
+				t.Fatalf("BumpVersionInFile failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read result: %v", err)
+			}
+			if string(got) != tc.wantContent {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, tc.wantContent)
+			}
+		})
+	}
+}
+
+// TestBumpVersionInFilePreservesVPrefix verifies that main version fields keep
+// their original "v" prefix, or lack of one, when replaced.
+func TestBumpVersionInFilePreservesVPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		newVersion  string
+		wantContent string
+	}{
+		{
+			name:        "v-prefixed TOML field",
+			content:     "[package]\nname = \"tool\"\nversion = \"v1.2.3\"\n",
+			newVersion:  "2.0.0",
+			wantContent: "[package]\nname = \"tool\"\nversion = \"v2.0.0\"\n",
+		},
+		{
+			name:        "v-prefixed YAML field",
+			content:     "name: tool\nversion: v1.2.3\n",
+			newVersion:  "v1.3.0",
+			wantContent: "name: tool\nversion: v1.3.0\n",
+		},
+		{
+			name:        "bare JSON field given v-prefixed version",
+			content:     "{\n  \"version\": \"1.2.3\"\n}\n",
+			newVersion:  "v1.2.4",
+			wantContent: "{\n  \"version\": \"1.2.4\"\n}\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			if err := BumpVersionInFile(path, tc.newVersion); err != nil {
+				t.Fatalf("BumpVersionInFile failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read result: %v", err)
+			}
+			if string(got) != tc.wantContent {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, tc.wantContent)
+			}
+		})
+	}
+}
+
+// TestBumpVersionInFileWithSeparator verifies that a custom prerelease separator
+// is matched in the existing version and written in the new one.
+func TestBumpVersionInFileWithSeparator(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		sep         string
+		newVersion  string
+		wantContent string
+	}{
+		{
+			name:        "dot separator in a main field",
+			content:     "name = \"tool\"\nversion = \"1.2.3.rc1\"\n",
+			sep:         ".",
+			newVersion:  "1.2.4-rc1",
+			wantContent: "name = \"tool\"\nversion = \"1.2.4.rc1\"\n",
+		},
+		{
+			name:        "underscore separator released",
+			content:     "{\n  \"version\": \"1.2.3_beta\"\n}\n",
+			sep:         "_",
+			newVersion:  "1.2.4",
+			wantContent: "{\n  \"version\": \"1.2.4\"\n}\n",
+		},
+		{
+			name:        "dot separator in plain text",
+			content:     "release 1.2.3.rc1\n",
+			sep:         ".",
+			newVersion:  "1.2.4-rc1",
+			wantContent: "release 1.2.4.rc1\n",
+		},
+		{
+			name:        "default separator",
+			content:     "version = \"1.2.3-rc.1\"\n",
+			sep:         "-",
+			newVersion:  "1.2.4-rc.1",
+			wantContent: "version = \"1.2.4-rc.1\"\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "manifest")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			if err := BumpVersionInFileWithSeparator(path, tc.newVersion, tc.sep); err != nil {
+				t.Fatalf("BumpVersionInFileWithSeparator failed: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read result: %v", err)
+			}
+			if string(got) != tc.wantContent {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, tc.wantContent)
+			}
+		})
+	}
+}
+
+// TestBumpAllVersionsInFileChart verifies that both version and appVersion in a Helm
+// Chart.yaml are updated while dependency versions are left alone.
+func TestBumpAllVersionsInFileChart(t *testing.T) {
+	content := `apiVersion: v2
+name: tool
+version: 1.2.3
+appVersion: "1.2.3"
+dependencies:
+  - name: postgresql
+    version: 12.1.6
+    repository: https://charts.example.com
+`
+	want := `apiVersion: v2
+name: tool
+version: 1.3.0
+appVersion: "1.3.0"
+dependencies:
+  - name: postgresql
+    version: 12.1.6
+    repository: https://charts.example.com
+`
+	path := filepath.Join(t.TempDir(), "Chart.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := BumpAllVersionsInFile(path, "1.3.0"); err != nil {
+		t.Fatalf("BumpAllVersionsInFile failed: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestBumpAllVersionsInFileJSON verifies that only the top-level version of a
+// package.json is updated, leaving nested dependency versions alone.
+func TestBumpAllVersionsInFileJSON(t *testing.T) {
+	content := `{
+  "name": "tool",
+  "version": "1.2.3",
+  "packages": {
+    "node_modules/left-pad": {
+      "version": "1.2.3"
+    }
+  },
+  "dependencies": {
+    "left-pad": {
+      "version": "1.2.3"
+    }
+  }
+}
+`
+	want := strings.Replace(content, `"version": "1.2.3"`, `"version": "1.3.0"`, 1)
+	path := filepath.Join(t.TempDir(), "package-lock.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := BumpAllVersionsInFile(path, "1.3.0"); err != nil {
+		t.Fatalf("BumpAllVersionsInFile failed: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestBumpVersionInFileGradleProperties verifies that only the versionName or
+// version property of a gradle.properties file is bumped, leaving the integer
+// versionCode and dependency versions alone.
+func TestBumpVersionInFileGradleProperties(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		wantPattern string
+	}{
+		{name: "versionName", key: "versionName", wantPattern: "properties-version-name"},
+		{name: "version", key: "version", wantPattern: "ini-version"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			content := "# Project-wide Gradle settings.\n" +
+				"org.gradle.jvmargs=-Xmx2048m\n" +
+				"okhttpVersion=4.12.0\n" +
+				"okhttp=com.squareup.okhttp3:okhttp:4.12.0\n" +
+				"versionCode=42\n" +
+				tc.key + "=1.2.3\n" +
+				"kotlin.version=1.9.22\n"
+			path := filepath.Join(t.TempDir(), "gradle.properties")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			match, err := FindMainVersionInFile(path)
+			if err != nil {
+				t.Fatalf("FindMainVersionInFile failed: %v", err)
+			}
+			if match.Pattern != tc.wantPattern || match.Version != "1.2.3" {
+				t.Errorf("matched %q by %q, want 1.2.3 by %q", match.Version, match.Pattern, tc.wantPattern)
+			}
+			if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+				t.Fatalf("BumpVersionInFile failed: %v", err)
+			}
+			got, _ := os.ReadFile(path)
+			if want := strings.Replace(content, tc.key+"=1.2.3", tc.key+"=1.3.0", 1); string(got) != want {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestBumpVersionInFileMakefile verifies that a Makefile's VERSION := or ?=
+// assignment is bumped, rather than an earlier assignment to another variable.
+func TestBumpVersionInFileMakefile(t *testing.T) {
+	for _, op := range []string{":=", "?=", "::="} {
+		t.Run(op, func(t *testing.T) {
+			content := "GO_VERSION := 1.22.0\n" +
+				"GOLANGCI_LINT_VERSION ?= 1.55.2\n" +
+				"BUILD := $(shell git rev-parse HEAD)\n" +
+				"VERSION " + op + " 1.2.3\n" +
+				"\nrelease:\n\t./deploy.sh $(VERSION)\n"
+			path := filepath.Join(t.TempDir(), "Makefile")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			match, err := FindMainVersionInFile(path)
+			if err != nil {
+				t.Fatalf("FindMainVersionInFile failed: %v", err)
+			}
+			if match.Pattern != "makefile-version" || match.Line != 4 {
+				t.Errorf("matched %q by %q on line %d, want line 4 by makefile-version", match.Version, match.Pattern, match.Line)
+			}
+			if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+				t.Fatalf("BumpVersionInFile failed: %v", err)
+			}
+			got, _ := os.ReadFile(path)
+			if want := strings.Replace(content, op+" 1.2.3", op+" 1.3.0", 1); string(got) != want {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestBumpVersionInFilePythonDunder verifies that __version__ is bumped in an
+// __init__.py, with or without a type annotation and in either quote style,
+// while other dunder assignments are left alone.
+func TestBumpVersionInFilePythonDunder(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "plain", line: `__version__ = "1.2.3"`},
+		{name: "annotated", line: `__version__: str = '1.2.3'`},
+		{name: "final", line: `__version__: typing.Final[str] = "1.2.3"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			content := "\"\"\"mypkg: does things.\"\"\"\n\n" +
+				"__author__ = \"Jane 0.1.0\"\n" +
+				"__min_python__ = \"3.9.0\"\n" +
+				tc.line + "\n\n" +
+				"__all__ = [\"thing\"]\n"
+			path := filepath.Join(t.TempDir(), "__init__.py")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			match, err := FindMainVersionInFile(path)
+			if err != nil {
+				t.Fatalf("FindMainVersionInFile failed: %v", err)
+			}
+			if match.Pattern != "python-dunder-version" || match.Line != 5 {
+				t.Errorf("matched %q by %q on line %d, want line 5 by python-dunder-version", match.Version, match.Pattern, match.Line)
+			}
+			if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+				t.Fatalf("BumpVersionInFile failed: %v", err)
+			}
+			got, _ := os.ReadFile(path)
+			want := strings.Replace(content, tc.line, strings.Replace(tc.line, "1.2.3", "1.3.0", 1), 1)
+			if string(got) != want {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestMainVersionPatternsWhitespace verifies that hand-formatted fields with
+// tabs or unusual spacing around their separators are found and bumped.
+func TestMainVersionPatternsWhitespace(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		wantPattern string
+	}{
+		{name: "json", file: "package.json", content: "{\n\t\"name\"\t:\t\"x\",\n\t\"version\"\t:\t\"1.2.3\"\n}\n", wantPattern: "json-version"},
+		{name: "json without extension", file: "manifest", content: "{\"version\" \t :\t\t\"1.2.3\"}\n", wantPattern: "json-version"},
+		{name: "toml table", file: "Cargo.toml", content: "[\tpackage\t]\t# crate\nversion\t=\t\"1.2.3\"\n", wantPattern: "toml-table-version"},
+		{name: "toml", file: "config.ini.tmpl", content: "\tversion \t=\t'1.2.3'\n", wantPattern: "toml-version"},
+		{name: "ini", file: "setup.cfg", content: "[metadata]\nversion\t=\t1.2.3\t\n", wantPattern: "ini-version"},
+		{name: "yaml", file: "Chart.yaml", content: "version\t:\t1.2.3\n", wantPattern: "yaml-version"},
+		{name: "xml", file: "pom.xml", content: "<project>\n\t<version>\n\t\t1.2.3\n\t</version>\n</project>\n", wantPattern: "xml-version"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.file)
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			match, err := FindMainVersionInFile(path)
+			if err != nil {
+				t.Fatalf("FindMainVersionInFile failed: %v", err)
+			}
+			if match.Pattern != tc.wantPattern || match.Version != "1.2.3" {
+				t.Errorf("match = %s %q, want %s \"1.2.3\"", match.Pattern, match.Version, tc.wantPattern)
+			}
+			if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+				t.Fatalf("BumpVersionInFile failed: %v", err)
+			}
+			want := strings.Replace(tc.content, "1.2.3", "1.3.0", 1)
+			if got, _ := os.ReadFile(path); string(got) != want {
+				t.Errorf("content mismatch\ngot:\n%q\nwant:\n%q", got, want)
+			}
+			if found, _ := FindVersionsInFile(path); len(found) != 1 || found[0].Version != "1.3.0" {
+				t.Errorf("FindVersionsInFile = %+v, want the bumped field", found)
+			}
+		})
+	}
+}
+
+const testCitation = `cff-version: 1.2.0
+message: "If you use this software, please cite it as below."
+title: widget
+version: 1.2.3
+date-released: 2024-01-01
+references:
+  - type: software
+    title: dependency
+    version: 4.5.6
+`
+
+// TestBumpVersionInFileCitation verifies that only the top-level version of a
+// CITATION.cff file is bumped, never its cff-version, even if it has no version.
+func TestBumpVersionInFileCitation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CITATION.cff")
+	if err := os.WriteFile(path, []byte(testCitation), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+		t.Fatalf("BumpVersionInFile failed: %v", err)
+	}
+	want := strings.Replace(testCitation, "version: 1.2.3", "version: 1.3.0", 1)
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+
+	unversioned := "cff-version: 1.2.0\ntitle: widget\n"
+	if err := os.WriteFile(path, []byte(unversioned), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := BumpVersionInFile(path, "1.3.0"); err == nil {
+		t.Error("expected an error for a CITATION.cff without a version")
+	}
+	if got, _ := os.ReadFile(path); string(got) != unversioned {
+		t.Errorf("cff-version was modified:\n%s", got)
+	}
+}
+
+const testCsproj = `<Project Sdk="Microsoft.NET.Sdk">
+  <ItemGroup>
+    <PackageReference Include="Newtonsoft.Json" Version="13.0.3" />
+  </ItemGroup>
+  <PropertyGroup>
+    <PackageVersion>2.0.0</PackageVersion>
+    <Version>1.2.3</Version>
+  </PropertyGroup>
+</Project>
+`
+
+// TestBumpVersionInFileCsproj verifies that the <Version> or <VersionPrefix>
+// property of a .NET project is bumped, and never a package reference or
+// <PackageVersion> before it.
+func TestBumpVersionInFileCsproj(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "version", file: "App.csproj", content: testCsproj},
+		{name: "version prefix", file: "Directory.Build.props", content: strings.Replace(testCsproj, "Version>1.2.3</Version", "VersionPrefix>1.2.3</VersionPrefix", 1)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.file)
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			match, err := FindMainVersionInFile(path)
+			if err != nil {
+				t.Fatalf("FindMainVersionInFile failed: %v", err)
+			}
+			if match.Pattern != "xml-version" || match.Version != "1.2.3" {
+				t.Errorf("match = %s %q, want xml-version \"1.2.3\"", match.Pattern, match.Version)
+			}
+			if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+				t.Fatalf("BumpVersionInFile failed: %v", err)
+			}
+			want := strings.Replace(tc.content, "1.2.3", "1.3.0", 1)
+			if got, _ := os.ReadFile(path); string(got) != want {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
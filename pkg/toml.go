@@ -0,0 +1,126 @@
+package goversion
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tomlVersionKeys are the dotted key paths that hold a project's own version in
+// TOML manifests: Cargo.toml, pyproject.toml (PEP 621 and Poetry), and a
+// top-level version in other files.
+var tomlVersionKeys = []string{
+	"package.version",
+	"workspace.package.version",
+	"project.version",
+	"tool.poetry.version",
+	"version",
+}
+
+// tomlTableHeader matches a standard table header such as `[tool.poetry]`,
+// capturing its key path, with an optional trailing comment.
+var tomlTableHeader = regexp.MustCompile(`^\[([^\[\]]+)\][ \t]*(?:#.*)?$`)
+
+// tomlKeyValue matches a key/value pair, capturing the (possibly dotted and
+// quoted) key and the value with any trailing comment.
+var tomlKeyValue = regexp.MustCompile(`^((?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:[ \t]*\.[ \t]*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*)[ \t]*=[ \t]*(.*)$`)
+
+// tomlVersionValue matches a quoted semantic version, optionally prefixed with
+// "v", followed by an optional comment.
+var tomlVersionValue = regexp.MustCompile(`^(["'])v?(` + semverCore + `)(["'])[ \t]*(?:#.*)?$`)
+
+// tomlKeyPath normalizes a TOML key such as `tool . "poetry"` to "tool.poetry".
+func tomlKeyPath(key string) string {
+	var parts []string
+	var part strings.Builder
+	var quote byte
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				part.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		case c != ' ' && c != '\t':
+			part.WriteByte(c)
+		}
+	}
+	return strings.Join(append(parts, part.String()), ".")
+}
+
+// findTOMLVersion returns the project version in TOML content: the first
+// version key in [package], [workspace.package], [project], or [tool.poetry],
+// or at the top level. Keys in other tables, such as dependency versions,
+// comments, and the contents of multi-line strings are never matched, and the
+// rest of the document is left as is. It reports false if there is no such key.
+func findTOMLVersion(content []byte) (VersionMatch, bool) {
+	var (
+		table     string
+		multiline string // The delimiter of the multi-line string being skipped, if any.
+		offset    int
+	)
+	for lineNum, line := range bytes.SplitAfter(content, []byte("\n")) {
+		start := offset
+		offset += len(line)
+		text := strings.TrimRight(string(line), "\r\n")
+		if multiline != "" {
+			if strings.Count(text, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[[") {
+			table = "[[array]]" // Never a version table.
+			continue
+		}
+		if m := tomlTableHeader.FindStringSubmatch(trimmed); m != nil {
+			table = tomlKeyPath(m[1])
+			continue
+		}
+		m := tomlKeyValue.FindStringSubmatchIndex(trimmed)
+		if m == nil {
+			continue
+		}
+		value := trimmed[m[4]:m[5]]
+		for _, delim := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, delim) && strings.Count(value, delim)%2 == 1 {
+				multiline = delim
+			}
+		}
+		path := tomlKeyPath(trimmed[m[2]:m[3]])
+		if table != "" {
+			path = table + "." + path
+		}
+		if !slices.Contains(tomlVersionKeys, path) {
+			continue
+		}
+		vm := tomlVersionValue.FindStringSubmatchIndex(value)
+		if vm == nil || value[vm[2]:vm[3]] != value[vm[6]:vm[7]] {
+			continue
+		}
+		valueStart := start + len(text) - len(trimmed) + m[4]
+		return VersionMatch{
+			Pattern:   "toml-table-version",
+			Line:      lineNum + 1,
+			Version:   value[vm[4]:vm[5]],
+			FullMatch: strings.TrimSpace(trimmed),
+			Prefix:    trimmed[:m[4]] + value[:vm[4]],
+			Suffix:    value[vm[5]:],
+			Start:     valueStart + vm[4],
+			End:       valueStart + vm[5],
+		}, true
+	}
+	return VersionMatch{}, false
+}
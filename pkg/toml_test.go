@@ -0,0 +1,88 @@
+package goversion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCommentedCargo = `# Cargo manifest.
+# version = "9.9.9" is not the package version.
+
+[dependencies.serde] # pinned
+version = "1.0.188"
+features = ["derive"]
+
+[[bin]]
+name = "tool"
+version = "8.8.8"
+
+[package]
+name    = "widget"   # crate name
+description = """
+A widget.
+version = "7.7.7"
+"""
+# The release version, bumped by goversion.
+version = "1.2.3"   # keep in sync
+edition = "2021"
+
+[dependencies]
+anyhow = { version = "1.0.75" }
+`
+
+// TestBumpCommentedCargoTOML verifies that only the [package] version of a
+// heavily commented Cargo.toml changes, leaving comments, ordering, and
+// formatting as they were.
+func TestBumpCommentedCargoTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Cargo.toml")
+	if err := os.WriteFile(path, []byte(testCommentedCargo), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := BumpVersionInFile(path, "1.3.0"); err != nil {
+		t.Fatalf("BumpVersionInFile failed: %v", err)
+	}
+	want := strings.Replace(testCommentedCargo, `version = "1.2.3"`, `version = "1.3.0"`, 1)
+	got, _ := os.ReadFile(path)
+	if string(got) != want {
+		t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestFindTOMLVersion verifies which table's version key is chosen.
+func TestFindTOMLVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{name: "poetry", content: "[tool.black]\nversion = \"0.1.0\"\n\n[tool.poetry]\nversion = \"2.0.0\"\n", want: "2.0.0", wantOK: true},
+		{name: "project", content: "[project]\nname = \"x\"\nversion = 'v3.1.0'\n", want: "3.1.0", wantOK: true},
+		{name: "workspace", content: "[workspace.package]\nversion = \"0.4.0\"\n", want: "0.4.0", wantOK: true},
+		{name: "dotted key", content: "package.version = \"1.0.0\"\n", want: "1.0.0", wantOK: true},
+		{name: "quoted header", content: "[ \"package\" ]\nversion = \"5.0.0\"\n", want: "5.0.0", wantOK: true},
+		{name: "top level", content: "version = \"0.9.0\"\n[server]\nport = 80\n", want: "0.9.0", wantOK: true},
+		{name: "dependency only", content: "[dependencies.serde]\nversion = \"1.0.0\"\n", wantOK: false},
+		{name: "not a version", content: "[package]\nversion = \"latest\"\n", wantOK: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			content := []byte(tc.content)
+			m, ok := findTOMLVersion(content)
+			if ok != tc.wantOK {
+				t.Fatalf("found = %v, want %v (match %+v)", ok, tc.wantOK, m)
+			}
+			if !ok {
+				return
+			}
+			if m.Version != tc.want {
+				t.Errorf("version = %q, want %q", m.Version, tc.want)
+			}
+			if string(content[m.Start:m.End]) != m.Version {
+				t.Errorf("offsets %d:%d do not span %q", m.Start, m.End, m.Version)
+			}
+		})
+	}
+}
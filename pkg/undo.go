@@ -0,0 +1,129 @@
+package goversion
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPushed is returned, possibly wrapped, by Undo when the release commit is
+// already on the upstream branch and Config.Force is not set.
+var ErrPushed = errors.New("release commit has been pushed")
+
+// Undo reverts the most recent release made with cfg, as long as it has not been
+// pushed: it deletes the tag for the version in the version file, if there is
+// one, and moves the branch back to the commit before HEAD, restoring the files
+// the release commit changed (git reset --keep HEAD~1).
+//
+// It refuses unless the working tree has no uncommitted changes to tracked files,
+// HEAD is the commit that last changed the version file, and the tag for the
+// current version, if it exists, points at HEAD. If the branch's upstream, or the
+// branch given by cfg.Remote and cfg.TrackingBranch, contains HEAD as of the last
+// fetch, it returns an error wrapping ErrPushed unless cfg.Force is set. A tag
+// pushed without its branch cannot be detected, and is only deleted locally.
+//
+// In the returned VersionMeta, OldVersion is the version that was undone,
+// NewVersion the version restored, and Tag the tag deleted, if any.
+func Undo(cfg Config) (VersionMeta, error) {
+	meta := VersionMeta{BumpType: "undo"}
+	cfg, err := cfg.withResolvedPaths()
+	if err != nil {
+		return meta, err
+	}
+	if err := cfg.checkGit(); err != nil {
+		return meta, err
+	}
+	g := cfg.git()
+
+	head, err := g.RevParse(cfg.Dir, "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return meta, fmt.Errorf("%w; there is no release to undo", ErrNoCommits)
+	}
+	if _, err := g.RevParse(cfg.Dir, "--verify", "--quiet", "HEAD~1"); err != nil {
+		return meta, fmt.Errorf("HEAD is the root commit; refusing to undo it")
+	}
+
+	// Uncommitted changes could be mistaken for, or lost with, the release.
+	status, err := g.Status(cfg.Dir)
+	if err != nil {
+		return meta, fmt.Errorf("failed to check git status: %w", err)
+	}
+	var dirty []string
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) > 3 && !strings.HasPrefix(line, "??") {
+			dirty = append(dirty, strings.TrimSpace(line[3:]))
+		}
+	}
+	if len(dirty) > 0 {
+		return meta, fmt.Errorf("%w; commit or stash %v before undoing a release", ErrDirtyWorkingTree, dirty)
+	}
+
+	// HEAD must be the release commit: the last one to change the version file.
+	if _, err := os.Stat(cfg.VersionFile); err != nil {
+		return meta, fmt.Errorf("failed to read version file: %w", err)
+	}
+	current, err := readConfiguredVersion(cfg, false)
+	if err != nil {
+		return meta, err
+	}
+	meta.OldVersion = current
+	last, err := g.RevList(cfg.Dir, "-1", "HEAD", "--", filepath.Clean(cfg.VersionFile))
+	if err != nil || last != head {
+		return meta, fmt.Errorf("HEAD did not change %s; refusing to undo a commit that is not a release", cfg.VersionFile)
+	}
+
+	tagName := cfg.tagName(current)
+	hasTag := tagExists(g, cfg.Dir, tagName)
+	if hasTag {
+		target, err := g.RevParse(cfg.Dir, "--verify", "--quiet", "refs/tags/"+tagName+"^{commit}")
+		if err != nil {
+			return meta, fmt.Errorf("failed to resolve tag %s: %w", tagName, err)
+		}
+		if target != head {
+			return meta, fmt.Errorf("tag %s does not point at HEAD; refusing to undo", tagName)
+		}
+		meta.Tag = tagName
+	}
+
+	// Rewriting commits others may have pulled needs an explicit Force.
+	if ref, upstream, err := cfg.upstreamRef(g); err == nil {
+		ahead, err := g.RevList(cfg.Dir, "--count", ref+"..HEAD")
+		if err != nil {
+			return meta, err
+		}
+		if ahead == "0" {
+			if !cfg.Force {
+				return meta, fmt.Errorf("%w: %s already contains HEAD; refusing to undo without Force (-force)", ErrPushed, upstream)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %s already contains the release commit; push with care after undoing it\n", upstream)
+		}
+	}
+
+	if hasTag {
+		if err := g.DeleteTag(cfg.Dir, tagName); err != nil {
+			return meta, fmt.Errorf("failed to delete tag %s: %w", tagName, err)
+		}
+		cfg.emit(Event{Kind: EventGitStep, Step: "delete-tag"})
+	}
+	if err := g.ResetKeep(cfg.Dir, "HEAD~1"); err != nil {
+		if hasTag {
+			message, tagErr := cfg.commitTagMessage(g, head)
+			if tagErr == nil {
+				tagErr = g.Tag(cfg.Dir, tagName, head, message)
+			}
+			if tagErr != nil {
+				return meta, fmt.Errorf("failed to undo the release commit: %w; restoring tag %s also failed: %v", err, tagName, tagErr)
+			}
+		}
+		return meta, fmt.Errorf("failed to undo the release commit: %w", err)
+	}
+	cfg.emit(Event{Kind: EventGitStep, Step: "reset"})
+
+	if meta.NewVersion, err = readConfiguredVersion(cfg, false); err != nil {
+		return meta, err
+	}
+	meta.UpdatedFiles = []string{cfg.VersionFile}
+	return meta, nil
+}
@@ -0,0 +1,165 @@
+package goversion
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// releaseForUndoT creates a repository at 1.2.3, releases a minor bump that
+// also adds a section to CHANGELOG.md, and returns the repository, the release
+// configuration, and the commit before the release.
+func releaseForUndoT(t *testing.T) (string, Config, string) {
+	t.Helper()
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"CHANGELOG.md": "# Changelog\n",
+	})
+	commitAllT(t, tmpDir, "initial commit")
+	before := gitT(t, tmpDir, "rev-parse", "HEAD")
+	cfg := Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+		Changelog:   "CHANGELOG.md",
+	}
+	if _, err := RunWithConfig(cfg); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	return tmpDir, cfg, before
+}
+
+// TestUndo verifies that an unpushed release is undone: its tag is deleted,
+// the branch is back at the previous commit, and every file the release
+// commit changed holds its previous content again.
+func TestUndo(t *testing.T) {
+	tmpDir, cfg, before := releaseForUndoT(t)
+	writeFilesT(t, tmpDir, map[string]string{"build.log": "untracked\n"})
+
+	var steps []string
+	cfg.OnEvent = func(e Event) { steps = append(steps, e.Step) }
+	meta, err := Undo(cfg)
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if meta.OldVersion != "1.3.0" || meta.NewVersion != "1.2.3" || meta.Tag != "v1.3.0" {
+		t.Errorf("meta = %+v, want 1.3.0 undone to 1.2.3 with tag v1.3.0", meta)
+	}
+	if got := strings.Join(steps, ","); got != "delete-tag,reset" {
+		t.Errorf("steps = %s, want delete-tag,reset", got)
+	}
+	if head := gitT(t, tmpDir, "rev-parse", "HEAD"); head != before {
+		t.Errorf("HEAD = %s, want %s", head, before)
+	}
+	if tags := gitT(t, tmpDir, "tag", "--list"); tags != "" {
+		t.Errorf("tags = %q, want none", tags)
+	}
+	if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+		t.Errorf("version file = %q, want 1.2.3", v)
+	}
+	if got, _ := os.ReadFile(filepath.Join(tmpDir, "CHANGELOG.md")); string(got) != "# Changelog\n" {
+		t.Errorf("changelog = %q, want it restored", got)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "?? build.log" {
+		t.Errorf("status = %q, want only the untracked file", status)
+	}
+}
+
+// TestUndoRefuses verifies the guards that keep Undo from removing anything
+// but an unpushed release commit and its tag.
+func TestUndoRefuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{
+			name: "dirty",
+			setup: func(t *testing.T, dir string) {
+				writeFilesT(t, dir, map[string]string{"CHANGELOG.md": "# Edited\n"})
+			},
+			wantErr: "commit or stash",
+		},
+		{
+			name: "not a release commit",
+			setup: func(t *testing.T, dir string) {
+				writeFilesT(t, dir, map[string]string{"other.go": "package foo\n"})
+				commitAllT(t, dir, "later work")
+			},
+			wantErr: "HEAD did not change",
+		},
+		{
+			name: "tag elsewhere",
+			setup: func(t *testing.T, dir string) {
+				gitT(t, dir, "tag", "-f", "v1.3.0", "HEAD~1")
+			},
+			wantErr: "tag v1.3.0 does not point at HEAD",
+		},
+		{
+			name: "pushed",
+			setup: func(t *testing.T, dir string) {
+				setUpstreamT(t, dir, "HEAD")
+			},
+			wantErr: "already contains HEAD",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir, cfg, _ := releaseForUndoT(t)
+			tc.setup(t, tmpDir)
+			head := gitT(t, tmpDir, "rev-parse", "HEAD")
+			tags := gitT(t, tmpDir, "tag", "--list")
+			_, err := Undo(cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Undo error = %v, want %q", err, tc.wantErr)
+			}
+			if got := gitT(t, tmpDir, "rev-parse", "HEAD"); got != head {
+				t.Errorf("HEAD moved to %s", got)
+			}
+			if got := gitT(t, tmpDir, "tag", "--list"); got != tags {
+				t.Errorf("tags = %q, want %q", got, tags)
+			}
+		})
+	}
+}
+
+// TestUndoPushed verifies that a release its upstream already contains is only
+// undone with Force, while one the upstream lacks is undone without it.
+func TestUndoPushed(t *testing.T) {
+	tmpDir, cfg, before := releaseForUndoT(t)
+	setUpstreamT(t, tmpDir, "HEAD")
+	if _, err := Undo(cfg); !errors.Is(err, ErrPushed) {
+		t.Fatalf("Undo error = %v, want ErrPushed", err)
+	}
+	cfg.Force = true
+	if _, err := Undo(cfg); err != nil {
+		t.Fatalf("Undo with Force failed: %v", err)
+	}
+	if head := gitT(t, tmpDir, "rev-parse", "HEAD"); head != before {
+		t.Errorf("HEAD = %s, want %s", head, before)
+	}
+
+	tmpDir, cfg, before = releaseForUndoT(t)
+	setUpstreamT(t, tmpDir, "HEAD~1")
+	if _, err := Undo(cfg); err != nil {
+		t.Fatalf("Undo of an unpushed release failed: %v", err)
+	}
+	if head := gitT(t, tmpDir, "rev-parse", "HEAD"); head != before {
+		t.Errorf("HEAD = %s, want %s", head, before)
+	}
+}
+
+// setUpstreamT makes the current branch of dir track origin's branch of the
+// same name, recorded as last fetched at rev.
+func setUpstreamT(t *testing.T, dir, rev string) {
+	t.Helper()
+	branch := gitT(t, dir, "rev-parse", "--abbrev-ref", "HEAD")
+	gitT(t, dir, "remote", "add", "origin", t.TempDir())
+	gitT(t, dir, "update-ref", "refs/remotes/origin/"+branch, rev)
+	gitT(t, dir, "config", "branch."+branch+".remote", "origin")
+	gitT(t, dir, "config", "branch."+branch+".merge", "refs/heads/"+branch)
+}
@@ -0,0 +1,66 @@
+package goversion
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Version is a semantic version split into its components.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string // Dot-separated prerelease identifiers without the leading "-", e.g. "rc.1".
+	Build      string // Build metadata without the leading "+", e.g. "build.5".
+}
+
+// ParseVersion parses a full semantic version such as "1.2.3-rc.1+build.5",
+// with or without a "v" prefix. Shorthands such as "1.2" are rejected.
+func ParseVersion(s string) (Version, error) {
+	v := "v" + strings.TrimPrefix(s, "v")
+	if !semver.IsValid(v) {
+		return Version{}, fmt.Errorf("version %q is not valid semver", s)
+	}
+	if err := checkPrereleaseIdentifiers(v); err != nil {
+		return Version{}, fmt.Errorf("version %q is not valid semver: %w", s, err)
+	}
+	major, minor, patch, prerelease, err := parseSemVer(v)
+	if err != nil {
+		return Version{}, err
+	}
+	return Version{
+		Major:      major,
+		Minor:      minor,
+		Patch:      patch,
+		Prerelease: prerelease,
+		Build:      strings.TrimPrefix(semver.Build(v), "+"),
+	}, nil
+}
+
+// String returns the version without a "v" prefix, e.g. "1.2.3-rc.1+build.5".
+func (v Version) String() string {
+	s := strings.TrimPrefix(formatSemVer(v.Major, v.Minor, v.Patch, v.Prerelease), "v")
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Bump returns the version that the bump keyword kind (major, minor, patch,
+// premajor, preminor, prepatch, prerelease, or prerelease-same) produces from v,
+// as for the CLI. Build metadata is dropped.
+func (v Version) Bump(kind string) (Version, error) {
+	bumped, err := bumpVersion(formatSemVer(v.Major, v.Minor, v.Patch, v.Prerelease), kind)
+	if err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(bumped)
+}
+
+// Compare returns -1, 0, or +1 as v has lower, equal, or higher precedence than
+// other. Build metadata is ignored, as the semver specification requires.
+func (v Version) Compare(other Version) int {
+	return CompareVersions(v.String(), other.String(), false)
+}
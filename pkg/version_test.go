@@ -0,0 +1,108 @@
+package goversion
+
+import "testing"
+
+// TestParseVersion verifies that versions are split into their components and
+// that String restores the input, without any "v" prefix.
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Version
+		wantStr string
+		wantErr bool
+	}{
+		{in: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}, wantStr: "1.2.3"},
+		{in: "v0.10.0", want: Version{Minor: 10}, wantStr: "0.10.0"},
+		{in: "2.0.0-rc.1", want: Version{Major: 2, Prerelease: "rc.1"}, wantStr: "2.0.0-rc.1"},
+		{in: "1.2.3+build.5", want: Version{Major: 1, Minor: 2, Patch: 3, Build: "build.5"}, wantStr: "1.2.3+build.5"},
+		{in: "1.2.3-beta-2.x+sha.abc-1", want: Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "beta-2.x", Build: "sha.abc-1"}, wantStr: "1.2.3-beta-2.x+sha.abc-1"},
+		{in: "1.2", wantErr: true},
+		{in: "1.2.3-01", wantErr: true},
+		{in: "dev", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseVersion(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseVersion(%q) = %+v, want an error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseVersion(%q) failed: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseVersion(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+		if s := got.String(); s != tc.wantStr {
+			t.Errorf("ParseVersion(%q).String() = %q, want %q", tc.in, s, tc.wantStr)
+		}
+	}
+}
+
+// TestVersionBump verifies each bump keyword, and that build metadata is dropped.
+func TestVersionBump(t *testing.T) {
+	tests := []struct {
+		from, kind, want string
+	}{
+		{"1.2.3+build.5", "major", "2.0.0"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3-rc.1", "patch", "1.2.4"},
+		{"1.2.3", "premajor", "2.0.0-0"},
+		{"1.2.3", "preminor", "1.3.0-0"},
+		{"1.2.3", "prepatch", "1.2.4-0"},
+		{"1.2.3", "prerelease", "1.2.4-0"},
+		{"1.2.3-rc.1", "prerelease", "1.2.3-rc.2"},
+		{"1.2.3-beta", "prerelease", "1.2.3-beta.0"},
+		{"1.2.3", "prerelease-same", "1.2.3-0"},
+	}
+	for _, tc := range tests {
+		v, err := ParseVersion(tc.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := v.Bump(tc.kind)
+		if err != nil {
+			t.Errorf("%s.Bump(%q) failed: %v", tc.from, tc.kind, err)
+			continue
+		}
+		if got.String() != tc.want {
+			t.Errorf("%s.Bump(%q) = %s, want %s", tc.from, tc.kind, got, tc.want)
+		}
+	}
+	if _, err := (Version{Major: 1}).Bump("sideways"); err == nil {
+		t.Error("expected an error for an unknown bump keyword")
+	}
+}
+
+// TestVersionCompare verifies semver precedence, including prereleases, and
+// that build metadata is ignored.
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.4", -1},
+		{"2.0.0", "1.9.9", 1},
+		{"1.2.3-rc.1", "1.2.3", -1},
+		{"1.2.3-alpha", "1.2.3-alpha.1", -1},
+		{"1.2.3-rc.2", "1.2.3-rc.10", -1},
+		{"1.2.3-beta", "1.2.3-alpha.9", 1},
+		{"1.2.3+1", "1.2.3+2", 0},
+		{"1.2.3-rc.1+b", "1.2.3-rc.1", 0},
+	}
+	for _, tc := range tests {
+		a, err := ParseVersion(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseVersion(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Compare(b); got != tc.want {
+			t.Errorf("%s.Compare(%s) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package goversion
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// yamlKeyLine matches a block-mapping entry: an optionally quoted key, a colon, and
+// an optional inline value.
+var yamlKeyLine = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#'"{\[][^:#]*?)[ \t]*:(?:[ \t]+(.*))?$`)
+
+// yamlVersionValue matches a scalar value holding a semantic version, optionally
+// quoted and prefixed with "v", followed by an optional comment.
+var yamlVersionValue = regexp.MustCompile(`^(["']?)v?(` + semverCore + `)(["']?)[ \t]*(?:#.*)?$`)
+
+// yamlOpenAPIKey matches the top-level key that identifies an OpenAPI or Swagger document.
+var yamlOpenAPIKey = regexp.MustCompile(`(?m)^(?:openapi|swagger)[ \t]*:`)
+
+// splitKeyPath splits a bump file spec such as "openapi.yaml#info.version" into the
+// file path and the YAML key path. keyPath is empty if spec has no "#" suffix.
+func splitKeyPath(spec string) (path, keyPath string) {
+	i := strings.LastIndex(spec, "#")
+	if i <= 0 || i == len(spec)-1 {
+		return spec, ""
+	}
+	return spec[:i], spec[i+1:]
+}
+
+// findYAMLKey returns the version held by every block-mapping key in content whose
+// dotted path from the document root equals keyPath (e.g. "info.version"). Only
+// block style YAML is understood: flow mappings, anchors, and multi-line scalars
+// are skipped, and values that are not semantic versions are ignored.
+func findYAMLKey(content []byte, keyPath string) []VersionMatch {
+	type entry struct {
+		indent int
+		key    string
+	}
+	var (
+		stack   []entry
+		matches []VersionMatch
+		offset  int
+	)
+	for lineNum, line := range bytes.SplitAfter(content, []byte("\n")) {
+		start := offset
+		offset += len(line)
+		text := strings.TrimRight(string(line), "\r\n")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "---") {
+			continue
+		}
+		indent := len(text) - len(trimmed)
+		// A list may sit at the same indentation as its parent key, so a list item
+		// only closes an equally indented entry if that entry is a previous item.
+		isItem := strings.HasPrefix(trimmed, "- ") || trimmed == "-"
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.indent < indent || (top.indent == indent && isItem && top.key != "[*]") {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		// A list item opens an anonymous element; a mapping on the same line is
+		// nested inside it.
+		for strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			stack = append(stack, entry{indent: indent, key: "[*]"})
+			rest := strings.TrimLeft(strings.TrimPrefix(trimmed, "-"), " ")
+			indent += len(trimmed) - len(rest)
+			trimmed = rest
+		}
+		m := yamlKeyLine.FindStringSubmatchIndex(trimmed)
+		if m == nil {
+			continue
+		}
+		key := strings.Trim(trimmed[m[2]:m[3]], `"'`)
+		stack = append(stack, entry{indent: indent, key: key})
+		if m[4] < 0 {
+			continue
+		}
+		var path strings.Builder
+		for i, e := range stack {
+			if i > 0 && e.key != "[*]" {
+				path.WriteByte('.')
+			}
+			path.WriteString(e.key)
+		}
+		if path.String() != keyPath {
+			continue
+		}
+		value := trimmed[m[4]:m[5]]
+		vm := yamlVersionValue.FindStringSubmatchIndex(value)
+		if vm == nil || value[vm[2]:vm[3]] != value[vm[6]:vm[7]] {
+			continue
+		}
+		valueStart := start + len(text) - len(trimmed) + m[4]
+		matches = append(matches, VersionMatch{
+			Pattern:   "yaml-key",
+			Line:      lineNum + 1,
+			Version:   value[vm[4]:vm[5]],
+			FullMatch: strings.TrimSpace(trimmed),
+			Prefix:    trimmed[:m[4]] + value[:vm[4]],
+			Suffix:    value[vm[5]:],
+			Start:     valueStart + vm[4],
+			End:       valueStart + vm[5],
+		})
+	}
+	return matches
+}
+
+// findOpenAPIVersion returns the API version at info.version if content is an
+// OpenAPI or Swagger document, so that the spec version in "openapi: 3.0.0" is
+// never mistaken for it.
+func findOpenAPIVersion(content []byte) (VersionMatch, bool) {
+	if !yamlOpenAPIKey.Match(content) {
+		return VersionMatch{}, false
+	}
+	matches := findYAMLKey(content, "info.version")
+	if len(matches) == 0 {
+		return VersionMatch{}, false
+	}
+	matches[0].Pattern = "openapi-info-version"
+	return matches[0], true
+}
+
+// FindYAMLKeyInFile returns the version held by the block-mapping key at keyPath,
+// a dotted path from the document root such as "info.version", in the YAML file at path.
+// It returns an error if the key is missing or does not hold a semantic version.
+func FindYAMLKeyInFile(path, keyPath string) (VersionMatch, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return VersionMatch{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	matches := findYAMLKey(content, keyPath)
+	if len(matches) == 0 {
+		return VersionMatch{}, fmt.Errorf("no version found at key %q in %s", keyPath, path)
+	}
+	return matches[0], nil
+}
+
+// BumpYAMLListInFile sets the version at keyPath, which selects list elements with
+// "[*]" (e.g. "dependencies[*].version"), to newVersion in the YAML file at path,
+// but only in the elements whose version equals the file's own top-level version,
+// so that the versions of unrelated entries, such as external subcharts in a Helm
+// Chart.yaml, are left alone. It must run before the top-level version is bumped.
+// It returns an error if the file has no top-level version.
+func BumpYAMLListInFile(path, keyPath, newVersion string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	own := findYAMLKey(content, "version")
+	if len(own) == 0 {
+		return fmt.Errorf("no top-level version in %s to compare %s against", path, keyPath)
+	}
+	newVersion = strings.TrimPrefix(newVersion, "v")
+	var out bytes.Buffer
+	last := 0
+	for _, m := range findYAMLKey(content, keyPath) {
+		if m.Version != own[0].Version {
+			continue
+		}
+		out.Write(content[last:m.Start])
+		out.WriteString(newVersion)
+		last = m.End
+	}
+	if last == 0 {
+		return nil
+	}
+	out.Write(content[last:])
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// BumpYAMLKeyInFile sets the version at keyPath in the YAML file at path to newVersion,
+// leaving the rest of the file untouched. A "v" prefix and quotes on the field are kept.
+func BumpYAMLKeyInFile(path, keyPath, newVersion string) error {
+	match, err := FindYAMLKeyInFile(path, keyPath)
+	if err != nil {
+		return err
+	}
+	return ReplaceVersionInFile(path, match, newVersion)
+}
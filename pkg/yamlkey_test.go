@@ -0,0 +1,211 @@
+package goversion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testOpenAPISpec = `openapi: 3.0.0
+info:
+  title: Example API
+  version: "1.2.3" # API version
+servers:
+  - url: https://api.example.com
+components:
+  schemas:
+    Tool:
+      version: 9.9.9
+`
+
+// TestOpenAPIInfoVersion verifies that info.version is bumped in an OpenAPI spec,
+// both when targeted explicitly and when the file is bumped without a key path,
+// and that the "openapi: 3.0.0" spec version is never touched.
+func TestOpenAPIInfoVersion(t *testing.T) {
+	want := `openapi: 3.0.0
+info:
+  title: Example API
+  version: "1.3.0" # API version
+servers:
+  - url: https://api.example.com
+components:
+  schemas:
+    Tool:
+      version: 9.9.9
+`
+	tests := []struct {
+		name string
+		bump func(path string) error
+	}{
+		{name: "key path", bump: func(path string) error { return BumpYAMLKeyInFile(path, "info.version", "1.3.0") }},
+		{name: "detected", bump: func(path string) error { return BumpVersionInFile(path, "1.3.0") }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "openapi.yaml")
+			if err := os.WriteFile(path, []byte(testOpenAPISpec), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.bump(path); err != nil {
+				t.Fatalf("bump failed: %v", err)
+			}
+			got, _ := os.ReadFile(path)
+			if string(got) != want {
+				t.Errorf("content mismatch\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestFindYAMLKey verifies key path resolution across nesting, lists, and quoting.
+func TestFindYAMLKey(t *testing.T) {
+	content := []byte(`version: 0.1.0
+info:
+  version: 1.2.3
+  nested:
+    version: 'v2.0.0'
+dependencies:
+- name: a
+  version: 3.0.0
+-   name: b
+    version: 3.1.0
+other: {version: 4.0.0}
+`)
+	tests := []struct {
+		keyPath string
+		want    []string
+	}{
+		{keyPath: "version", want: []string{"0.1.0"}},
+		{keyPath: "info.version", want: []string{"1.2.3"}},
+		{keyPath: "info.nested.version", want: []string{"2.0.0"}},
+		{keyPath: "dependencies[*].version", want: []string{"3.0.0", "3.1.0"}},
+		{keyPath: "other.version", want: nil},
+		{keyPath: "info.missing", want: nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.keyPath, func(t *testing.T) {
+			matches := findYAMLKey(content, tc.keyPath)
+			var got []string
+			for _, m := range matches {
+				got = append(got, m.Version)
+				if string(content[m.Start:m.End]) != m.Version {
+					t.Errorf("offsets %d:%d do not span %q", m.Start, m.End, m.Version)
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("versions = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("versions = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+// TestRunBumpFileKeyPath verifies that a "file#key.path" bump file is bumped at that
+// key and committed under its plain path.
+func TestRunBumpFileKeyPath(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go":   "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"openapi.yaml": testOpenAPISpec,
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	meta, err := RunWithConfig(Config{
+		Dir:         tmpDir,
+		VersionFile: "version.go",
+		VersionArg:  "minor",
+		ExtraFiles:  []string{"version.go"},
+		BumpFiles:   []string{"openapi.yaml#info.version"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	match, err := FindYAMLKeyInFile(filepath.Join(tmpDir, "openapi.yaml"), "info.version")
+	if err != nil || match.Version != "1.3.0" {
+		t.Errorf("info.version = %q (%v), want 1.3.0", match.Version, err)
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree after the release, got:\n%s", status)
+	}
+	if want := filepath.Join(tmpDir, "openapi.yaml"); meta.UpdatedFiles[len(meta.UpdatedFiles)-1] != want {
+		t.Errorf("UpdatedFiles = %v, want it to end with %s", meta.UpdatedFiles, want)
+	}
+}
+
+const testUmbrellaChart = `apiVersion: v2
+name: platform
+version: 1.2.3
+appVersion: "1.2.3"
+dependencies:
+  - name: api
+    version: 1.2.3
+    repository: file://charts/api
+  - name: postgresql
+    version: 12.1.6
+    repository: https://charts.bitnami.com/bitnami
+  - name: worker
+    version: "1.2.3"
+    repository: file://charts/worker
+`
+
+// TestRunBumpYAMLList verifies that only the Chart.yaml dependencies whose version
+// equals the chart's own version are bumped along with it.
+func TestRunBumpYAMLList(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"Chart.yaml": testUmbrellaChart,
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	if _, err := RunWithConfig(Config{
+		Dir:           tmpDir,
+		VersionFile:   "version.go",
+		VersionArg:    "minor",
+		ExtraFiles:    []string{"version.go"},
+		BumpAllFields: []string{"Chart.yaml"},
+		BumpYAMLLists: []string{"Chart.yaml#dependencies[*].version"},
+	}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := strings.NewReplacer("version: 1.2.3", "version: 1.3.0", `"1.2.3"`, `"1.3.0"`).Replace(testUmbrellaChart)
+	got, _ := os.ReadFile(filepath.Join(tmpDir, "Chart.yaml"))
+	if string(got) != want {
+		t.Errorf("Chart.yaml mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+	if !strings.Contains(string(got), "version: 12.1.6") {
+		t.Error("the external postgresql subchart version must not change")
+	}
+	if status := gitT(t, tmpDir, "status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree after the release, got:\n%s", status)
+	}
+}
+
+// TestBumpYAMLListRequiresListPath verifies that a list target without [*] is
+// refused before anything is written.
+func TestBumpYAMLListRequiresListPath(t *testing.T) {
+	tmpDir := initTestRepo(t)
+	writeFilesT(t, tmpDir, map[string]string{
+		"version.go": "package foo\n\nvar (\n\tVersion = \"1.2.3\"\n)\n",
+		"Chart.yaml": testUmbrellaChart,
+	})
+	commitAllT(t, tmpDir, "initial commit")
+
+	_, err := RunWithConfig(Config{
+		Dir:           tmpDir,
+		VersionFile:   "version.go",
+		VersionArg:    "minor",
+		BumpYAMLLists: []string{"Chart.yaml#dependencies.version"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "[*]") {
+		t.Fatalf("Run error = %v, want a list path error", err)
+	}
+	if v, _ := readCurrentVersion(filepath.Join(tmpDir, "version.go")); v != "1.2.3" {
+		t.Errorf("version file = %q, want it untouched", v)
+	}
+}